@@ -1,6 +1,6 @@
 module api
 
-go 1.15
+go 1.16
 
 require (
 	github.com/badoux/checkmail v1.2.1
@@ -8,5 +8,7 @@ require (
 	github.com/go-sql-driver/mysql v1.5.0
 	github.com/gorilla/mux v1.8.0
 	github.com/joho/godotenv v1.3.0
+	github.com/lib/pq v1.10.9
+	github.com/mattn/go-sqlite3 v1.14.16
 	golang.org/x/crypto v0.0.0-20201117144127-c1f2f97bffc9
 )
@@ -1,19 +1,84 @@
 package main
 
 import (
+	"api/src/audit"
+	"api/src/authentication"
 	"api/src/config"
+	"api/src/container"
+	"api/src/database"
+	"api/src/mailer"
+	"api/src/migrations"
+	"api/src/revocation"
 	"api/src/router"
+	"api/src/security"
+	"api/src/server"
+	"api/src/throttling"
+	"database/sql"
 	"fmt"
 	"log"
-	"net/http"
+	"os"
 )
 
 func main() {
 	config.Load()
 
-	fmt.Printf("Api started at port %d", config.Port)
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if error := migrations.Command(os.Args[2:], os.Stdout); error != nil {
+			log.Fatal(error)
+		}
 
-	router := router.Generate()
+		return
+	}
 
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", config.Port), router))
+	if error := authentication.LoadKeys(); error != nil {
+		log.Fatal(error)
+	}
+
+	db, dialect, error := database.Connect()
+
+	if error != nil {
+		log.Fatal(error)
+	}
+
+	dependencies := container.New(db, dialect)
+
+	if error = configure(db, dialect); error != nil {
+		log.Fatal(error)
+	}
+
+	fmt.Printf("Api started at port %d\n", config.Port)
+
+	router := router.Generate(dependencies)
+
+	// Emails and audit entries still queued are written before the pool they may use is closed
+	error = server.Run(server.New(router), mailer.Close, audit.Close, func() {
+		if error := db.Close(); error != nil {
+			log.Printf("Could not close the database: %v", error)
+		}
+	})
+
+	if error != nil {
+		log.Fatal(error)
+	}
+
+	log.Print("Api stopped")
+}
+
+// configure set up the services the requests rely on, on the database of the api
+func configure(db *sql.DB, dialect database.Dialect) error {
+	if error := revocation.Configure(db, dialect); error != nil {
+		return error
+	}
+
+	if error := mailer.Configure(); error != nil {
+		return error
+	}
+
+	if error := audit.Configure(db, dialect); error != nil {
+		return error
+	}
+
+	throttling.Configure()
+
+	return security.Configure()
 }
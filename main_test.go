@@ -0,0 +1,87 @@
+package main
+
+import (
+	"api/src/audit"
+	"api/src/config"
+	"api/src/database"
+	"api/src/mailer"
+	"api/src/repositories/conformance"
+	"api/src/revocation"
+	"testing"
+	"time"
+)
+
+// TestConfigureOnEveryDialect starts the api on a database of each dialect, the servers are
+// reached through conformance.ServerVariables and skipped without them
+func TestConfigureOnEveryDialect(t *testing.T) {
+	config.MailDriver = "memory"
+	config.RevocationStore = "database"
+
+	defer func() { config.RevocationStore = "memory" }()
+
+	for _, dialect := range database.Dialects {
+		dialect := dialect
+
+		t.Run(dialect.Name, func(t *testing.T) {
+			testConfigure(t, dialect)
+		})
+	}
+}
+
+// testConfigure checks the default configuration starts on the dialect, with the revocations
+// and the audit written to its database
+func testConfigure(t *testing.T, dialect database.Dialect) {
+	db := conformance.OpenDatabase(t, dialect)
+
+	error := configure(db, dialect)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	defer mailer.Close()
+
+	now := time.Now()
+
+	if error = revocation.Default.RevokeToken("token", now.Add(time.Hour)); error != nil {
+		t.Fatal(error)
+	}
+
+	if error = revocation.Default.RevokeUser(1, now); error != nil {
+		t.Fatal(error)
+	}
+
+	// Revoking again keeps the latest moment
+	if error = revocation.Default.RevokeUser(1, now.Add(-time.Hour)); error != nil {
+		t.Fatal(error)
+	}
+
+	if revoked, error := revocation.Default.IsRevoked("token", 2, now); error != nil || !revoked {
+		t.Fatalf("Revoked token accepted: %v", error)
+	}
+
+	if revoked, error := revocation.Default.IsRevoked("other", 1, now.Add(-time.Minute)); error != nil || !revoked {
+		t.Fatalf("Token of a revoked user accepted: %v", error)
+	}
+
+	// Tokens issued right after the revocation, in the same second, are valid
+	if revoked, error := revocation.Default.IsRevoked("other", 1, now.Add(time.Millisecond)); error != nil || revoked {
+		t.Fatalf("Token issued after the revocation rejected: %v", error)
+	}
+
+	if revoked, error := revocation.Default.IsRevoked("other", 2, now); error != nil || revoked {
+		t.Fatalf("Valid token rejected: %v", error)
+	}
+
+	if error = audit.Default.Write(audit.Entry{ActorID: 1, UserID: 2, TokenID: "token", Method: "GET", Path: "/users", Status: 200, IP: "127.0.0.1", CreatedAt: now}); error != nil {
+		t.Fatal(error)
+	}
+
+	audit.Close()
+
+	var entries int
+
+	if error = db.QueryRow("SELECT count(*) FROM impersonation_audit WHERE actor_id = 1 AND user_id = 2").Scan(&entries); error != nil || entries != 1 {
+		t.Fatalf("Expected 1 audit entry, found %d: %v", entries, error)
+	}
+}
@@ -5,6 +5,9 @@ values
 ("carlos", "carlos00315", "carlos@gmail.com", "$2a$10$c445dxy0b4Yaizy5i0oXk.k8DTjLB1x.LucLAtyDSG0Oj8PxP4iuC"),
 ("pedro", "pedro00315", "pedro@gmail.com", "$2a$10$c445dxy0b4Yaizy5i0oXk.k8DTjLB1x.LucLAtyDSG0Oj8PxP4iuC");
 
+update users set verified_at = current_timestamp();
+update users set roles = "user,admin" where email = "miller@gmail.com";
+
 insert into followers(user_id, follower_id)
 values
 (1, 2),
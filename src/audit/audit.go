@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Entry is a request made by a user on behalf of another one
+type Entry struct {
+	ActorID   uint64    `json:"actorId"`
+	UserID    uint64    `json:"userId"`
+	TokenID   string    `json:"tokenId"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Status    int       `json:"status"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Writer persists audit entries
+type Writer interface {
+	Write(entry Entry) error
+}
+
+// Default is the writer of the audit entries
+var Default Writer = NewMemoryWriter()
+
+// Configure select the writer set in config.AuditDriver, entries are written in background
+// so auditing does not slow the audited requests
+func Configure(db *sql.DB, dialect database.Dialect) error {
+	var writer Writer
+
+	switch config.AuditDriver {
+	case "", "database", "mysql":
+		writer = NewSQLWriter(db, dialect)
+	case "log":
+		writer = NewLogWriter()
+	case "memory":
+		writer = NewMemoryWriter()
+	default:
+		return fmt.Errorf("Unknown audit driver %s", config.AuditDriver)
+	}
+
+	Default = NewWorker(writer, 100)
+
+	return nil
+}
+
+// Reserve hold a place in the default writer for the entry of a request about to run, see
+// Worker.Reserve. Writers without a queue write the entry when it is given
+func Reserve(ctx context.Context) (func(Entry), error) {
+	if worker, ok := Default.(*Worker); ok {
+		return worker.Reserve(ctx)
+	}
+
+	writer := Default
+
+	return func(entry Entry) {
+		if error := writer.Write(entry); error != nil {
+			log.Printf("Could not write audit entry of user %d acting as %d: %v", entry.ActorID, entry.UserID, error)
+		}
+	}, nil
+}
+
+// Close stop the background writes once the entries waiting in them are written
+func Close() {
+	if worker, ok := Default.(*Worker); ok {
+		worker.Close()
+	}
+}
@@ -0,0 +1,27 @@
+package audit
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// LogWriter writes the entries as json lines in the application log
+type LogWriter struct{}
+
+// NewLogWriter returns a writer to the application log
+func NewLogWriter() *LogWriter {
+	return &LogWriter{}
+}
+
+// Write log the entry
+func (writer *LogWriter) Write(entry Entry) error {
+	line, error := json.Marshal(entry)
+
+	if error != nil {
+		return error
+	}
+
+	log.Printf("audit %s", line)
+
+	return nil
+}
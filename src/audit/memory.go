@@ -0,0 +1,32 @@
+package audit
+
+import "sync"
+
+// MemoryWriter keeps the entries in memory, used in tests
+type MemoryWriter struct {
+	mutex   sync.Mutex
+	entries []Entry
+}
+
+// NewMemoryWriter returns a writer without entries
+func NewMemoryWriter() *MemoryWriter {
+	return &MemoryWriter{}
+}
+
+// Write append the entry
+func (writer *MemoryWriter) Write(entry Entry) error {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	writer.entries = append(writer.entries, entry)
+
+	return nil
+}
+
+// Entries returns a copy of the entries written
+func (writer *MemoryWriter) Entries() []Entry {
+	writer.mutex.Lock()
+	defer writer.mutex.Unlock()
+
+	return append([]Entry{}, writer.entries...)
+}
@@ -0,0 +1,45 @@
+package audit
+
+import (
+	"api/src/database"
+	"database/sql"
+)
+
+// SQLWriter writes the entries in the impersonation_audit table
+type SQLWriter struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewSQLWriter returns a writer to a database of the dialect
+func NewSQLWriter(db *sql.DB, dialect database.Dialect) *SQLWriter {
+	return &SQLWriter{db, dialect}
+}
+
+// Write insert the entry
+func (writer *SQLWriter) Write(entry Entry) error {
+	statement, error := writer.db.Prepare(writer.dialect.Rebind(`
+	insert into impersonation_audit (actor_id, user_id, token_id, method, path, status, ip, createdAt)
+	values (?, ?, ?, ?, ?, ?, ?, ?)`))
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.Exec(
+		entry.ActorID,
+		entry.UserID,
+		entry.TokenID,
+		entry.Method,
+		entry.Path,
+		entry.Status,
+		entry.IP,
+		entry.CreatedAt,
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+)
+
+// Worker is a Writer that writes the entries in background through another writer
+type Worker struct {
+	writer  Writer
+	entries chan Entry
+	// slots holds a value for each entry queued or reserved, up to the size of the worker
+	slots    chan struct{}
+	mutex    sync.RWMutex
+	closed   bool
+	reserved sync.WaitGroup
+	done     sync.WaitGroup
+}
+
+// NewWorker starts a worker holding up to size entries waiting to be written
+func NewWorker(writer Writer, size int) *Worker {
+	worker := &Worker{
+		writer:  writer,
+		entries: make(chan Entry, size),
+		slots:   make(chan struct{}, size),
+	}
+
+	worker.done.Add(1)
+
+	go worker.work()
+
+	return worker
+}
+
+// Write enqueue the entry, failing when the worker is full or closed
+func (worker *Worker) Write(entry Entry) error {
+	worker.mutex.RLock()
+	defer worker.mutex.RUnlock()
+
+	if worker.closed {
+		return errors.New("Audit worker is closed")
+	}
+
+	select {
+	case worker.slots <- struct{}{}:
+		worker.entries <- entry
+		return nil
+	default:
+		return errors.New("Audit worker is full")
+	}
+}
+
+// Reserve hold a place for the entry of a request about to run, waiting for one while the
+// worker is full. The entry given to the returned function is always queued, so a request can
+// be refused before it runs when its entry could not be kept. The function must be called
+func (worker *Worker) Reserve(ctx context.Context) (func(Entry), error) {
+	worker.mutex.RLock()
+	defer worker.mutex.RUnlock()
+
+	if worker.closed {
+		return nil, errors.New("Audit worker is closed")
+	}
+
+	select {
+	case worker.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errors.New("Audit worker is full")
+	}
+
+	worker.reserved.Add(1)
+
+	return func(entry Entry) {
+		worker.entries <- entry
+		worker.reserved.Done()
+	}, nil
+}
+
+// Close stop accepting entries and wait the pending ones, the reserved included, to be written
+func (worker *Worker) Close() {
+	worker.mutex.Lock()
+
+	closing := !worker.closed
+	worker.closed = true
+
+	worker.mutex.Unlock()
+
+	if closing {
+		worker.reserved.Wait()
+		close(worker.entries)
+	}
+
+	worker.done.Wait()
+}
+
+func (worker *Worker) work() {
+	defer worker.done.Done()
+
+	for entry := range worker.entries {
+		if error := worker.writer.Write(entry); error != nil {
+			log.Printf("Could not write audit entry of user %d acting as %d: %v", entry.ActorID, entry.UserID, error)
+		}
+
+		<-worker.slots
+	}
+}
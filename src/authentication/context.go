@@ -0,0 +1,20 @@
+package authentication
+
+import (
+	"context"
+	"net/http"
+)
+
+type claimsContextKey struct{}
+
+// WithClaims returns the request carrying the claims of its authenticated credential,
+// which GetClaims returns from then on without parsing the request again
+func WithClaims(r *http.Request, claims Claims) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), claimsContextKey{}, claims))
+}
+
+func claimsFromContext(r *http.Request) (Claims, bool) {
+	claims, ok := r.Context().Value(claimsContextKey{}).(Claims)
+
+	return claims, ok
+}
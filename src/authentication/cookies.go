@@ -0,0 +1,29 @@
+package authentication
+
+import "net/http"
+
+// Cookies and header of the cookie mode, where browsers keep the tokens in cookies
+// the front-end scripts cannot read
+const (
+	AccessTokenCookie  = "access_token"
+	RefreshTokenCookie = "refresh_token"
+	// CSRFCookie is readable by the front-end, which sends its value back on CSRFHeader
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CookieAuthenticated report if the credential of the request comes from the cookies,
+// only then the request can be forged by another site
+func CookieAuthenticated(r *http.Request) bool {
+	if r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != "" {
+		return false
+	}
+
+	for _, name := range []string{AccessTokenCookie, RefreshTokenCookie} {
+		if cookie, error := r.Cookie(name); error == nil && cookie.Value != "" {
+			return true
+		}
+	}
+
+	return false
+}
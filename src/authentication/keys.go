@@ -0,0 +1,383 @@
+package authentication
+
+import (
+	"api/src/config"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+)
+
+// Key is a key able to verify tokens and, when its private part is known, to sign them
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.PrivateKey
+	PublicKey  crypto.PublicKey
+	// ActivatedAt is when the key started or starts signing as listed in the manifest, zero when
+	// it is not listed
+	ActivatedAt time.Time
+	// RetiresAt is when a key that signed before the signing key stops verifying, zero otherwise
+	RetiresAt time.Time
+}
+
+// KeyManifest is the keys.json file of the keys directory, holding the activation time of each key
+type KeyManifest map[string]struct {
+	ActivatedAt time.Time `json:"activatedAt"`
+}
+
+// keyManifestFile is the name of the manifest in the keys directory
+const keyManifestFile = "keys.json"
+
+// KeyRing holds the key used to sign new tokens and every key still accepted on verification
+type KeyRing struct {
+	Signing *Key
+	Keys    map[string]*Key
+}
+
+// JSONWebKey is the public part of a key as published in the JWKS document
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+	Curve     string `json:"crv,omitempty"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	X         string `json:"x,omitempty"`
+	Y         string `json:"y,omitempty"`
+}
+
+// JSONWebKeySet is the document served to let other services verify our tokens
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+var (
+	keyRingMutex sync.RWMutex
+	keyRing      *KeyRing
+)
+
+// LoadKeys read the key ring from config.KeysDirectory, without a directory tokens keep
+// being signed with HS256 and config.SecretKey
+func LoadKeys() error {
+	if config.KeysDirectory == "" {
+		return nil
+	}
+
+	ring, error := ReadKeyRing(config.KeysDirectory, config.SigningKeyID, config.KeyOverlapDuration)
+
+	if error != nil {
+		return error
+	}
+
+	keyRingMutex.Lock()
+	keyRing = ring
+	keyRingMutex.Unlock()
+
+	return nil
+}
+
+// ReadKeyRing load every PEM file of a directory, the key id is the file name without extension.
+// Private keys can sign, public keys only verify. The signing key is signingKeyID or the private
+// key activated last according to the manifest. The keys activated before the signing key keep
+// verifying for overlap after its activation, the others until they are removed
+func ReadKeyRing(directory, signingKeyID string, overlap time.Duration) (*KeyRing, error) {
+	files, error := filepath.Glob(filepath.Join(directory, "*.pem"))
+
+	if error != nil {
+		return nil, error
+	}
+
+	manifest, error := readKeyManifest(filepath.Join(directory, keyManifestFile))
+
+	if error != nil {
+		return nil, fmt.Errorf("Invalid key manifest: %v", error)
+	}
+
+	keys := map[string]*Key{}
+
+	for _, file := range files {
+		key, error := readKey(file)
+
+		if error != nil {
+			return nil, fmt.Errorf("Invalid key %s: %v", file, error)
+		}
+
+		if _, duplicated := keys[key.ID]; duplicated {
+			return nil, fmt.Errorf("Duplicated key id %s", key.ID)
+		}
+
+		key.ActivatedAt = manifest[key.ID].ActivatedAt
+		keys[key.ID] = key
+	}
+
+	signing, error := signingKey(keys, signingKeyID, time.Now())
+
+	if error != nil {
+		return nil, fmt.Errorf("%v in %s", error, directory)
+	}
+
+	for ID, key := range keys {
+		if ID != signing.ID && !key.ActivatedAt.IsZero() && key.ActivatedAt.Before(signing.ActivatedAt) {
+			key.RetiresAt = signing.ActivatedAt.Add(overlap)
+		}
+	}
+
+	return &KeyRing{Signing: signing, Keys: keys}, nil
+}
+
+// signingKey returns the key with signingKeyID or, without one, the private key activated last
+// until now. Keys activated later are published ahead of signing
+func signingKey(keys map[string]*Key, signingKeyID string, now time.Time) (*Key, error) {
+	if signingKeyID != "" {
+		if key, found := keys[signingKeyID]; found && key.PrivateKey != nil {
+			return key, nil
+		}
+
+		return nil, fmt.Errorf("No private key %s to sign tokens", signingKeyID)
+	}
+
+	var signing *Key
+	ambiguous := false
+
+	for _, key := range keys {
+		if key.PrivateKey == nil || key.ActivatedAt.After(now) {
+			continue
+		}
+
+		switch {
+		case signing == nil || key.ActivatedAt.After(signing.ActivatedAt):
+			signing = key
+			ambiguous = false
+		case key.ActivatedAt.Equal(signing.ActivatedAt):
+			ambiguous = true
+		}
+	}
+
+	if signing == nil {
+		return nil, errors.New("No private key to sign tokens")
+	}
+
+	if ambiguous {
+		return nil, errors.New("Several private keys could sign tokens, set the signing key id or their activation in " + keyManifestFile)
+	}
+
+	return signing, nil
+}
+
+// readKeyManifest returns the manifest of the keys directory, empty when there is none
+func readKeyManifest(file string) (KeyManifest, error) {
+	content, error := ioutil.ReadFile(file)
+
+	if os.IsNotExist(error) {
+		return KeyManifest{}, nil
+	}
+
+	if error != nil {
+		return nil, error
+	}
+
+	manifest := KeyManifest{}
+
+	if error := json.Unmarshal(content, &manifest); error != nil {
+		return nil, error
+	}
+
+	return manifest, nil
+}
+
+func readKey(file string) (*Key, error) {
+	content, error := ioutil.ReadFile(file)
+
+	if error != nil {
+		return nil, error
+	}
+
+	block, _ := pem.Decode(content)
+
+	if block == nil {
+		return nil, errors.New("No PEM block found")
+	}
+
+	key := &Key{
+		ID: strings.TrimSuffix(strings.TrimSuffix(filepath.Base(file), ".pem"), ".pub"),
+	}
+
+	switch block.Type {
+	case "PRIVATE KEY":
+		key.PrivateKey, error = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key.PrivateKey, error = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key.PrivateKey, error = x509.ParseECPrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key.PublicKey, error = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("Unsupported PEM block %s", block.Type)
+	}
+
+	if error != nil {
+		return nil, error
+	}
+
+	if key.PrivateKey != nil {
+		signer, ok := key.PrivateKey.(crypto.Signer)
+
+		if !ok {
+			return nil, errors.New("Unsupported private key")
+		}
+
+		key.PublicKey = signer.Public()
+	}
+
+	if key.Method, error = signingMethodOf(key.PublicKey); error != nil {
+		return nil, error
+	}
+
+	return key, nil
+}
+
+func signingMethodOf(publicKey crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch publicKey := publicKey.(type) {
+	case *rsa.PublicKey:
+		if publicKey.N.BitLen() < 2048 {
+			return nil, errors.New("RSA keys must have at least 2048 bits")
+		}
+
+		return jwt.SigningMethodRS256, nil
+	case *ecdsa.PublicKey:
+		switch publicKey.Curve {
+		case elliptic.P256():
+			return jwt.SigningMethodES256, nil
+		case elliptic.P384():
+			return jwt.SigningMethodES384, nil
+		case elliptic.P521():
+			return jwt.SigningMethodES512, nil
+		}
+	case ed25519.PublicKey:
+		return SigningMethodEdDSA, nil
+	}
+
+	return nil, errors.New("Unsupported key type")
+}
+
+// signToken sign claims with the current signing key, identifying it on the kid header
+func signToken(permissions jwt.MapClaims) (string, error) {
+	keyRingMutex.RLock()
+	ring := keyRing
+	keyRingMutex.RUnlock()
+
+	if ring == nil {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
+
+		return token.SignedString([]byte(config.SecretKey))
+	}
+
+	token := jwt.NewWithClaims(ring.Signing.Method, permissions)
+	token.Header["kid"] = ring.Signing.ID
+
+	return token.SignedString(ring.Signing.PrivateKey)
+}
+
+func getVerificationKey(token *jwt.Token) (interface{}, error) {
+	keyRingMutex.RLock()
+	ring := keyRing
+	keyRingMutex.RUnlock()
+
+	if ring == nil {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Invalid signature method %v", token.Header["alg"])
+		}
+
+		return config.SecretKey, nil
+	}
+
+	keyID, _ := token.Header["kid"].(string)
+
+	key, found := ring.Keys[keyID]
+
+	if !found || key.retired() {
+		return nil, fmt.Errorf("Unknown signing key %s", keyID)
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("Invalid signature method %v", token.Header["alg"])
+	}
+
+	return key.PublicKey, nil
+}
+
+// JWKS returns the public keys accepted on verification, empty when tokens are signed with a shared secret
+func JWKS() JSONWebKeySet {
+	keyRingMutex.RLock()
+	ring := keyRing
+	keyRingMutex.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+
+	if ring == nil {
+		return set
+	}
+
+	for _, key := range ring.Keys {
+		if !key.retired() {
+			set.Keys = append(set.Keys, key.JSONWebKey())
+		}
+	}
+
+	return set
+}
+
+func (key *Key) retired() bool {
+	return !key.RetiresAt.IsZero() && time.Now().After(key.RetiresAt)
+}
+
+// JSONWebKey returns the public part of the key in the JWK format
+func (key *Key) JSONWebKey() JSONWebKey {
+	webKey := JSONWebKey{
+		KeyID:     key.ID,
+		Use:       "sig",
+		Algorithm: key.Method.Alg(),
+	}
+
+	switch publicKey := key.PublicKey.(type) {
+	case *rsa.PublicKey:
+		webKey.KeyType = "RSA"
+		webKey.N = encodeBase64(publicKey.N.Bytes())
+		webKey.E = encodeBase64(big.NewInt(int64(publicKey.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (publicKey.Curve.Params().BitSize + 7) / 8
+
+		webKey.KeyType = "EC"
+		webKey.Curve = publicKey.Curve.Params().Name
+		webKey.X = encodeBase64(publicKey.X.FillBytes(make([]byte, size)))
+		webKey.Y = encodeBase64(publicKey.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		webKey.KeyType = "OKP"
+		webKey.Curve = "Ed25519"
+		webKey.X = encodeBase64(publicKey)
+	}
+
+	return webKey
+}
+
+func encodeBase64(bytes []byte) string {
+	return base64.RawURLEncoding.EncodeToString(bytes)
+}
@@ -0,0 +1,115 @@
+package authentication_test
+
+import (
+	"api/src/authentication"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeKey writes a new Ed25519 private key named after its id in directory
+func writeKey(t *testing.T, directory, ID string) {
+	t.Helper()
+
+	_, privateKey, error := ed25519.GenerateKey(rand.Reader)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	bytes, error := x509.MarshalPKCS8PrivateKey(privateKey)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	content := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: bytes})
+
+	if error := ioutil.WriteFile(filepath.Join(directory, ID+".pem"), content, 0600); error != nil {
+		t.Fatal(error)
+	}
+}
+
+func TestReadKeyRingRetiresOnlyPreviousKeys(t *testing.T) {
+	directory := t.TempDir()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, ID := range []string{"previous", "current", "next"} {
+		writeKey(t, directory, ID)
+	}
+
+	manifest := `{
+		"previous": {"activatedAt": "` + now.Add(-30*24*time.Hour).Format(time.RFC3339) + `"},
+		"current": {"activatedAt": "` + now.Add(-time.Hour).Format(time.RFC3339) + `"},
+		"next": {"activatedAt": "` + now.Add(30*24*time.Hour).Format(time.RFC3339) + `"}
+	}`
+
+	if error := ioutil.WriteFile(filepath.Join(directory, "keys.json"), []byte(manifest), 0600); error != nil {
+		t.Fatal(error)
+	}
+
+	// The file times must not matter, the next key is the one written last
+	if error := os.Chtimes(filepath.Join(directory, "next.pem"), now, now.Add(time.Hour)); error != nil {
+		t.Fatal(error)
+	}
+
+	ring, error := authentication.ReadKeyRing(directory, "", 24*time.Hour)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if ring.Signing.ID != "current" {
+		t.Fatalf("Expected current to sign, got %s", ring.Signing.ID)
+	}
+
+	if expected := now.Add(23 * time.Hour); !ring.Keys["previous"].RetiresAt.Equal(expected) {
+		t.Errorf("Expected previous to retire at %v, got %v", expected, ring.Keys["previous"].RetiresAt)
+	}
+
+	if !ring.Keys["next"].RetiresAt.IsZero() {
+		t.Errorf("Expected next to never retire, got %v", ring.Keys["next"].RetiresAt)
+	}
+
+	if !ring.Keys["current"].RetiresAt.IsZero() {
+		t.Errorf("Expected current to never retire, got %v", ring.Keys["current"].RetiresAt)
+	}
+}
+
+func TestReadKeyRingWithoutActivation(t *testing.T) {
+	directory := t.TempDir()
+
+	writeKey(t, directory, "first")
+
+	ring, error := authentication.ReadKeyRing(directory, "", 24*time.Hour)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if ring.Signing.ID != "first" {
+		t.Fatalf("Expected first to sign, got %s", ring.Signing.ID)
+	}
+
+	writeKey(t, directory, "second")
+
+	if _, error := authentication.ReadKeyRing(directory, "", 24*time.Hour); error == nil {
+		t.Fatal("Expected an error when nothing tells which key signs")
+	}
+
+	ring, error = authentication.ReadKeyRing(directory, "second", 24*time.Hour)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if !ring.Keys["first"].RetiresAt.IsZero() {
+		t.Errorf("Expected first to never retire without activation times, got %v", ring.Keys["first"].RetiresAt)
+	}
+}
@@ -0,0 +1,51 @@
+package authentication
+
+// Roles a user can have
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
+
+// Permissions granted through roles
+const (
+	PermissionManageUsers        = "users:manage"
+	PermissionManagePublications = "publications:manage"
+	PermissionImpersonateUsers   = "users:impersonate"
+)
+
+// RolePermissions lists what each role is allowed to do
+var RolePermissions = map[string][]string{
+	RoleUser:  {},
+	RoleAdmin: {PermissionManageUsers, PermissionManagePublications, PermissionImpersonateUsers},
+}
+
+// ValidRole report if a role exists
+func ValidRole(role string) bool {
+	_, exists := RolePermissions[role]
+
+	return exists
+}
+
+// HasRole report if the token was issued to a user with the role
+func (claims Claims) HasRole(role string) bool {
+	for _, tokenRole := range claims.Roles {
+		if tokenRole == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// HasPermission report if any role of the token grants the permission
+func (claims Claims) HasPermission(permission string) bool {
+	for _, role := range claims.Roles {
+		for _, rolePermission := range RolePermissions[role] {
+			if rolePermission == permission {
+				return true
+			}
+		}
+	}
+
+	return false
+}
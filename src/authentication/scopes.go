@@ -0,0 +1,61 @@
+package authentication
+
+import "strings"
+
+// Scopes restricting what a credential can reach
+const (
+	ScopeUsersRead         = "users:read"
+	ScopeUsersWrite        = "users:write"
+	ScopePublicationsRead  = "publications:read"
+	ScopePublicationsWrite = "publications:write"
+)
+
+// Scopes lists every scope a credential can be restricted to
+var Scopes = []string{
+	ScopeUsersRead,
+	ScopeUsersWrite,
+	ScopePublicationsRead,
+	ScopePublicationsWrite,
+}
+
+// ValidScope report if a scope exists
+func ValidScope(scope string) bool {
+	for _, existing := range Scopes {
+		if existing == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseScope split a space separated scope claim, an empty claim restricts the credential to nothing
+func ParseScope(scope string) []string {
+	return append([]string{}, strings.Fields(scope)...)
+}
+
+// FormatScope join scopes in the space separated format of the scope claim
+func FormatScope(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// Restricted report if the credential is limited to its scopes, unrestricted
+// credentials reach everything their user can
+func (claims Claims) Restricted() bool {
+	return claims.Scopes != nil
+}
+
+// HasScope report if the credential can reach routes requiring the scope
+func (claims Claims) HasScope(scope string) bool {
+	if !claims.Restricted() {
+		return true
+	}
+
+	for _, claimScope := range claims.Scopes {
+		if claimScope == scope {
+			return true
+		}
+	}
+
+	return false
+}
@@ -2,6 +2,7 @@ package authentication
 
 import (
 	"api/src/config"
+	"api/src/security"
 	"errors"
 	"fmt"
 	"net/http"
@@ -12,35 +13,203 @@ import (
 	jwt "github.com/dgrijalva/jwt-go"
 )
 
-// CreateToken create a token to validate user
-func CreateToken(userId uint64) (string, error) {
+// Token types, only access tokens authenticate requests
+const (
+	TokenTypeAccess = "access"
+	// TokenTypeMFA is given after the password of a user with two factor authentication
+	// is checked, and can only be exchanged for an access token along with a code
+	TokenTypeMFA = "mfa"
+	// TokenTypeAPIKey identifies claims of requests authenticated by a personal api key
+	TokenTypeAPIKey = "api_key"
+	// TokenTypeMagicLink is emailed for a passwordless login and can be exchanged once for an access token
+	TokenTypeMagicLink = "magic_link"
+)
+
+// IssuedAtPrecision of the iat claim, written with a fraction of second so revocations tell
+// apart the tokens issued just before and just after them
+const IssuedAtPrecision = time.Microsecond
+
+// Claims represents the information carried by a valid token
+type Claims struct {
+	TokenID   string
+	Type      string
+	UserID    uint64
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+	Roles     []string
+	// Scopes restricting the credential, nil when it is not restricted
+	Scopes []string
+	// ClientID of the oauth client the token was issued to, empty for the api own login
+	ClientID string
+	// SessionID of the login the token belongs to, zero for credentials outside a session
+	SessionID uint64
+	// ActorID of the admin acting as the user, zero when the user is not impersonated
+	ActorID uint64
+}
+
+// CreateToken create a token to validate user, the id, issue and expiration times
+// are filled in the claims when empty
+func CreateToken(claims *Claims) (string, error) {
+	if claims.TokenID == "" {
+		tokenID, error := security.GenerateToken(16)
+
+		if error != nil {
+			return "", error
+		}
+
+		claims.TokenID = tokenID
+	}
+
+	if claims.Type == "" {
+		claims.Type = TokenTypeAccess
+	}
+
+	if claims.IssuedAt.IsZero() {
+		claims.IssuedAt = time.Now()
+	}
+
+	if claims.ExpiresAt.IsZero() {
+		claims.ExpiresAt = claims.IssuedAt.Add(config.AccessTokenDuration)
+	}
+
+	if claims.Roles == nil {
+		claims.Roles = []string{RoleUser}
+	}
+
 	permissions := jwt.MapClaims{}
 	permissions["authorized"] = true
-	permissions["exp"] = time.Now().Add(time.Hour * 6).Unix()
-	permissions["userId"] = userId
+	permissions["jti"] = claims.TokenID
+	permissions["type"] = claims.Type
+	permissions["iat"] = float64(claims.IssuedAt.Truncate(IssuedAtPrecision).UnixNano()) / float64(time.Second)
+	permissions["exp"] = claims.ExpiresAt.Unix()
+	permissions["userId"] = claims.UserID
+	permissions["roles"] = claims.Roles
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, permissions)
+	if claims.Restricted() {
+		permissions["scope"] = FormatScope(claims.Scopes)
+	}
 
-	return token.SignedString([]byte(config.SecretKey))
+	if claims.ClientID != "" {
+		permissions["client_id"] = claims.ClientID
+	}
+
+	if claims.SessionID != 0 {
+		permissions["sid"] = claims.SessionID
+	}
+
+	if claims.ActorID != 0 {
+		permissions["act"] = map[string]string{"sub": strconv.FormatUint(claims.ActorID, 10)}
+	}
+
+	return signToken(permissions)
 }
 
 // ValidateToken verify if request token is valid
 func ValidateToken(r *http.Request) error {
-	tokenString := extractToken(r)
+	_, error := GetClaims(r)
+
+	return error
+}
+
+// GetClaims return the claims of the credential authenticated by the middleware,
+// or validate the access token of the request
+func GetClaims(r *http.Request) (Claims, error) {
+	if claims, ok := claimsFromContext(r); ok {
+		return claims, nil
+	}
+
+	return ParseToken(extractToken(r), TokenTypeAccess)
+}
 
+// ParseToken validate a token of the given type and return its claims
+func ParseToken(tokenString, tokenType string) (Claims, error) {
 	token, error := jwt.Parse(tokenString, getVerificationKey)
 
 	if error != nil {
-		return error
+		return Claims{}, error
+	}
+
+	permissions, ok := token.Claims.(jwt.MapClaims)
+
+	if !ok || !token.Valid {
+		return Claims{}, errors.New("Invalid token")
+	}
+
+	tokenID, _ := permissions["jti"].(string)
+
+	if tokenID == "" {
+		return Claims{}, errors.New("Invalid token")
+	}
+
+	// Tokens issued before the type claim existed are access tokens
+	claimedType, _ := permissions["type"].(string)
+
+	if claimedType == "" {
+		claimedType = TokenTypeAccess
+	}
+
+	if claimedType != tokenType {
+		return Claims{}, errors.New("Invalid token type")
+	}
+
+	userID, error := strconv.ParseUint(fmt.Sprintf("%.0f", permissions["userId"]), 10, 64)
+
+	if error != nil {
+		return Claims{}, error
+	}
+
+	issuedAt, _ := permissions["iat"].(float64)
+	expiresAt, _ := permissions["exp"].(float64)
+
+	clientID, _ := permissions["client_id"].(string)
+	sessionID, _ := permissions["sid"].(float64)
+
+	var actorID uint64
+
+	if actor, impersonated := permissions["act"].(map[string]interface{}); impersonated {
+		subject, _ := actor["sub"].(string)
+
+		if actorID, error = strconv.ParseUint(subject, 10, 64); error != nil || actorID == 0 {
+			return Claims{}, errors.New("Invalid actor claim")
+		}
 	}
 
-	if _, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		return nil
+	var scopes []string
+
+	if scope, restricted := permissions["scope"].(string); restricted {
+		scopes = ParseScope(scope)
 	}
 
-	return errors.New("Invalid token")
+	return Claims{
+		TokenID:   tokenID,
+		Type:      claimedType,
+		UserID:    userID,
+		IssuedAt:  time.Unix(0, int64(issuedAt*float64(time.Second))).Round(IssuedAtPrecision),
+		ExpiresAt: time.Unix(int64(expiresAt), 0),
+		Roles:     stringList(permissions["roles"]),
+		Scopes:    scopes,
+		ClientID:  clientID,
+		SessionID: uint64(sessionID),
+		ActorID:   actorID,
+	}, nil
 }
 
+// stringList convert a decoded json array of strings
+func stringList(value interface{}) []string {
+	items, _ := value.([]interface{})
+
+	list := []string{}
+
+	for _, item := range items {
+		if text, ok := item.(string); ok {
+			list = append(list, text)
+		}
+	}
+
+	return list
+}
+
+// extractToken read the bearer token of the request, or the access token cookie set in cookie mode
 func extractToken(r *http.Request) string {
 	token := r.Header.Get("Authorization")
 
@@ -48,35 +217,43 @@ func extractToken(r *http.Request) string {
 		return strings.Split(token, " ")[1]
 	}
 
+	if cookie, error := r.Cookie(AccessTokenCookie); error == nil {
+		return cookie.Value
+	}
+
 	return ""
 }
 
-func getVerificationKey(token *jwt.Token) (interface{}, error) {
-	if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-		return nil, fmt.Errorf("Invalid signature method %v", token.Header["alg"])
-	}
+// Identity tells who a request acts as and, when an admin is impersonating that user, who is acting
+type Identity struct {
+	UserID  uint64
+	ActorID uint64
+}
 
-	return config.SecretKey, nil
+// Impersonated report if someone else is acting as the user
+func (identity Identity) Impersonated() bool {
+	return identity.ActorID != 0
 }
 
+// GetUserId returns the user the request acts as, GetIdentity also tells the admin
+// behind an impersonated request
 func GetUserId(r *http.Request) (uint64, error) {
-	tokenString := extractToken(r)
-
-	token, error := jwt.Parse(tokenString, getVerificationKey)
+	identity, error := GetIdentity(r)
 
 	if error != nil {
 		return 0, error
 	}
 
-	if permissions, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, error := strconv.ParseUint(fmt.Sprintf("%.0f", permissions["userId"]), 10, 64)
+	return identity.UserID, nil
+}
 
-		if error != nil {
-			return 0, error
-		}
+// GetIdentity returns both the user the request acts as and the admin acting as that user
+func GetIdentity(r *http.Request) (Identity, error) {
+	claims, error := GetClaims(r)
 
-		return userID, nil
+	if error != nil {
+		return Identity{}, error
 	}
 
-	return 0, errors.New("Invalid token")
+	return Identity{UserID: claims.UserID, ActorID: claims.ActorID}, nil
 }
@@ -0,0 +1,22 @@
+package container
+
+import (
+	"api/src/database"
+	"api/src/repositories"
+	"database/sql"
+)
+
+// Container holds the dependencies created once at startup and shared by every request
+type Container struct {
+	// DB is the connection pool, it must not be closed by its users
+	DB *sql.DB
+	// Dialect of the database of the pool
+	Dialect database.Dialect
+	// Store gives the repositories, backed by DB unless another store is set, like in tests
+	Store repositories.Store
+}
+
+// New returns a container with the dependencies
+func New(db *sql.DB, dialect database.Dialect) *Container {
+	return &Container{DB: db, Dialect: dialect, Store: repositories.NewSQLStore(repositories.NewDB(db, dialect))}
+}
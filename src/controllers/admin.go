@@ -0,0 +1,376 @@
+package controllers
+
+import (
+	"api/src/audit"
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/network"
+	"api/src/repositories"
+	"api/src/responses"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// AdminListUsers list every user with its roles and suspension
+func AdminListUsers(w http.ResponseWriter, r *http.Request) {
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.Users()
+
+	users, error := repository.List(r.Context())
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, users)
+}
+
+// AdminUpdateRoles replace the roles of any user
+func AdminUpdateRoles(w http.ResponseWriter, r *http.Request) {
+	userID, error := adminTargetUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var roles models.Roles
+
+	if error = json.Unmarshal(requestBody, &roles); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if len(roles.Roles) == 0 {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field roles cannot be empty"))
+		return
+	}
+
+	for _, role := range roles.Roles {
+		if !authentication.ValidRole(role) {
+			responses.Error(w, http.StatusBadRequest, fmt.Errorf("Invalid role %s", role))
+			return
+		}
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	if error = updateRoles(r.Context(), store, userID, roles.Roles); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// AdminSuspendUser block a user from logging in and end all of its logins
+func AdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	setUserSuspension(w, r, true)
+}
+
+// AdminUnsuspendUser allow a suspended user to log in again
+func AdminUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	setUserSuspension(w, r, false)
+}
+
+func setUserSuspension(w http.ResponseWriter, r *http.Request, suspended bool) {
+	userID, error := adminTargetUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	if error = suspendUser(r.Context(), store, userID, suspended); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// AdminDeleteUser delete any user
+func AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	userID, error := adminTargetUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	if error = deleteUser(r.Context(), store, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// updateRoles replace the roles of the user and, as tokens carry the roles, end all of its logins
+func updateRoles(ctx context.Context, store repositories.Store, userID uint64, roles []string) error {
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if error := work.Users().UpdateRoles(ctx, userID, roles); error != nil {
+			return error
+		}
+
+		return work.RefreshTokens().RevokeUser(ctx, userID)
+	})
+
+	if error != nil {
+		return error
+	}
+
+	return revokeAccessTokens(userID)
+}
+
+// suspendUser suspend or allow back the user, the logins of a suspended user are ended
+func suspendUser(ctx context.Context, store repositories.Store, userID uint64, suspended bool) error {
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if error := work.Users().Suspend(ctx, userID, suspended); error != nil {
+			return error
+		}
+
+		if !suspended {
+			return nil
+		}
+
+		return work.RefreshTokens().RevokeUser(ctx, userID)
+	})
+
+	if error != nil || !suspended {
+		return error
+	}
+
+	return revokeAccessTokens(userID)
+}
+
+// deleteUser delete the user and end all of its logins
+func deleteUser(ctx context.Context, store repositories.Store, userID uint64) error {
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if error := work.Users().Delete(ctx, userID); error != nil {
+			return error
+		}
+
+		return work.RefreshTokens().RevokeUser(ctx, userID)
+	})
+
+	if error != nil {
+		return error
+	}
+
+	return revokeAccessTokens(userID)
+}
+
+// AdminListPublications list the publications of every user
+func AdminListPublications(w http.ResponseWriter, r *http.Request) {
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.Publications()
+
+	publications, error := repository.ListAllPublications(r.Context())
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, publications)
+}
+
+// AdminDeletePublication delete a publication of any user
+func AdminDeletePublication(w http.ResponseWriter, r *http.Request) {
+	params := mux.Vars(r)
+
+	publicationID, error := strconv.ParseUint(params["publicationId"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.Publications()
+
+	if error = repository.DeletePublication(r.Context(), publicationID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// AdminImpersonateUser give the admin a short lived token to act as another user, every
+// request made with it is audited. Users allowed to impersonate cannot be impersonated
+func AdminImpersonateUser(w http.ResponseWriter, r *http.Request) {
+	userID, error := adminTargetUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	identity, error := authentication.GetIdentity(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	if identity.Impersonated() {
+		responses.Error(w, http.StatusForbidden, errors.New("Not allowed while impersonating a user"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	user, error := store.Users().GetAccount(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if user.ID == 0 {
+		responses.Error(w, http.StatusNotFound, errors.New("User not found"))
+		return
+	}
+
+	if (authentication.Claims{Roles: user.Roles}).HasPermission(authentication.PermissionImpersonateUsers) {
+		responses.Error(w, http.StatusForbidden, errors.New("Administrators cannot be impersonated"))
+		return
+	}
+
+	claims := authentication.Claims{
+		UserID:    user.ID,
+		Roles:     user.Roles,
+		ActorID:   identity.UserID,
+		ExpiresAt: time.Now().Add(config.ImpersonationDuration),
+	}
+
+	token, error := authentication.CreateToken(&claims)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// The impersonation is refused when it cannot be audited
+	if error = audit.Default.Write(audit.Entry{
+		ActorID:   claims.ActorID,
+		UserID:    claims.UserID,
+		TokenID:   claims.TokenID,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		Status:    http.StatusCreated,
+		IP:        network.ClientIP(r),
+		CreatedAt: claims.IssuedAt,
+	}); error != nil {
+		responses.Error(w, http.StatusServiceUnavailable, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, models.Impersonation{
+		AccessToken: token,
+		ExpiresAt:   claims.ExpiresAt,
+		UserID:      claims.UserID,
+		ActorID:     claims.ActorID,
+	})
+}
+
+// AdminDatabaseStats show the state of the database connection pool, for monitoring
+func AdminDatabaseStats(w http.ResponseWriter, r *http.Request) {
+	db, error := SetDatabase(w)
+
+	if error != nil {
+		return
+	}
+
+	stats := db.Stats()
+
+	responses.JSON(w, http.StatusOK, models.DatabaseStats{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDuration:       stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	})
+}
+
+// adminTargetUser read the user of the route, administrators cannot act on themselves
+// so they do not lock themselves out
+func adminTargetUser(w http.ResponseWriter, r *http.Request) (uint64, error) {
+	params := mux.Vars(r)
+
+	userID, error := strconv.ParseUint(params["userId"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return 0, error
+	}
+
+	adminID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return 0, error
+	}
+
+	if userID == adminID {
+		error = errors.New("Administrators cannot change their own account here")
+		responses.Error(w, http.StatusForbidden, error)
+		return 0, error
+	}
+
+	return userID, nil
+}
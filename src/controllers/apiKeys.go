@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/responses"
+	"api/src/security"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// CreateAPIKey create a personal api key, the key is only shown in this answer
+func CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, error := apiKeyUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var apiKey models.APIKey
+
+	if error = json.Unmarshal(requestBody, &apiKey); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error = apiKey.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	for _, scope := range apiKey.Scopes {
+		if !authentication.ValidScope(scope) {
+			responses.Error(w, http.StatusBadRequest, fmt.Errorf("Invalid scope %s", scope))
+			return
+		}
+	}
+
+	if apiKey.Scopes == nil {
+		apiKey.Scopes = []string{}
+	}
+
+	apiKey.UserID = userID
+
+	if apiKey.Key, apiKey.Prefix, error = security.GenerateAPIKey(); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	apiKey.KeyHash = security.HashToken(apiKey.Key)
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.APIKeys()
+
+	if apiKey.ID, error = repository.Create(r.Context(), apiKey); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, apiKey)
+}
+
+// ListAPIKeys list the api keys of the user, without the keys themselves
+func ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, error := apiKeyUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.APIKeys()
+
+	apiKeys, error := repository.ListByUser(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, apiKeys)
+}
+
+// RevokeAPIKey revoke an api key of the user
+func RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, error := apiKeyUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	params := mux.Vars(r)
+
+	apiKeyID, error := strconv.ParseUint(params["apiKeyId"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.APIKeys()
+
+	revoked, error := repository.Revoke(r.Context(), apiKeyID, userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !revoked {
+		responses.Error(w, http.StatusNotFound, errors.New("Api key not found"))
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// apiKeyUser read the user of the route, which must be the user of the credential
+func apiKeyUser(w http.ResponseWriter, r *http.Request) (uint64, error) {
+	params := mux.Vars(r)
+
+	userID, error := strconv.ParseUint(params["userID"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return 0, error
+	}
+
+	tokenUserID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return 0, error
+	}
+
+	if userID != tokenUserID {
+		error = errors.New("Cannot manage api keys of others users")
+		responses.Error(w, http.StatusForbidden, error)
+		return 0, error
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/security"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// setTokenCookies keep the tokens of a login in HttpOnly cookies when the cookie mode is
+// enabled, along with a new csrf token the front-end must echo on state changing requests
+func setTokenCookies(w http.ResponseWriter, tokens models.Tokens) error {
+	if !config.CookieAuth {
+		return nil
+	}
+
+	csrfToken, error := security.GenerateToken(32)
+
+	if error != nil {
+		return error
+	}
+
+	http.SetCookie(w, tokenCookie(authentication.AccessTokenCookie, tokens.AccessToken, "/", config.AccessTokenDuration, true))
+	http.SetCookie(w, tokenCookie(authentication.RefreshTokenCookie, tokens.RefreshToken, "/auth", config.RefreshTokenDuration, true))
+	http.SetCookie(w, tokenCookie(authentication.CSRFCookie, csrfToken, "/", config.RefreshTokenDuration, false))
+
+	return nil
+}
+
+// clearTokenCookies remove the cookies of the cookie mode
+func clearTokenCookies(w http.ResponseWriter) {
+	if !config.CookieAuth {
+		return
+	}
+
+	http.SetCookie(w, tokenCookie(authentication.AccessTokenCookie, "", "/", -time.Second, true))
+	http.SetCookie(w, tokenCookie(authentication.RefreshTokenCookie, "", "/auth", -time.Second, true))
+	http.SetCookie(w, tokenCookie(authentication.CSRFCookie, "", "/", -time.Second, false))
+}
+
+// tokenCookie builds a cookie with the attributes of the configuration, a negative
+// duration deletes the cookie
+func tokenCookie(name, value, path string, duration time.Duration, httpOnly bool) *http.Cookie {
+	maxAge := int(duration.Seconds())
+
+	if duration < 0 {
+		maxAge = -1
+	}
+
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   config.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   config.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: sameSite(config.CookieSameSite),
+	}
+}
+
+func sameSite(policy string) http.SameSite {
+	switch strings.ToLower(policy) {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
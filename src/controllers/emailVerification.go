@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/mailer"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/security"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var errInvalidVerificationToken = errors.New("Invalid or expired token")
+
+// VerifyEmail confirm the email a verification token was sent to
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.VerifyEmail
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if request.Token == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field token cannot be empty"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.EmailVerifications()
+
+	token, error := repository.GetByHash(r.Context(), security.HashToken(request.Token))
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if token.ID == 0 {
+		responses.Error(w, http.StatusBadRequest, errInvalidVerificationToken)
+		return
+	}
+
+	error = verifyEmail(r.Context(), store, token)
+
+	if error == errInvalidVerificationToken {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// verifyEmail consume the verification token and mark its email as verified. A token already
+// used, or sent to an email the user no longer has, fails with errInvalidVerificationToken
+func verifyEmail(ctx context.Context, store repositories.Store, token models.OneTimeToken) error {
+	return store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		used, error := work.EmailVerifications().Use(ctx, token.ID)
+
+		if error != nil {
+			return error
+		}
+
+		if !used {
+			return errInvalidVerificationToken
+		}
+
+		// The user may have changed the email after the token was sent
+		verified, error := work.Users().MarkVerified(ctx, token.UserID, token.Email)
+
+		if error != nil {
+			return error
+		}
+
+		if !verified {
+			return errInvalidVerificationToken
+		}
+
+		return nil
+	})
+}
+
+// ResendVerification send a new verification email, at most once per config.VerificationResendInterval
+func ResendVerification(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	user, error := store.Users().GetAccount(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if user.VerifiedAt != nil {
+		responses.Error(w, http.StatusConflict, errors.New("Email already verified"))
+		return
+	}
+
+	lastSentAt, error := store.EmailVerifications().LastSentAt(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if wait := time.Until(lastSentAt.Add(config.VerificationResendInterval)); wait > 0 {
+		setRetryAfter(w, wait)
+		responses.Error(w, http.StatusTooManyRequests, errors.New("Verification email sent recently"))
+		return
+	}
+
+	if error = sendVerification(r.Context(), store, user.ID, user.Email); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+// sendVerification create a verification token for the email and send it
+func sendVerification(ctx context.Context, store repositories.Store, userID uint64, email string) error {
+	token, error := security.GenerateToken(32)
+
+	if error != nil {
+		return error
+	}
+
+	repository := store.EmailVerifications()
+
+	if _, error = repository.Create(ctx, models.OneTimeToken{
+		UserID:    userID,
+		Email:     email,
+		TokenHash: security.HashToken(token),
+		ExpiresAt: time.Now().Add(config.EmailVerificationDuration),
+	}); error != nil {
+		return error
+	}
+
+	return mailer.Default.Send(emailVerificationMessage(email, token))
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"api/src/config"
+	"api/src/mailer"
+	"fmt"
+	"net/url"
+)
+
+// publicLink builds a front-end link carrying a token, or just the token when the
+// front-end address is not configured
+func publicLink(path, token string) string {
+	if config.PublicURL == "" {
+		return token
+	}
+
+	return fmt.Sprintf("%s%s?token=%s", config.PublicURL, path, url.QueryEscape(token))
+}
+
+func passwordResetMessage(email, token string) mailer.Message {
+	return mailer.Message{
+		To:      email,
+		Subject: "Password reset",
+		Body: fmt.Sprintf(
+			"A password reset was requested for your account. Use the link below in the next %s to choose a new password:\n\n%s\n\nIf you did not request it, ignore this email.",
+			config.PasswordResetDuration,
+			publicLink("/reset-password", token),
+		),
+	}
+}
+
+func emailVerificationMessage(email, token string) mailer.Message {
+	return mailer.Message{
+		To:      email,
+		Subject: "Confirm your email",
+		Body: fmt.Sprintf(
+			"Confirm this is your email address using the link below in the next %s:\n\n%s",
+			config.EmailVerificationDuration,
+			publicLink("/verify-email", token),
+		),
+	}
+}
+
+func magicLinkMessage(email, token string) mailer.Message {
+	return mailer.Message{
+		To:      email,
+		Subject: "Your login link",
+		Body: fmt.Sprintf(
+			"Use the link below in the next %s to log in, it works only once:\n\n%s\n\nIf you did not request it, ignore this email.",
+			config.MagicLinkDuration,
+			publicLink("/magic-link", token),
+		),
+	}
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/responses"
+	"net/http"
+)
+
+// JWKS publish the public keys that verify our tokens
+func JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+
+	responses.JSON(w, http.StatusOK, authentication.JWKS())
+}
@@ -2,14 +2,26 @@ package controllers
 
 import (
 	"api/src/authentication"
-	"api/src/database"
 	"api/src/models"
+	"api/src/network"
 	"api/src/repositories"
 	"api/src/responses"
 	"api/src/security"
+	"api/src/throttling"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"log"
 	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	errInvalidCredentials = errors.New("Invalid email or password")
+	errTooManyAttempts    = errors.New("Too many failed attempts, try again later")
 )
 
 func Login(w http.ResponseWriter, r *http.Request) {
@@ -20,43 +32,182 @@ func Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var user models.User
+	var user models.Credentials
 
 	if error = json.Unmarshal(requestBody, &user); error != nil {
 		responses.Error(w, http.StatusUnprocessableEntity, error)
 		return
 	}
 
-	db, error := database.Connect()
+	scopes, error := requestedScopes(user.Scope)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	accountKey := "email:" + strings.ToLower(strings.TrimSpace(user.Email))
+	addressKey := network.ClientIP(r)
+
+	if wait := loginBlocked(accountKey, addressKey); wait > 0 {
+		recordFailedLogin(store, r, user.Email, 0, models.LoginFailureLocked)
+		setRetryAfter(w, wait)
+		responses.Error(w, http.StatusTooManyRequests, errTooManyAttempts)
+		return
+	}
+
+	repository := store.Users()
+
+	databaseUser, error := repository.SearchByEmail(r.Context(), user.Email)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
-	defer db.Close()
+	if databaseUser.ID == 0 {
+		// Unknown emails take as long and fail the same way as wrong passwords
+		security.SimulatePasswordCheck(user.Password)
+		error = errInvalidCredentials
+	} else if error = security.CheckPassword(databaseUser.Password, user.Password); error != nil {
+		error = errInvalidCredentials
+	}
+
+	if error != nil {
+		recordFailedLogin(store, r, user.Email, databaseUser.ID, models.LoginFailureInvalidCredentials)
+		failLogin(w, error, accountKey, addressKey)
+		return
+	}
+
+	throttling.LoginAccounts.Reset(accountKey)
+
+	if security.NeedsRehash(databaseUser.Password) {
+		rehashPassword(r.Context(), repository, databaseUser.ID, user.Password)
+	}
 
-	repository := repositories.NewUserRepository(db)
+	if databaseUser.SuspendedAt != nil {
+		responses.Error(w, http.StatusForbidden, errors.New("User is suspended"))
+		return
+	}
 
-	databaseUser, error := repository.SearchByEmail(user.Email)
+	totp, error := repository.GetTOTP(r.Context(), databaseUser.ID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
-	if error = security.CheckPassword(databaseUser.Password, user.Password); error != nil {
-		responses.Error(w, http.StatusUnauthorized, error)
+	if totp.Enabled {
+		challenge, error := mfaChallenge(databaseUser.ID, scopes)
+
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, challenge)
 		return
 	}
 
-	token, error := authentication.CreateToken(databaseUser.ID)
+	tokens, error := issueTokens(store, r, databaseUser, scopes)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
-	w.Write([]byte(token))
+	respondLogin(w, r, store, databaseUser.ID, tokens)
+}
+
+// requestedScopes validate the scope asked on login, nil when the tokens are not restricted
+func requestedScopes(scope *string) ([]string, error) {
+	if scope == nil {
+		return nil, nil
+	}
 
+	scopes := authentication.ParseScope(*scope)
+
+	for _, scope := range scopes {
+		if !authentication.ValidScope(scope) {
+			return nil, fmt.Errorf("Invalid scope %s", scope)
+		}
+	}
+
+	return scopes, nil
+}
+
+// loginBlocked returns how long the account or the client address must still wait to try again
+func loginBlocked(accountKey, addressKey string) time.Duration {
+	wait := throttling.LoginAccounts.Blocked(accountKey)
+
+	if addressWait := throttling.LoginAddresses.Blocked(addressKey); addressWait > wait {
+		wait = addressWait
+	}
+
+	return wait
+}
+
+// failLogin count the failure of the account and client address and answer the request,
+// asking the client to wait when the failure locked any of them
+func failLogin(w http.ResponseWriter, error error, accountKey, addressKey string) {
+	failAttempt(w, http.StatusUnauthorized, error, accountKey, addressKey)
+}
+
+// failAttempt count a failed guess of a secret against the account and client address and
+// answer it with the status, asking the client to wait when the failure locked any of them
+func failAttempt(w http.ResponseWriter, statusCode int, error error, accountKey, addressKey string) {
+	wait := throttling.LoginAccounts.Fail(accountKey)
+
+	if addressWait := throttling.LoginAddresses.Fail(addressKey); addressWait > wait {
+		wait = addressWait
+	}
+
+	if wait > 0 {
+		setRetryAfter(w, wait)
+	}
+
+	responses.Error(w, statusCode, error)
+}
+
+// recordFailedLogin write the audit record of a failed login, a failure to write it does not change the answer
+func recordFailedLogin(store repositories.Store, r *http.Request, email string, userID uint64, reason string) {
+	repository := store.LoginAttempts()
+
+	if error := repository.Create(r.Context(), models.LoginAttempt{
+		Email:     truncate(email, 50),
+		UserID:    userID,
+		IP:        network.ClientIP(r),
+		UserAgent: truncate(r.UserAgent(), 255),
+		Reason:    reason,
+	}); error != nil {
+		log.Printf("Could not record failed login: %v", error)
+	}
+}
+
+func truncate(text string, size int) string {
+	if len(text) <= size {
+		return text
+	}
+
+	return fmt.Sprintf("%.*s", size, text)
+}
+
+// rehashPassword hash again a password checked against an outdated hash, a failure only
+// delays the upgrade to the next login
+func rehashPassword(ctx context.Context, repository repositories.UserRepository, userID uint64, password string) {
+	hashPassword, error := security.Hash(password)
+
+	if error == nil {
+		error = repository.UpdatePassword(ctx, string(hashPassword), userID)
+	}
+
+	if error != nil {
+		log.Printf("Could not rehash the password of user %d: %v", userID, error)
+	}
 }
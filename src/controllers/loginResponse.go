@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// respondLogin answer a successful login with the tokens and the profile of the user.
+// Clients preferring text/plain get only the access token, as the login used to answer
+func respondLogin(w http.ResponseWriter, r *http.Request, store repositories.Store, userID uint64, tokens models.Tokens) {
+	if error := setTokenCookies(w, tokens); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if prefersPlainText(r) {
+		responses.Text(w, http.StatusOK, tokens.AccessToken)
+		return
+	}
+
+	user, error := store.Users().Get(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.LoginResponse{Tokens: tokens, User: user.Profile()})
+}
+
+// prefersPlainText report if the Accept header ranks text/plain above json
+func prefersPlainText(r *http.Request) bool {
+	var plainText, json float64
+
+	for _, accepted := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, error := mime.ParseMediaType(strings.TrimSpace(accepted))
+
+		if error != nil {
+			continue
+		}
+
+		quality := 1.0
+
+		if value, exists := params["q"]; exists {
+			if quality, error = strconv.ParseFloat(value, 64); error != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "text/plain":
+			plainText = quality
+		case "application/json":
+			json = quality
+		}
+	}
+
+	return plainText > json
+}
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/revocation"
+	"api/src/security"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+// Logout revoke the token of the request and end its session, a refresh token of a login
+// started before sessions existed can be informed to revoke it too
+func Logout(w http.ResponseWriter, r *http.Request) {
+	claims, error := authentication.GetClaims(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	if claims.Type != authentication.TokenTypeAccess {
+		responses.Error(w, http.StatusBadRequest, errors.New("Api keys are revoked through their own route"))
+		return
+	}
+
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.RefreshRequest
+
+	if len(requestBody) > 0 {
+		if error = json.Unmarshal(requestBody, &request); error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	if error = revocation.Default.RevokeToken(claims.TokenID, claims.ExpiresAt); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	clearTokenCookies(w)
+
+	if claims.SessionID == 0 && request.RefreshToken == "" {
+		responses.JSON(w, http.StatusNoContent, nil)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	if claims.SessionID != 0 {
+		session, error := store.Sessions().Get(r.Context(), claims.SessionID)
+
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		if session.ID != 0 && session.UserID == claims.UserID {
+			if error = revokeSession(r.Context(), store, session); error != nil {
+				responses.Error(w, http.StatusInternalServerError, error)
+				return
+			}
+		}
+	}
+
+	if request.RefreshToken != "" {
+		repository := store.RefreshTokens()
+
+		refreshToken, error := repository.GetByHash(r.Context(), security.HashToken(request.RefreshToken))
+
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		if refreshToken.ID != 0 && refreshToken.UserID == claims.UserID {
+			if error = repository.RevokeFamily(r.Context(), refreshToken.FamilyID); error != nil {
+				responses.Error(w, http.StatusInternalServerError, error)
+				return
+			}
+		}
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// LogoutAll revoke every token and refresh token of the user
+func LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	if error = revokeUserTokens(r.Context(), store, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	clearTokenCookies(w)
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// revokeUserTokens end every login of a user, both access and refresh tokens
+func revokeUserTokens(ctx context.Context, store repositories.UnitOfWork, userID uint64) error {
+	if error := store.RefreshTokens().RevokeUser(ctx, userID); error != nil {
+		return error
+	}
+
+	return revokeAccessTokens(userID)
+}
+
+// revokeAccessTokens reject the access tokens issued to the user until now. The revocation
+// store is not part of the units of work, so it is written once the refresh tokens of the user
+// are revoked and committed
+func revokeAccessTokens(userID uint64) error {
+	return revocation.Default.RevokeUser(userID, time.Now())
+}
@@ -0,0 +1,178 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/mailer"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/throttling"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var errInvalidMagicLink = errors.New("Invalid or expired login link")
+
+// SendMagicLink email a single use login link, the answer is the same whether the email exists or not
+func SendMagicLink(w http.ResponseWriter, r *http.Request) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.MagicLinkRequest
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if request.Email == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field email cannot be empty"))
+		return
+	}
+
+	if wait := throttling.MagicLinks.Hit("email:" + strings.ToLower(request.Email)); wait > 0 {
+		setRetryAfter(w, wait)
+		responses.Error(w, http.StatusTooManyRequests, errors.New("Too many login links requested, try again later"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	user, error := store.Users().SearchByEmail(r.Context(), request.Email)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// Failures are not answered, as only existing accounts could run into them
+	if error = sendMagicLink(r.Context(), store, user, request.Email); error != nil {
+		log.Printf("Could not send a login link to user %d: %v", user.ID, error)
+	}
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+// sendMagicLink email a login link to the user. Unknown and suspended accounts sign a link and
+// spend it, which matches nothing, instead of keeping and sending it, so they take about as long
+// to answer
+func sendMagicLink(ctx context.Context, store repositories.Store, user models.User, email string) error {
+	claims := authentication.Claims{
+		UserID:    user.ID,
+		Type:      authentication.TokenTypeMagicLink,
+		ExpiresAt: time.Now().Add(config.MagicLinkDuration),
+		Roles:     []string{},
+	}
+
+	token, error := authentication.CreateToken(&claims)
+
+	if error != nil {
+		return error
+	}
+
+	repository := store.MagicLinks()
+
+	if user.ID == 0 || user.SuspendedAt != nil {
+		_, error = repository.Use(ctx, claims.TokenID)
+		return error
+	}
+
+	if error = repository.Create(ctx, claims.TokenID, user.ID, claims.ExpiresAt); error != nil {
+		return error
+	}
+
+	return mailer.Default.Send(magicLinkMessage(email, token))
+}
+
+// MagicLinkLogin exchange a login link for tokens, users with two factor authentication
+// still have to inform a code
+func MagicLinkLogin(w http.ResponseWriter, r *http.Request) {
+	claims, error := authentication.ParseToken(mux.Vars(r)["token"], authentication.TokenTypeMagicLink)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, errInvalidMagicLink)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	used, error := store.MagicLinks().Use(r.Context(), claims.TokenID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !used {
+		responses.Error(w, http.StatusUnauthorized, errInvalidMagicLink)
+		return
+	}
+
+	repository := store.Users()
+
+	user, error := repository.GetAccount(r.Context(), claims.UserID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if user.ID == 0 {
+		responses.Error(w, http.StatusUnauthorized, errInvalidMagicLink)
+		return
+	}
+
+	if user.SuspendedAt != nil {
+		responses.Error(w, http.StatusForbidden, errors.New("User is suspended"))
+		return
+	}
+
+	totp, error := repository.GetTOTP(r.Context(), user.ID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if totp.Enabled {
+		challenge, error := mfaChallenge(user.ID, nil)
+
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, challenge)
+		return
+	}
+
+	tokens, error := issueTokens(store, r, user, nil)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	respondLogin(w, r, store, user.ID, tokens)
+}
@@ -0,0 +1,456 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/network"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/revocation"
+	"api/src/security"
+	"api/src/throttling"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// recoveryCodesCount is how many recovery codes a user receives when enabling two factor authentication
+const recoveryCodesCount = 10
+
+var errInvalidMFACode = errors.New("Invalid code")
+
+// EnrollMFA generate a pending two factor secret, enabled only after a first code is confirmed
+func EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	userID, error := mfaUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.Users()
+
+	totp, error := repository.GetTOTP(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if totp.Enabled {
+		responses.Error(w, http.StatusConflict, errors.New("Two factor authentication already enabled"))
+		return
+	}
+
+	user, error := repository.GetAccount(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	secret, error := security.GenerateTOTPSecret()
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error = repository.SetTOTPSecret(r.Context(), userID, secret); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.MFAEnrollment{
+		Secret: secret,
+		URI:    security.TOTPURI(config.TOTPIssuer, user.Email, secret),
+	})
+}
+
+// ConfirmMFA enable two factor authentication with a first code and return the recovery codes
+func ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	userID, error := mfaUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	code, error := readMFACode(w, r)
+
+	if error != nil {
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.Users()
+
+	totp, error := repository.GetTOTP(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if totp.Enabled {
+		responses.Error(w, http.StatusConflict, errors.New("Two factor authentication already enabled"))
+		return
+	}
+
+	if totp.Secret == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Two factor authentication was not enrolled"))
+		return
+	}
+
+	// Codes are guessed as they are on the login, so they are throttled the same way
+	accountKey := fmt.Sprintf("user:%d", userID)
+	addressKey := network.ClientIP(r)
+
+	if wait := loginBlocked(accountKey, addressKey); wait > 0 {
+		setRetryAfter(w, wait)
+		responses.Error(w, http.StatusTooManyRequests, errTooManyAttempts)
+		return
+	}
+
+	step, valid := security.ValidateTOTP(totp.Secret, code, time.Now())
+
+	if !valid {
+		failAttempt(w, http.StatusBadRequest, errInvalidMFACode, accountKey, addressKey)
+		return
+	}
+
+	throttling.LoginAccounts.Reset(accountKey)
+
+	recoveryCodes, error := enableMFA(r.Context(), store, userID, step)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.RecoveryCodes{RecoveryCodes: recoveryCodes})
+}
+
+// DisableMFA turn two factor authentication off, proven with a code
+func DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, error := mfaUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	code, error := readMFACode(w, r)
+
+	if error != nil {
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	accountKey := fmt.Sprintf("user:%d", userID)
+	addressKey := network.ClientIP(r)
+
+	if wait := loginBlocked(accountKey, addressKey); wait > 0 {
+		setRetryAfter(w, wait)
+		responses.Error(w, http.StatusTooManyRequests, errTooManyAttempts)
+		return
+	}
+
+	valid, error := verifySecondFactor(r.Context(), store, userID, code)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !valid {
+		failAttempt(w, http.StatusForbidden, errInvalidMFACode, accountKey, addressKey)
+		return
+	}
+
+	throttling.LoginAccounts.Reset(accountKey)
+
+	if error = disableMFA(r.Context(), store, userID); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// LoginMFA exchange the token given by the login and a code for the access tokens
+func LoginMFA(w http.ResponseWriter, r *http.Request) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.MFALogin
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	claims, error := authentication.ParseToken(request.MFAToken, authentication.TokenTypeMFA)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	revoked, error := revocation.Default.IsRevoked(claims.TokenID, claims.UserID, claims.IssuedAt)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if revoked {
+		responses.Error(w, http.StatusUnauthorized, errors.New("Token has been revoked"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	accountKey := fmt.Sprintf("user:%d", claims.UserID)
+	addressKey := network.ClientIP(r)
+
+	if wait := loginBlocked(accountKey, addressKey); wait > 0 {
+		recordFailedLogin(store, r, "", claims.UserID, models.LoginFailureLocked)
+		setRetryAfter(w, wait)
+		responses.Error(w, http.StatusTooManyRequests, errTooManyAttempts)
+		return
+	}
+
+	valid, error := verifySecondFactor(r.Context(), store, claims.UserID, request.Code)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if !valid {
+		recordFailedLogin(store, r, "", claims.UserID, models.LoginFailureInvalidCode)
+		failLogin(w, errInvalidMFACode, accountKey, addressKey)
+		return
+	}
+
+	throttling.LoginAccounts.Reset(accountKey)
+
+	// The challenge is finished, the same token cannot start another login
+	if error = revocation.Default.RevokeToken(claims.TokenID, claims.ExpiresAt); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	user, error := store.Users().GetAccount(r.Context(), claims.UserID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if user.SuspendedAt != nil {
+		responses.Error(w, http.StatusForbidden, errors.New("User is suspended"))
+		return
+	}
+
+	tokens, error := issueTokens(store, r, user, claims.Scopes)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	respondLogin(w, r, store, user.ID, tokens)
+}
+
+// mfaChallenge creates the token a user with two factor authentication exchanges at /login/mfa,
+// carrying the scopes requested on the login
+func mfaChallenge(userID uint64, scopes []string) (models.MFAChallenge, error) {
+	token, error := authentication.CreateToken(&authentication.Claims{
+		UserID:    userID,
+		Type:      authentication.TokenTypeMFA,
+		ExpiresAt: time.Now().Add(config.MFATokenDuration),
+		Roles:     []string{},
+		Scopes:    scopes,
+	})
+
+	if error != nil {
+		return models.MFAChallenge{}, error
+	}
+
+	return models.MFAChallenge{MFARequired: true, MFAToken: token}, nil
+}
+
+// verifySecondFactor check a code of the authenticator app or a recovery code, both are single use
+func verifySecondFactor(ctx context.Context, store repositories.Store, userID uint64, code string) (bool, error) {
+	repository := store.Users()
+
+	totp, error := repository.GetTOTP(ctx, userID)
+
+	if error != nil {
+		return false, error
+	}
+
+	if !totp.Enabled {
+		return false, nil
+	}
+
+	code = strings.ToLower(strings.TrimSpace(code))
+
+	if strings.Contains(code, "-") {
+		return store.RecoveryCodes().Use(ctx, userID, security.HashToken(code))
+	}
+
+	step, valid := security.ValidateTOTP(totp.Secret, code, time.Now())
+
+	if !valid {
+		return false, nil
+	}
+
+	return repository.UseTOTPStep(ctx, userID, step)
+}
+
+// enableMFA start requiring codes of the enrolled secret, the code of the step confirming it
+// is spent. It returns the first recovery codes of the user
+func enableMFA(ctx context.Context, store repositories.Store, userID uint64, step int64) ([]string, error) {
+	var recoveryCodes []string
+
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		repository := work.Users()
+
+		if _, error := repository.UseTOTPStep(ctx, userID, step); error != nil {
+			return error
+		}
+
+		if error := repository.EnableTOTP(ctx, userID); error != nil {
+			return error
+		}
+
+		codes, error := replaceRecoveryCodes(ctx, work, userID)
+		recoveryCodes = codes
+
+		return error
+	})
+
+	if error != nil {
+		return nil, error
+	}
+
+	return recoveryCodes, nil
+}
+
+// disableMFA stop requiring codes and discard the secret and recovery codes of the user
+func disableMFA(ctx context.Context, store repositories.Store, userID uint64) error {
+	return store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if error := work.Users().SetTOTPSecret(ctx, userID, ""); error != nil {
+			return error
+		}
+
+		return work.RecoveryCodes().DeleteUser(ctx, userID)
+	})
+}
+
+// replaceRecoveryCodes generate new recovery codes, storing only their hashes
+func replaceRecoveryCodes(ctx context.Context, store repositories.UnitOfWork, userID uint64) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+
+	for index := range codes {
+		code, error := security.GenerateRecoveryCode()
+
+		if error != nil {
+			return nil, error
+		}
+
+		codes[index] = code
+		hashes[index] = security.HashToken(code)
+	}
+
+	if error := store.RecoveryCodes().Replace(ctx, userID, hashes); error != nil {
+		return nil, error
+	}
+
+	return codes, nil
+}
+
+// mfaUser read the user of the route, which must be the user of the token
+func mfaUser(w http.ResponseWriter, r *http.Request) (uint64, error) {
+	params := mux.Vars(r)
+
+	userID, error := strconv.ParseUint(params["userID"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return 0, error
+	}
+
+	tokenUserID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return 0, error
+	}
+
+	if userID != tokenUserID {
+		error = errors.New("Cannot change two factor authentication of others users")
+		responses.Error(w, http.StatusForbidden, error)
+		return 0, error
+	}
+
+	return userID, nil
+}
+
+func readMFACode(w http.ResponseWriter, r *http.Request) (string, error) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return "", error
+	}
+
+	var request models.MFACode
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return "", error
+	}
+
+	if request.Code == "" {
+		error = errors.New("Field code cannot be empty")
+		responses.Error(w, http.StatusBadRequest, error)
+		return "", error
+	}
+
+	return request.Code, nil
+}
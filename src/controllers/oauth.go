@@ -0,0 +1,588 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/revocation"
+	"api/src/security"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Error codes of the oauth token endpoint, defined by RFC 6749
+const (
+	oauthInvalidRequest       = "invalid_request"
+	oauthInvalidClient        = "invalid_client"
+	oauthInvalidGrant         = "invalid_grant"
+	oauthUnsupportedGrantType = "unsupported_grant_type"
+	oauthServerError          = "server_error"
+)
+
+var (
+	errInvalidClient    = errors.New("Invalid client credentials")
+	errInvalidOAuthCode = errors.New("Invalid or expired authorization code")
+	// errOAuthCodeReplayed is returned along with a code presented again after being exchanged
+	errOAuthCodeReplayed = errors.New("Authorization code already used")
+)
+
+// OAuthConsent describe an authorization request for the user to approve,
+// the parameters are the ones of RFC 6749 with PKCE mandatory
+func OAuthConsent(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	request := models.OAuthAuthorization{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	client, scopes, statusCode, error := validateAuthorization(r.Context(), store, request)
+
+	if error != nil {
+		responses.Error(w, statusCode, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.OAuthConsent{
+		ClientID:    client.ClientID,
+		ClientName:  client.Name,
+		RedirectURI: request.RedirectURI,
+		Scopes:      scopes,
+		State:       request.State,
+	})
+}
+
+// OAuthAuthorize record the decision of the user on an authorization request and
+// answer the address the user agent must be redirected to, carrying the code when approved
+func OAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	claims, error := authentication.GetClaims(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	// Tokens issued to the client would act as the user without a trace of the admin
+	if claims.ActorID != 0 {
+		responses.Error(w, http.StatusForbidden, errors.New("Not allowed while impersonating a user"))
+		return
+	}
+
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.OAuthAuthorization
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	_, scopes, statusCode, error := validateAuthorization(r.Context(), store, request)
+
+	if error != nil {
+		responses.Error(w, statusCode, error)
+		return
+	}
+
+	params := url.Values{}
+
+	if request.State != "" {
+		params.Set("state", request.State)
+	}
+
+	if !request.Approve {
+		params.Set("error", "access_denied")
+		responses.JSON(w, http.StatusOK, models.OAuthRedirect{RedirectURI: redirectTo(request.RedirectURI, params)})
+		return
+	}
+
+	code, error := security.GenerateToken(32)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	repository := store.OAuthCodes()
+
+	if _, error = repository.Create(r.Context(), models.OAuthCode{
+		CodeHash:            security.HashToken(code),
+		ClientID:            request.ClientID,
+		UserID:              claims.UserID,
+		RedirectURI:         request.RedirectURI,
+		Scopes:              scopes,
+		CodeChallenge:       request.CodeChallenge,
+		CodeChallengeMethod: request.CodeChallengeMethod,
+		ExpiresAt:           time.Now().Add(config.OAuthCodeDuration),
+	}); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	params.Set("code", code)
+
+	responses.JSON(w, http.StatusOK, models.OAuthRedirect{RedirectURI: redirectTo(request.RedirectURI, params)})
+}
+
+// OAuthToken exchange an authorization code or a refresh token for tokens, the access
+// token is restricted to the scopes granted to the client
+func OAuthToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if error := r.ParseForm(); error != nil {
+		oauthError(w, http.StatusBadRequest, oauthInvalidRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	client, error := authenticateClient(store, r)
+
+	if error == errInvalidClient {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		oauthError(w, http.StatusUnauthorized, oauthInvalidClient, error)
+		return
+	}
+
+	if error != nil {
+		oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+		return
+	}
+
+	var (
+		tokens models.Tokens
+		scopes []string
+	)
+
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case "authorization_code":
+		tokens, scopes, error = exchangeCode(store, r, client)
+	case "refresh_token":
+		tokens, scopes, error = exchangeRefreshToken(r.Context(), store, r.PostForm.Get("refresh_token"), client.ClientID)
+	default:
+		oauthError(w, http.StatusBadRequest, oauthUnsupportedGrantType, fmt.Errorf("Unsupported grant type %s", grantType))
+		return
+	}
+
+	if error == errInvalidOAuthCode || error == errInvalidRefreshToken {
+		oauthError(w, http.StatusBadRequest, oauthInvalidGrant, error)
+		return
+	}
+
+	if error != nil {
+		oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.OAuthTokens{
+		AccessToken:  tokens.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(config.AccessTokenDuration.Seconds()),
+		RefreshToken: tokens.RefreshToken,
+		Scope:        authentication.FormatScope(scopes),
+	})
+}
+
+// OAuthIntrospect report if a token issued to the client is active, following RFC 7662.
+// Tokens of other clients are reported as inactive
+func OAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	if error := r.ParseForm(); error != nil {
+		oauthError(w, http.StatusBadRequest, oauthInvalidRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	client, error := authenticateClient(store, r)
+
+	if error == errInvalidClient {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		oauthError(w, http.StatusUnauthorized, oauthInvalidClient, error)
+		return
+	}
+
+	if error != nil {
+		oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+
+	if claims, error := authentication.ParseToken(token, authentication.TokenTypeAccess); error == nil {
+		if claims.ClientID != client.ClientID {
+			responses.JSON(w, http.StatusOK, models.OAuthIntrospection{})
+			return
+		}
+
+		// Tokens are inactive as soon as the api rejects them, the revoked sessions included
+		revoked, error := revocation.IsAccessRevoked(claims)
+
+		if error != nil {
+			oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+			return
+		}
+
+		if revoked {
+			responses.JSON(w, http.StatusOK, models.OAuthIntrospection{})
+			return
+		}
+
+		responses.JSON(w, http.StatusOK, models.OAuthIntrospection{
+			Active:    true,
+			Scope:     authentication.FormatScope(claims.Scopes),
+			ClientID:  claims.ClientID,
+			Subject:   strconv.FormatUint(claims.UserID, 10),
+			TokenType: "Bearer",
+			ExpiresAt: claims.ExpiresAt.Unix(),
+			IssuedAt:  claims.IssuedAt.Unix(),
+		})
+		return
+	}
+
+	storedToken, error := store.RefreshTokens().GetByHash(r.Context(), security.HashToken(token))
+
+	if error != nil {
+		oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+		return
+	}
+
+	if storedToken.ID == 0 || storedToken.ClientID != client.ClientID || storedToken.Revoked ||
+		time.Now().After(storedToken.ExpiresAt) {
+		responses.JSON(w, http.StatusOK, models.OAuthIntrospection{})
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, models.OAuthIntrospection{
+		Active:    true,
+		Scope:     authentication.FormatScope(storedToken.Scopes),
+		ClientID:  storedToken.ClientID,
+		Subject:   strconv.FormatUint(storedToken.UserID, 10),
+		TokenType: "refresh_token",
+		ExpiresAt: storedToken.ExpiresAt.Unix(),
+		IssuedAt:  storedToken.CreatedAt.Unix(),
+	})
+}
+
+// OAuthRevoke revoke a token issued to the client following RFC 7009, revoking a refresh
+// token ends its whole family. Unknown tokens are answered as revoked
+func OAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	if error := r.ParseForm(); error != nil {
+		oauthError(w, http.StatusBadRequest, oauthInvalidRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	client, error := authenticateClient(store, r)
+
+	if error == errInvalidClient {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		oauthError(w, http.StatusUnauthorized, oauthInvalidClient, error)
+		return
+	}
+
+	if error != nil {
+		oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+		return
+	}
+
+	token := r.PostForm.Get("token")
+
+	if claims, error := authentication.ParseToken(token, authentication.TokenTypeAccess); error == nil {
+		if claims.ClientID == client.ClientID {
+			if error = revocation.Default.RevokeToken(claims.TokenID, claims.ExpiresAt); error != nil {
+				oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+				return
+			}
+		}
+
+		responses.JSON(w, http.StatusOK, nil)
+		return
+	}
+
+	repository := store.RefreshTokens()
+
+	storedToken, error := repository.GetByHash(r.Context(), security.HashToken(token))
+
+	if error != nil {
+		oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+		return
+	}
+
+	if storedToken.ID != 0 && storedToken.ClientID == client.ClientID {
+		if error = repository.RevokeFamily(r.Context(), storedToken.FamilyID); error != nil {
+			oauthError(w, http.StatusInternalServerError, oauthServerError, error)
+			return
+		}
+	}
+
+	responses.JSON(w, http.StatusOK, nil)
+}
+
+// validateAuthorization check an authorization request and return the client and the
+// scopes to be granted, which default to every scope allowed to the client
+func validateAuthorization(ctx context.Context, store repositories.Store, request models.OAuthAuthorization) (models.OAuthClient, []string, int, error) {
+	client, error := store.OAuthClients().GetByClientID(ctx, request.ClientID)
+
+	if error != nil {
+		return models.OAuthClient{}, nil, http.StatusInternalServerError, error
+	}
+
+	if client.ID == 0 {
+		return models.OAuthClient{}, nil, http.StatusBadRequest, errors.New("Unknown client")
+	}
+
+	if !client.HasRedirectURI(request.RedirectURI) {
+		return models.OAuthClient{}, nil, http.StatusBadRequest, errors.New("Redirect uri not registered by the client")
+	}
+
+	if request.ResponseType != "code" {
+		return models.OAuthClient{}, nil, http.StatusBadRequest, errors.New("Only the code response type is supported")
+	}
+
+	if request.CodeChallenge == "" || request.CodeChallengeMethod != security.PKCEMethodS256 {
+		return models.OAuthClient{}, nil, http.StatusBadRequest, errors.New("A S256 code challenge is required")
+	}
+
+	if request.Scope == "" {
+		return client, client.Scopes, 0, nil
+	}
+
+	scopes := authentication.ParseScope(request.Scope)
+
+	for _, scope := range scopes {
+		if !client.AllowsScope(scope) {
+			return models.OAuthClient{}, nil, http.StatusBadRequest, fmt.Errorf("Scope %s not allowed to the client", scope)
+		}
+	}
+
+	return client, scopes, 0, nil
+}
+
+// exchangeCode consume an authorization code of the client and start a login for it in the
+// same transaction, so a code is only spent once its tokens exist. A code presented twice was
+// intercepted, so the login started for it ends
+func exchangeCode(store repositories.Store, r *http.Request, client models.OAuthClient) (models.Tokens, []string, error) {
+	var (
+		tokens  models.Tokens
+		scopes  []string
+		ended   models.Session
+		invalid bool
+	)
+
+	error := store.WithTx(r.Context(), func(work repositories.UnitOfWork) error {
+		code, user, error := useCode(work, r, client)
+
+		if error == errOAuthCodeReplayed {
+			error = errInvalidOAuthCode
+
+			if code.FamilyID != "" {
+				if ended, error = endFamily(r.Context(), work, code.FamilyID); error == nil {
+					error = errInvalidOAuthCode
+				}
+			}
+		}
+
+		if error == nil {
+			scopes = code.Scopes
+			tokens, error = issueCodeTokens(work, r, code, user)
+		}
+
+		// Spending an invalid code, or ending the login of a replayed one, is kept
+		invalid = error == errInvalidOAuthCode
+
+		if invalid {
+			return nil
+		}
+
+		return error
+	})
+
+	if error != nil {
+		return models.Tokens{}, nil, error
+	}
+
+	if ended.ID != 0 {
+		if error = revokeSessionTokens(ended); error != nil {
+			return models.Tokens{}, nil, error
+		}
+	}
+
+	if invalid {
+		return models.Tokens{}, nil, errInvalidOAuthCode
+	}
+
+	return tokens, scopes, nil
+}
+
+// useCode validate and consume an authorization code of the client, returning it along with
+// its user. A code already used fails with errOAuthCodeReplayed along with the code, other
+// invalid codes fail with errInvalidOAuthCode
+func useCode(store repositories.UnitOfWork, r *http.Request, client models.OAuthClient) (models.OAuthCode, models.User, error) {
+	form := r.PostForm
+
+	repository := store.OAuthCodes()
+
+	code, error := repository.GetByHash(r.Context(), security.HashToken(form.Get("code")))
+
+	if error != nil {
+		return models.OAuthCode{}, models.User{}, error
+	}
+
+	if code.ID == 0 || code.ClientID != client.ClientID {
+		return models.OAuthCode{}, models.User{}, errInvalidOAuthCode
+	}
+
+	used, error := repository.Use(r.Context(), code.ID)
+
+	if error != nil {
+		return models.OAuthCode{}, models.User{}, error
+	}
+
+	if !used {
+		return code, models.User{}, errOAuthCodeReplayed
+	}
+
+	if time.Now().After(code.ExpiresAt) || code.RedirectURI != form.Get("redirect_uri") ||
+		!security.VerifyPKCE(form.Get("code_verifier"), code.CodeChallenge, code.CodeChallengeMethod) {
+		return models.OAuthCode{}, models.User{}, errInvalidOAuthCode
+	}
+
+	user, error := store.Users().GetAccount(r.Context(), code.UserID)
+
+	if error != nil {
+		return models.OAuthCode{}, models.User{}, error
+	}
+
+	if user.ID == 0 || user.SuspendedAt != nil {
+		return models.OAuthCode{}, models.User{}, errInvalidOAuthCode
+	}
+
+	return code, user, nil
+}
+
+// issueCodeTokens start the login of an exchanged code, linking the code to the family of
+// its tokens so that a replay of the code revokes them
+func issueCodeTokens(store repositories.UnitOfWork, r *http.Request, code models.OAuthCode, user models.User) (models.Tokens, error) {
+	tokens, familyID, error := newTokenFamily(store, r, user, code.Scopes, code.ClientID)
+
+	if error != nil {
+		return models.Tokens{}, error
+	}
+
+	if error = store.OAuthCodes().SetFamily(r.Context(), code.ID, familyID); error != nil {
+		return models.Tokens{}, error
+	}
+
+	return tokens, nil
+}
+
+// authenticateClient identify the client of a request by http basic authentication or by
+// the client_id and client_secret form fields, public clients send no secret
+func authenticateClient(store repositories.Store, r *http.Request) (models.OAuthClient, error) {
+	clientID, clientSecret, basic := r.BasicAuth()
+
+	if basic {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" {
+		return models.OAuthClient{}, errInvalidClient
+	}
+
+	client, error := store.OAuthClients().GetByClientID(r.Context(), clientID)
+
+	if error != nil {
+		return models.OAuthClient{}, error
+	}
+
+	if client.ID == 0 || client.Confidential != (clientSecret != "") {
+		return models.OAuthClient{}, errInvalidClient
+	}
+
+	if client.Confidential &&
+		subtle.ConstantTimeCompare([]byte(security.HashToken(clientSecret)), []byte(client.SecretHash)) != 1 {
+		return models.OAuthClient{}, errInvalidClient
+	}
+
+	return client, nil
+}
+
+// redirectTo add the parameters to the query of a redirect uri
+func redirectTo(redirectURI string, params url.Values) string {
+	address, error := url.Parse(redirectURI)
+
+	if error != nil {
+		return redirectURI
+	}
+
+	query := address.Query()
+
+	for name, values := range params {
+		query[name] = values
+	}
+
+	address.RawQuery = query.Encode()
+
+	return address.String()
+}
+
+// oauthError return an error in the format of RFC 6749
+func oauthError(w http.ResponseWriter, statusCode int, code string, error error) {
+	responses.JSON(w, statusCode, struct {
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}{
+		Error:            code,
+		ErrorDescription: error.Error(),
+	})
+}
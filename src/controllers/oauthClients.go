@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/security"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+var errOAuthClientNotFound = errors.New("Oauth client not found")
+
+// CreateOAuthClient register an oauth client owned by the user, the secret of
+// confidential clients is only shown in this answer
+func CreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var client models.OAuthClient
+
+	if error = json.Unmarshal(requestBody, &client); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error = client.Prepare(); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	for _, scope := range client.Scopes {
+		if !authentication.ValidScope(scope) {
+			responses.Error(w, http.StatusBadRequest, fmt.Errorf("Invalid scope %s", scope))
+			return
+		}
+	}
+
+	if len(client.Scopes) == 0 {
+		client.Scopes = append([]string{}, authentication.Scopes...)
+	}
+
+	client.OwnerID = userID
+
+	if client.ClientID, error = security.GenerateToken(16); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if client.Confidential {
+		if client.ClientSecret, error = security.GenerateToken(32); error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
+
+		client.SecretHash = security.HashToken(client.ClientSecret)
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.OAuthClients()
+
+	if client.ID, error = repository.Create(r.Context(), client); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusCreated, client)
+}
+
+// ListOAuthClients list the oauth clients registered by the user
+func ListOAuthClients(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.OAuthClients()
+
+	clients, error := repository.ListByOwner(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, clients)
+}
+
+// DeleteOAuthClient delete an oauth client of the user, ending the logins made through it
+func DeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	clientID := mux.Vars(r)["clientId"]
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	error = deleteOAuthClient(r.Context(), store, clientID, userID)
+
+	if error == errOAuthClientNotFound {
+		responses.Error(w, http.StatusNotFound, error)
+		return
+	}
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// deleteOAuthClient delete a client of the user and revoke the tokens issued to it, a client
+// the user does not own fails with errOAuthClientNotFound
+func deleteOAuthClient(ctx context.Context, store repositories.Store, clientID string, userID uint64) error {
+	return store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		deleted, error := work.OAuthClients().Delete(ctx, clientID, userID)
+
+		if error != nil {
+			return error
+		}
+
+		if !deleted {
+			return errOAuthClientNotFound
+		}
+
+		return work.RefreshTokens().RevokeClient(ctx, clientID)
+	})
+}
@@ -0,0 +1,201 @@
+package controllers
+
+import (
+	"api/src/config"
+	"api/src/mailer"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/security"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+var errInvalidResetToken = errors.New("Invalid or expired token")
+
+// ForgotPassword email a password reset token, the answer is the same whether the email exists or not
+func ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.ForgotPassword
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if request.Email == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field email cannot be empty"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	user, error := store.Users().SearchByEmail(r.Context(), request.Email)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	// Failures are not answered, as only existing accounts could run into them
+	if error = sendPasswordReset(r.Context(), store, user, request.Email); error != nil {
+		log.Printf("Could not send a password reset to user %d: %v", user.ID, error)
+	}
+
+	responses.JSON(w, http.StatusAccepted, nil)
+}
+
+// sendPasswordReset email a reset token to the user. Unknown and suspended accounts generate a
+// token and look it up instead of keeping and sending it, so they take about as long to answer
+func sendPasswordReset(ctx context.Context, store repositories.Store, user models.User, email string) error {
+	token, error := security.GenerateToken(32)
+
+	if error != nil {
+		return error
+	}
+
+	repository := store.PasswordResets()
+	tokenHash := security.HashToken(token)
+
+	if user.ID == 0 || user.SuspendedAt != nil {
+		_, error = repository.GetByHash(ctx, tokenHash)
+		return error
+	}
+
+	if _, error = repository.Create(ctx, models.OneTimeToken{
+		UserID:    user.ID,
+		TokenHash: tokenHash,
+		ExpiresAt: time.Now().Add(config.PasswordResetDuration),
+	}); error != nil {
+		return error
+	}
+
+	return mailer.Default.Send(passwordResetMessage(email, token))
+}
+
+// ResetPassword replace the password of the owner of a reset token and end all of its logins
+func ResetPassword(w http.ResponseWriter, r *http.Request) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.ResetPassword
+
+	if error = json.Unmarshal(requestBody, &request); error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if request.Token == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field token cannot be empty"))
+		return
+	}
+
+	if request.NewPassword == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field newPassword cannot be empty"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	repository := store.PasswordResets()
+
+	token, error := repository.GetByHash(r.Context(), security.HashToken(request.Token))
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if token.ID == 0 {
+		responses.Error(w, http.StatusBadRequest, errInvalidResetToken)
+		return
+	}
+
+	user, error := store.Users().Get(r.Context(), token.UserID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error = models.ValidatePassword("newPassword", request.NewPassword, user.Nick, user.Email); error != nil {
+		responses.Error(w, passwordErrorStatus(error), error)
+		return
+	}
+
+	hashPassword, error := security.Hash(request.NewPassword)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	error = resetPassword(r.Context(), store, token, string(hashPassword))
+
+	if error == errInvalidResetToken {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// resetPassword consume the reset token and replace the password of its user, ending every
+// login made with the previous password. A token already used fails with errInvalidResetToken
+func resetPassword(ctx context.Context, store repositories.Store, token models.OneTimeToken, hashPassword string) error {
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		used, error := work.PasswordResets().Use(ctx, token.ID)
+
+		if error != nil {
+			return error
+		}
+
+		if !used {
+			return errInvalidResetToken
+		}
+
+		if error = work.Users().UpdatePassword(ctx, hashPassword, token.UserID); error != nil {
+			return error
+		}
+
+		if error = work.PasswordResets().InvalidateUser(ctx, token.UserID); error != nil {
+			return error
+		}
+
+		return work.RefreshTokens().RevokeUser(ctx, token.UserID)
+	})
+
+	if error != nil {
+		return error
+	}
+
+	return revokeAccessTokens(token.UserID)
+}
@@ -2,8 +2,8 @@ package controllers
 
 import (
 	"api/src/authentication"
+	"api/src/config"
 	"api/src/models"
-	"api/src/repositories"
 	"api/src/responses"
 	"encoding/json"
 	"errors"
@@ -44,17 +44,29 @@ func CreatePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	if config.RequireVerifiedEmailToPost {
+		user, error := store.Users().GetAccount(r.Context(), userID)
 
-	repository := repositories.NewPublicationRepository(db)
+		if error != nil {
+			responses.Error(w, http.StatusInternalServerError, error)
+			return
+		}
 
-	publication.ID, error = repository.CreatePublication(publication)
+		if user.VerifiedAt == nil {
+			responses.Error(w, http.StatusForbidden, errors.New("Verify your email before publishing"))
+			return
+		}
+	}
+
+	repository := store.Publications()
+
+	publication.ID, error = repository.CreatePublication(r.Context(), publication)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -73,17 +85,15 @@ func ListPublications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Publications()
 
-	repository := repositories.NewPublicationRepository(db)
-
-	publications, error := repository.ListPublications(userID)
+	publications, error := repository.ListPublications(r.Context(), userID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -105,17 +115,15 @@ func GetPublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewPublicationRepository(db)
+	repository := store.Publications()
 
-	publication, error := repository.GetPublication(publicationID)
+	publication, error := repository.GetPublication(r.Context(), publicationID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -143,17 +151,15 @@ func UpdatePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Publications()
 
-	repository := repositories.NewPublicationRepository(db)
-
-	databasePublication, error := repository.GetPublication(publicationID)
+	databasePublication, error := repository.GetPublication(r.Context(), publicationID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -184,7 +190,7 @@ func UpdatePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if error = repository.UpdatePublication(publication, publicationID); error != nil {
+	if error = repository.UpdatePublication(r.Context(), publication, publicationID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
@@ -210,17 +216,15 @@ func DeletePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewPublicationRepository(db)
+	repository := store.Publications()
 
-	databasePublication, error := repository.GetPublication(publicationID)
+	databasePublication, error := repository.GetPublication(r.Context(), publicationID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -232,7 +236,7 @@ func DeletePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	error = repository.DeletePublication(publicationID)
+	error = repository.DeletePublication(r.Context(), publicationID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -254,17 +258,15 @@ func ListUserPublications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewPublicationRepository(db)
+	repository := store.Publications()
 
-	publications, error := repository.ListUserPublications(userID)
+	publications, error := repository.ListUserPublications(r.Context(), userID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -286,17 +288,15 @@ func LikePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Publications()
 
-	repository := repositories.NewPublicationRepository(db)
-
-	if error = repository.LikePublication(publicationID); error != nil {
+	if error = repository.LikePublication(r.Context(), publicationID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
@@ -316,17 +316,15 @@ func UnLikePublication(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewPublicationRepository(db)
+	repository := store.Publications()
 
-	if error = repository.UnLikePublication(publicationID); error != nil {
+	if error = repository.UnLikePublication(r.Context(), publicationID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
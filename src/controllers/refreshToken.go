@@ -0,0 +1,316 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/network"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/security"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+var (
+	errInvalidRefreshToken = errors.New("Invalid refresh token")
+	// errRefreshTokenReplayed is returned along with a token presented again after being spent
+	errRefreshTokenReplayed = errors.New("Refresh token already used")
+)
+
+// RefreshToken exchange a refresh token for a new access token, rotating the refresh token.
+// In cookie mode the refresh token can come from its cookie
+func RefreshToken(w http.ResponseWriter, r *http.Request) {
+	requestBody, error := ioutil.ReadAll(r.Body)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnprocessableEntity, error)
+		return
+	}
+
+	var request models.RefreshRequest
+
+	if len(requestBody) > 0 {
+		if error = json.Unmarshal(requestBody, &request); error != nil {
+			responses.Error(w, http.StatusBadRequest, error)
+			return
+		}
+	}
+
+	if request.RefreshToken == "" {
+		if cookie, error := r.Cookie(authentication.RefreshTokenCookie); error == nil {
+			request.RefreshToken = cookie.Value
+		}
+	}
+
+	if request.RefreshToken == "" {
+		responses.Error(w, http.StatusBadRequest, errors.New("Field refreshToken cannot be empty"))
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	tokens, _, error := exchangeRefreshToken(r.Context(), store, request.RefreshToken, "")
+
+	if error == errInvalidRefreshToken {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error = setTokenCookies(w, tokens); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusOK, tokens)
+}
+
+// exchangeRefreshToken consume a refresh token issued to the client, an empty client id
+// standing for the api own login, and issue its successor in the same transaction, so a token
+// is only spent once the next one exists. A spent token being replayed means it leaked, so its
+// whole session ends. Invalid tokens fail with errInvalidRefreshToken, returning the scopes of
+// the token otherwise
+func exchangeRefreshToken(ctx context.Context, store repositories.Store, plainToken, clientID string) (models.Tokens, []string, error) {
+	var (
+		tokens  models.Tokens
+		scopes  []string
+		ended   models.Session
+		invalid bool
+	)
+
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		storedToken, user, error := consumeRefreshToken(ctx, work, plainToken, clientID)
+
+		if error == errRefreshTokenReplayed {
+			if ended, error = endFamily(ctx, work, storedToken.FamilyID); error == nil {
+				error = errInvalidRefreshToken
+			}
+		}
+
+		if error == nil {
+			scopes = storedToken.Scopes
+			tokens, error = rotateTokens(ctx, work, storedToken, user)
+		}
+
+		// Spending an invalid token, or ending the session of a replayed one, is kept
+		invalid = error == errInvalidRefreshToken
+
+		if invalid {
+			return nil
+		}
+
+		return error
+	})
+
+	if error != nil {
+		return models.Tokens{}, nil, error
+	}
+
+	if ended.ID != 0 {
+		if error = revokeSessionTokens(ended); error != nil {
+			return models.Tokens{}, nil, error
+		}
+	}
+
+	if invalid {
+		return models.Tokens{}, nil, errInvalidRefreshToken
+	}
+
+	return tokens, scopes, nil
+}
+
+// consumeRefreshToken validate and consume a refresh token issued to the client. A token
+// already spent fails with errRefreshTokenReplayed along with the token, other invalid tokens
+// fail with errInvalidRefreshToken
+func consumeRefreshToken(ctx context.Context, store repositories.UnitOfWork, plainToken, clientID string) (models.RefreshToken, models.User, error) {
+	repository := store.RefreshTokens()
+
+	storedToken, error := repository.GetByHash(ctx, security.HashToken(plainToken))
+
+	if error != nil {
+		return models.RefreshToken{}, models.User{}, error
+	}
+
+	if storedToken.ID == 0 || storedToken.ClientID != clientID {
+		return models.RefreshToken{}, models.User{}, errInvalidRefreshToken
+	}
+
+	consumed := false
+
+	if !storedToken.Revoked {
+		if consumed, error = repository.Consume(ctx, storedToken.ID); error != nil {
+			return models.RefreshToken{}, models.User{}, error
+		}
+	}
+
+	if !consumed {
+		return storedToken, models.User{}, errRefreshTokenReplayed
+	}
+
+	if time.Now().After(storedToken.ExpiresAt) {
+		return models.RefreshToken{}, models.User{}, errInvalidRefreshToken
+	}
+
+	user, error := store.Users().GetAccount(ctx, storedToken.UserID)
+
+	if error != nil {
+		return models.RefreshToken{}, models.User{}, error
+	}
+
+	if user.ID == 0 || user.SuspendedAt != nil {
+		return models.RefreshToken{}, models.User{}, errInvalidRefreshToken
+	}
+
+	return storedToken, user, nil
+}
+
+// issueTokens starts a session with an access token and the first refresh token of a new
+// family, restricted to the scopes unless they are nil
+func issueTokens(store repositories.Store, r *http.Request, user models.User, scopes []string) (models.Tokens, error) {
+	var tokens models.Tokens
+
+	error := store.WithTx(r.Context(), func(work repositories.UnitOfWork) error {
+		issued, _, error := newTokenFamily(work, r, user, scopes, "")
+		tokens = issued
+
+		return error
+	})
+
+	if error != nil {
+		return models.Tokens{}, error
+	}
+
+	return tokens, nil
+}
+
+// newTokenFamily starts a session and its family of refresh tokens for a login of the user,
+// through the client when one is informed, returning the tokens and the family id
+func newTokenFamily(store repositories.UnitOfWork, r *http.Request, user models.User, scopes []string, clientID string) (models.Tokens, string, error) {
+	familyID, error := security.GenerateToken(16)
+
+	if error != nil {
+		return models.Tokens{}, "", error
+	}
+
+	refreshToken := models.RefreshToken{
+		UserID:            user.ID,
+		FamilyID:          familyID,
+		ClientID:          clientID,
+		AbsoluteExpiresAt: time.Now().Add(config.SessionMaxDuration),
+		Scopes:            scopes,
+	}
+
+	sessionID, error := store.Sessions().Create(r.Context(), models.Session{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		ClientID:  clientID,
+		UserAgent: truncate(r.UserAgent(), 255),
+		IP:        network.ClientIP(r),
+		ExpiresAt: refreshToken.AbsoluteExpiresAt,
+	})
+
+	if error != nil {
+		return models.Tokens{}, "", error
+	}
+
+	tokens, _, error := createTokens(r.Context(), store, user, refreshToken, sessionID)
+
+	return tokens, familyID, error
+}
+
+// rotateTokens creates the successor of a consumed refresh token in the same family
+func rotateTokens(ctx context.Context, store repositories.UnitOfWork, previous models.RefreshToken, user models.User) (models.Tokens, error) {
+	session, error := store.Sessions().GetByFamily(ctx, previous.FamilyID)
+
+	if error != nil {
+		return models.Tokens{}, error
+	}
+
+	if session.Revoked {
+		return models.Tokens{}, errInvalidRefreshToken
+	}
+
+	tokens, refreshTokenID, error := createTokens(ctx, store, user, models.RefreshToken{
+		UserID:            previous.UserID,
+		FamilyID:          previous.FamilyID,
+		ClientID:          previous.ClientID,
+		AbsoluteExpiresAt: previous.AbsoluteExpiresAt,
+		Scopes:            previous.Scopes,
+	}, session.ID)
+
+	if error != nil {
+		return models.Tokens{}, error
+	}
+
+	if error = store.RefreshTokens().SetReplacement(ctx, previous.ID, refreshTokenID); error != nil {
+		return models.Tokens{}, error
+	}
+
+	return tokens, nil
+}
+
+// createTokens sign an access token and persist a new refresh token, the refresh token
+// expiration slides on every rotation but never passes the absolute expiration of the family.
+// The session, when there is one, is marked as seen with the new access token
+func createTokens(ctx context.Context, store repositories.UnitOfWork, user models.User, refreshToken models.RefreshToken, sessionID uint64) (models.Tokens, uint64, error) {
+	claims := authentication.Claims{
+		UserID:    user.ID,
+		Roles:     user.Roles,
+		Scopes:    refreshToken.Scopes,
+		ClientID:  refreshToken.ClientID,
+		SessionID: sessionID,
+	}
+
+	accessToken, error := authentication.CreateToken(&claims)
+
+	if error != nil {
+		return models.Tokens{}, 0, error
+	}
+
+	plainRefreshToken, error := security.GenerateToken(32)
+
+	if error != nil {
+		return models.Tokens{}, 0, error
+	}
+
+	refreshToken.TokenHash = security.HashToken(plainRefreshToken)
+	refreshToken.ExpiresAt = time.Now().Add(config.RefreshTokenDuration)
+
+	if refreshToken.ExpiresAt.After(refreshToken.AbsoluteExpiresAt) {
+		refreshToken.ExpiresAt = refreshToken.AbsoluteExpiresAt
+	}
+
+	repository := store.RefreshTokens()
+
+	refreshTokenID, error := repository.Create(ctx, refreshToken)
+
+	if error != nil {
+		return models.Tokens{}, 0, error
+	}
+
+	if sessionID != 0 {
+		if error = store.Sessions().Seen(ctx, sessionID, claims.TokenID); error != nil {
+			return models.Tokens{}, 0, error
+		}
+	}
+
+	return models.Tokens{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresAt:    claims.ExpiresAt,
+		RefreshToken: plainRefreshToken,
+	}, refreshTokenID, nil
+}
@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"api/src/models"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+)
+
+// setRetryAfter tell the client how many seconds to wait before trying again
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", fmt.Sprint(math.Ceil(wait.Seconds())))
+}
+
+// passwordErrorStatus returns the status of a failed password validation, only
+// policy violations are the client fault
+func passwordErrorStatus(error error) int {
+	if _, ok := error.(models.FieldErrors); ok {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
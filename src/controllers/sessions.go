@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/responses"
+	"api/src/revocation"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// ListSessions list the active logins of the user, telling which one is making the request
+func ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, error := sessionUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	claims, error := authentication.GetClaims(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	sessions, error := store.Sessions().ListActive(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	for index := range sessions {
+		sessions[index].Current = sessions[index].ID == claims.SessionID
+	}
+
+	responses.JSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession end a login of the user, its refresh and access tokens stop working
+func RevokeSession(w http.ResponseWriter, r *http.Request) {
+	userID, error := sessionUser(w, r)
+
+	if error != nil {
+		return
+	}
+
+	sessionID, error := strconv.ParseUint(mux.Vars(r)["sessionId"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return
+	}
+
+	store, error := SetStore(w)
+
+	if error != nil {
+		return
+	}
+
+	session, error := store.Sessions().Get(r.Context(), sessionID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if session.ID == 0 || session.UserID != userID || session.Revoked {
+		responses.Error(w, http.StatusNotFound, errors.New("Session not found"))
+		return
+	}
+
+	if error = revokeSession(r.Context(), store, session); error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	responses.JSON(w, http.StatusNoContent, nil)
+}
+
+// revokeSession end a session, both its refresh tokens and the access tokens carrying it
+func revokeSession(ctx context.Context, store repositories.Store, session models.Session) error {
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if _, error := work.Sessions().Revoke(ctx, session.ID); error != nil {
+			return error
+		}
+
+		return work.RefreshTokens().RevokeFamily(ctx, session.FamilyID)
+	})
+
+	if error != nil {
+		return error
+	}
+
+	return revokeSessionTokens(session)
+}
+
+// endFamily revoke a family of refresh tokens along with its session, returning the session
+// so the access tokens carrying it are revoked once the unit of work is committed
+func endFamily(ctx context.Context, store repositories.UnitOfWork, familyID string) (models.Session, error) {
+	if error := store.RefreshTokens().RevokeFamily(ctx, familyID); error != nil {
+		return models.Session{}, error
+	}
+
+	session, error := store.Sessions().GetByFamily(ctx, familyID)
+
+	if error != nil || session.ID == 0 {
+		return models.Session{}, error
+	}
+
+	if _, error = store.Sessions().Revoke(ctx, session.ID); error != nil {
+		return models.Session{}, error
+	}
+
+	return session, nil
+}
+
+// revokeSessionTokens reject the access tokens carrying a session, the revocation store is not
+// part of the units of work so it is written once the session is revoked and committed
+func revokeSessionTokens(session models.Session) error {
+	return revocation.Default.RevokeToken(revocation.SessionTokenID(session.ID), session.ExpiresAt)
+}
+
+// sessionUser read the user of the route, which must be the user of the credential
+func sessionUser(w http.ResponseWriter, r *http.Request) (uint64, error) {
+	params := mux.Vars(r)
+
+	userID, error := strconv.ParseUint(params["userID"], 10, 64)
+
+	if error != nil {
+		responses.Error(w, http.StatusBadRequest, error)
+		return 0, error
+	}
+
+	tokenUserID, error := authentication.GetUserId(r)
+
+	if error != nil {
+		responses.Error(w, http.StatusUnauthorized, error)
+		return 0, error
+	}
+
+	if userID != tokenUserID {
+		error = errors.New("Cannot manage sessions of others users")
+		responses.Error(w, http.StatusForbidden, error)
+		return 0, error
+	}
+
+	return userID, nil
+}
@@ -1,20 +1,41 @@
 package controllers
 
 import (
-	"api/src/database"
+	"api/src/container"
+	"api/src/repositories"
 	"api/src/responses"
 	"database/sql"
+	"errors"
 	"net/http"
 )
 
+// dependencies shared by the controllers, set at startup by Inject
+var dependencies = &container.Container{}
+
+// Inject set the dependencies of the controllers
+func Inject(c *container.Container) {
+	dependencies = c
+}
+
+// SetDatabase returns the shared connection pool, which must not be closed
 func SetDatabase(w http.ResponseWriter) (*sql.DB, error) {
 
-	db, error := database.Connect()
+	if dependencies.DB == nil {
+		error := errors.New("Database is not configured")
+		responses.Error(w, http.StatusInternalServerError, error)
+		return nil, error
+	}
+
+	return dependencies.DB, nil
+}
 
-	if error != nil {
+// SetStore returns the store of the repositories
+func SetStore(w http.ResponseWriter) (repositories.Store, error) {
+	if dependencies.Store == nil {
+		error := errors.New("Database is not configured")
 		responses.Error(w, http.StatusInternalServerError, error)
 		return nil, error
 	}
 
-	return db, nil
-}
\ No newline at end of file
+	return dependencies.Store, nil
+}
@@ -6,9 +6,11 @@ import (
 	"api/src/repositories"
 	"api/src/responses"
 	"api/src/security"
+	"context"
 	"encoding/json"
 	"errors"
 	"io/ioutil"
+	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -37,24 +39,27 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
 	// Instancia um novo repositorio através de um generator
-	repository := repositories.NewUserRepository(db)
+	repository := store.Users()
 
-	user.ID, error = repository.Create(user)
+	user.ID, error = repository.Create(r.Context(), user)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
+	// The account exists already, a failed email can be sent again through the resend route
+	if error = sendVerification(r.Context(), store, user.ID, user.Email); error != nil {
+		log.Printf("Could not send verification to user %d: %v", user.ID, error)
+	}
+
 	responses.JSON(w, http.StatusCreated, user)
 }
 
@@ -62,17 +67,15 @@ func CreateUser(w http.ResponseWriter, r *http.Request) {
 func GetUsers(w http.ResponseWriter, r *http.Request) {
 	userQuery := strings.ToLower(r.URL.Query().Get("user"))
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Users()
 
-	repository := repositories.NewUserRepository(db)
-
-	users, error := repository.Search(userQuery)
+	users, error := repository.Search(r.Context(), userQuery)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -93,17 +96,15 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
+	repository := store.Users()
 
-	user, error := repository.Get(userID)
+	user, error := repository.Get(r.Context(), userID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -155,21 +156,32 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Users()
 
-	repository := repositories.NewUserRepository(db)
+	databaseUser, error := repository.GetAccount(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
 
-	if error = repository.Update(userID, user); error != nil {
+	if error = repository.Update(r.Context(), userID, user); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
+	if databaseUser.Email != user.Email {
+		if error = sendVerification(r.Context(), store, userID, user.Email); error != nil {
+			log.Printf("Could not send verification to user %d: %v", userID, error)
+		}
+	}
+
 	responses.JSON(w, http.StatusNoContent, nil)
 }
 
@@ -196,17 +208,13 @@ func DeleteUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
-
-	if error = repository.Delete(userID); error != nil {
+	if error = deleteUser(r.Context(), store, userID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
@@ -237,17 +245,15 @@ func FollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Users()
 
-	repository := repositories.NewUserRepository(db)
-
-	if error := repository.Follow(userID, followerID); error != nil {
+	if error := repository.Follow(r.Context(), userID, followerID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
@@ -279,17 +285,15 @@ func UnFollowUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
+	repository := store.Users()
 
-	if error := repository.UnFollow(userID, followerID); error != nil {
+	if error := repository.UnFollow(r.Context(), userID, followerID); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
@@ -308,17 +312,15 @@ func GetFollowers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Users()
 
-	repository := repositories.NewUserRepository(db)
-
-	followers, error := repository.GetFollowers(userID)
+	followers, error := repository.GetFollowers(r.Context(), userID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -340,17 +342,15 @@ func GetFollowing(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
-
-	repository := repositories.NewUserRepository(db)
+	repository := store.Users()
 
-	following, error := repository.GetFollowing(userID)
+	following, error := repository.GetFollowing(r.Context(), userID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -397,17 +397,15 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	db, error := SetDatabase(w)
+	store, error := SetStore(w)
 
 	if error != nil {
 		return
 	}
 
-	defer db.Close()
+	repository := store.Users()
 
-	repository := repositories.NewUserRepository(db)
-
-	dbPassword, error := repository.GetPassword(userID)
+	dbPassword, error := repository.GetPassword(r.Context(), userID)
 
 	if error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
@@ -419,6 +417,18 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	user, error := repository.Get(r.Context(), userID)
+
+	if error != nil {
+		responses.Error(w, http.StatusInternalServerError, error)
+		return
+	}
+
+	if error = models.ValidatePassword("newPassword", password.NewPassword, user.Nick, user.Email); error != nil {
+		responses.Error(w, passwordErrorStatus(error), error)
+		return
+	}
+
 	hashPassword, error := security.Hash(password.NewPassword)
 
 	if error != nil {
@@ -426,10 +436,27 @@ func UpdatePassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if error = repository.UpdatePassword(string(hashPassword), userID); error != nil {
+	if error = changePassword(r.Context(), store, userID, string(hashPassword)); error != nil {
 		responses.Error(w, http.StatusInternalServerError, error)
 		return
 	}
 
 	responses.JSON(w, http.StatusNoContent, nil)
 }
+
+// changePassword store the new password hash of the user and end all of its logins
+func changePassword(ctx context.Context, store repositories.Store, userID uint64, hashPassword string) error {
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if error := work.Users().UpdatePassword(ctx, hashPassword, userID); error != nil {
+			return error
+		}
+
+		return work.RefreshTokens().RevokeUser(ctx, userID)
+	})
+
+	if error != nil {
+		return error
+	}
+
+	return revokeAccessTokens(userID)
+}
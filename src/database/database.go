@@ -3,19 +3,78 @@ package database
 import (
 	"api/src/config"
 	"database/sql"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql" // Driver mySql
+	_ "github.com/lib/pq"              // Driver PostgreSQL
+	_ "github.com/mattn/go-sqlite3"    // Driver SQLite
 )
 
-// Connect open database connection and return
-func Connect() (*sql.DB, error) {
+// Connect open the connection pool shared by the whole api, sized by the configuration,
+// along with the dialect of its database
+func Connect() (*sql.DB, Dialect, error) {
+	dialect, error := DialectOf(config.DBDriver)
 
-	db, error := sql.Open("mysql", config.ConnectionString)
+	if error != nil {
+		return nil, Dialect{}, error
+	}
+
+	db, error := Open(dialect, config.ConnectionString)
+
+	return db, dialect, error
+}
+
+// ConnectMigrations open a pool whose connections run the several statements of a migration
+// file at once, which the pool of the api refuses on mysql
+func ConnectMigrations() (*sql.DB, Dialect, error) {
+	dialect, error := DialectOf(config.DBDriver)
+
+	if error != nil {
+		return nil, Dialect{}, error
+	}
+
+	db, error := OpenMigrations(dialect, config.ConnectionString)
+
+	return db, dialect, error
+}
+
+// OpenMigrations open a pool on a database of the dialect able to run migration files
+func OpenMigrations(dialect Dialect, connectionString string) (*sql.DB, error) {
+	if dialect == MySQL {
+		connectionString = withParameter(connectionString, "multiStatements=true")
+	}
+
+	return Open(dialect, connectionString)
+}
+
+// Open open a pool on a database of the dialect
+func Open(dialect Dialect, connectionString string) (*sql.DB, error) {
+	// SQLite only enforces foreign keys, which the deletes rely on to cascade, when asked
+	if dialect == SQLite && !strings.Contains(connectionString, "_foreign_keys") {
+		connectionString = withParameter(connectionString, "_foreign_keys=on")
+	}
+
+	// The connections of the pool wait for each other instead of failing on a locked file
+	if dialect == SQLite && !strings.Contains(connectionString, "_busy_timeout") {
+		connectionString = withParameter(connectionString, "_busy_timeout=5000")
+	}
+
+	// Transactions take the write lock when they begin instead of upgrading to it, which would
+	// deadlock two of them reading before writing
+	if dialect == SQLite && !strings.Contains(connectionString, "_txlock") {
+		connectionString = withParameter(connectionString, "_txlock=immediate")
+	}
+
+	db, error := sql.Open(dialect.Driver, connectionString)
 
 	if error != nil {
 		return nil, error
 	}
 
+	db.SetMaxOpenConns(config.DBMaxOpenConns)
+	db.SetMaxIdleConns(config.DBMaxIdleConns)
+	db.SetConnMaxLifetime(config.DBConnMaxLifetime)
+
 	if error = db.Ping(); error != nil {
 		db.Close()
 		return nil, error
@@ -23,3 +82,12 @@ func Connect() (*sql.DB, error) {
 
 	return db, nil
 }
+
+// withParameter add a parameter to the query of a connection string
+func withParameter(connectionString, parameter string) string {
+	if strings.Contains(connectionString, "?") {
+		return connectionString + "&" + parameter
+	}
+
+	return connectionString + "?" + parameter
+}
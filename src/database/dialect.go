@@ -0,0 +1,122 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+)
+
+// Dialect is the flavor of sql spoken by a database. Queries are written for MySQL, with ?
+// placeholders, and adapted by the dialect to the other databases
+type Dialect struct {
+	// Name selects the dialect in config.DBDriver and names the folder of its migrations
+	Name string
+	// Driver is the database/sql driver of the dialect
+	Driver string
+	// numbered placeholders like $1 instead of ?
+	numbered bool
+	// returning ids from inserts instead of reporting the last insert id
+	returning bool
+}
+
+var (
+	// MySQL is the dialect of MySQL and MariaDB
+	MySQL = Dialect{Name: "mysql", Driver: "mysql"}
+	// PostgreSQL is the dialect of PostgreSQL
+	PostgreSQL = Dialect{Name: "postgres", Driver: "postgres", numbered: true, returning: true}
+	// SQLite is the dialect of SQLite, meant for development and tests
+	SQLite = Dialect{Name: "sqlite3", Driver: "sqlite3"}
+)
+
+// Dialects lists every dialect the api supports
+var Dialects = []Dialect{MySQL, PostgreSQL, SQLite}
+
+// DialectOf returns the dialect of a name
+func DialectOf(name string) (Dialect, error) {
+	for _, dialect := range Dialects {
+		if dialect.Name == name {
+			return dialect, nil
+		}
+	}
+
+	return Dialect{}, fmt.Errorf("Unknown database driver %s", name)
+}
+
+// Rebind replace the ? placeholders of a query by the ones of the dialect, question marks
+// inside quoted strings are kept
+func (dialect Dialect) Rebind(query string) string {
+	if !dialect.numbered {
+		return query
+	}
+
+	var (
+		rebound strings.Builder
+		number  int
+		quoted  bool
+	)
+
+	for _, character := range query {
+		switch {
+		case character == '\'':
+			quoted = !quoted
+		case character == '?' && !quoted:
+			number++
+			rebound.WriteString("$" + strconv.Itoa(number))
+			continue
+		}
+
+		rebound.WriteRune(character)
+	}
+
+	return rebound.String()
+}
+
+// InsertIgnore returns an insert that skips the rows breaking a unique key, like the
+// insert ignore of MySQL. The target is what follows into, like "t (a, b) values (?, ?)"
+func (dialect Dialect) InsertIgnore(target string) string {
+	switch dialect {
+	case PostgreSQL:
+		return "insert into " + target + " on conflict do nothing"
+	case SQLite:
+		return "insert or ignore into " + target
+	}
+
+	return "insert ignore into " + target
+}
+
+// Returning report if inserts must return the id of the new row, the driver of the dialect
+// does not report the last insert id
+func (dialect Dialect) Returning() bool {
+	return dialect.returning
+}
+
+// IsDeadlock report if the database aborted a transaction for conflicting with another one,
+// which then succeeds when run again
+func (dialect Dialect) IsDeadlock(error error) bool {
+	switch dialect {
+	case MySQL:
+		var mysqlError *mysql.MySQLError
+
+		// ER_LOCK_DEADLOCK
+		return errors.As(error, &mysqlError) && mysqlError.Number == 1213
+	case PostgreSQL:
+		var pqError *pq.Error
+
+		// deadlock_detected and serialization_failure
+		return errors.As(error, &pqError) && (pqError.Code == "40P01" || pqError.Code == "40001")
+	case SQLite:
+		var sqliteError sqlite3.Error
+
+		// Transactions take the write lock as they begin, so a busy database is a writer holding
+		// it for the whole busy timeout, maybe a write of the transaction made outside of it, and
+		// running again would wait as long. Only a snapshot made stale by another writer passes
+		return errors.As(error, &sqliteError) && sqliteError.ExtendedCode == sqlite3.ErrBusySnapshot
+	}
+
+	return false
+}
@@ -0,0 +1,32 @@
+package mailer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender writes every message to a file of a directory, useful in development
+type FileSender struct {
+	directory string
+}
+
+// NewFileSender returns a sender writing to the directory
+func NewFileSender(directory string) *FileSender {
+	return &FileSender{directory}
+}
+
+// Send write the message to a new file
+func (sender *FileSender) Send(message Message) error {
+	if error := os.MkdirAll(sender.directory, 0700); error != nil {
+		return error
+	}
+
+	name := fmt.Sprintf("%d.eml", time.Now().UnixNano())
+
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", message.To, message.Subject, message.Body)
+
+	return ioutil.WriteFile(filepath.Join(sender.directory, name), []byte(content), 0600)
+}
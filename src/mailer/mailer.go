@@ -0,0 +1,49 @@
+package mailer
+
+import (
+	"api/src/config"
+	"fmt"
+)
+
+// Message is an email to be delivered
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers messages
+type Sender interface {
+	Send(message Message) error
+}
+
+// Default is the sender used by the controllers
+var Default Sender = NewMemorySender()
+
+// Configure select the sender set in config.MailDriver, deliveries happen in background
+// so the time to answer a request does not depend on an email being sent
+func Configure() error {
+	var sender Sender
+
+	switch config.MailDriver {
+	case "smtp":
+		sender = NewSMTPSender(config.SMTPHost, config.SMTPPort, config.SMTPUsername, config.SMTPPassword, config.MailFrom)
+	case "", "file":
+		sender = NewFileSender(config.MailDirectory)
+	case "memory":
+		sender = NewMemorySender()
+	default:
+		return fmt.Errorf("Unknown mail driver %s", config.MailDriver)
+	}
+
+	Default = NewQueue(sender, 100)
+
+	return nil
+}
+
+// Close stop the background deliveries once the messages waiting in them are sent
+func Close() {
+	if queue, ok := Default.(*Queue); ok {
+		queue.Close()
+	}
+}
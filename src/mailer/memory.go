@@ -0,0 +1,32 @@
+package mailer
+
+import "sync"
+
+// MemorySender keeps the messages in an outbox instead of delivering them, used in tests
+type MemorySender struct {
+	mutex  sync.Mutex
+	outbox []Message
+}
+
+// NewMemorySender returns a sender with an empty outbox
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+// Send append the message to the outbox
+func (sender *MemorySender) Send(message Message) error {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	sender.outbox = append(sender.outbox, message)
+
+	return nil
+}
+
+// Messages returns a copy of the outbox
+func (sender *MemorySender) Messages() []Message {
+	sender.mutex.Lock()
+	defer sender.mutex.Unlock()
+
+	return append([]Message{}, sender.outbox...)
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"errors"
+	"log"
+	"sync"
+)
+
+// Queue is a Sender that delivers the messages in background through another sender
+type Queue struct {
+	sender   Sender
+	messages chan Message
+	mutex    sync.RWMutex
+	closed   bool
+	done     sync.WaitGroup
+}
+
+// NewQueue starts a queue holding up to size messages waiting for delivery
+func NewQueue(sender Sender, size int) *Queue {
+	queue := &Queue{
+		sender:   sender,
+		messages: make(chan Message, size),
+	}
+
+	queue.done.Add(1)
+
+	go queue.work()
+
+	return queue
+}
+
+// Send enqueue the message, failing when the queue is full or closed
+func (queue *Queue) Send(message Message) error {
+	queue.mutex.RLock()
+	defer queue.mutex.RUnlock()
+
+	if queue.closed {
+		return errors.New("Mail queue is closed")
+	}
+
+	select {
+	case queue.messages <- message:
+		return nil
+	default:
+		return errors.New("Mail queue is full")
+	}
+}
+
+// Close stop accepting messages and wait the pending ones to be delivered
+func (queue *Queue) Close() {
+	queue.mutex.Lock()
+
+	if !queue.closed {
+		queue.closed = true
+		close(queue.messages)
+	}
+
+	queue.mutex.Unlock()
+
+	queue.done.Wait()
+}
+
+func (queue *Queue) work() {
+	defer queue.done.Done()
+
+	for message := range queue.messages {
+		if error := queue.sender.Send(message); error != nil {
+			log.Printf("Could not send mail to %s: %v", message.To, error)
+		}
+	}
+}
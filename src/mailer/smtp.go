@@ -0,0 +1,44 @@
+package mailer
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+)
+
+// SMTPSender delivers messages through a SMTP server
+type SMTPSender struct {
+	address string
+	auth    smtp.Auth
+	from    string
+}
+
+// NewSMTPSender returns a sender authenticated with username and password, when informed
+func NewSMTPSender(host string, port int, username, password, from string) *SMTPSender {
+	var auth smtp.Auth
+
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{
+		address: fmt.Sprintf("%s:%d", host, port),
+		auth:    auth,
+		from:    from,
+	}
+}
+
+// Send deliver a plain text message
+func (sender *SMTPSender) Send(message Message) error {
+	content := strings.Join([]string{
+		"From: " + sender.from,
+		"To: " + message.To,
+		"Subject: " + message.Subject,
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"",
+		message.Body,
+	}, "\r\n")
+
+	return smtp.SendMail(sender.address, sender.auth, sender.from, []string{message.To}, []byte(content))
+}
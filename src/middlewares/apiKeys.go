@@ -0,0 +1,74 @@
+package middlewares
+
+import (
+	"api/src/authentication"
+	"api/src/security"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+)
+
+var errInvalidAPIKey = errors.New("Invalid api key")
+
+// authenticateAPIKey returns the claims of the owner of an api key, with the status to answer on failure
+func authenticateAPIKey(ctx context.Context, key string) (authentication.Claims, int, error) {
+	prefix, ok := security.APIKeyPrefix(key)
+
+	if !ok {
+		return authentication.Claims{}, http.StatusUnauthorized, errInvalidAPIKey
+	}
+
+	store := dependencies.Store
+
+	if store == nil {
+		return authentication.Claims{}, http.StatusInternalServerError, errors.New("Database is not configured")
+	}
+
+	repository := store.APIKeys()
+
+	apiKey, error := repository.GetByPrefix(ctx, prefix)
+
+	if error != nil {
+		return authentication.Claims{}, http.StatusInternalServerError, error
+	}
+
+	keyHash := security.HashToken(key)
+
+	if apiKey.ID == 0 || subtle.ConstantTimeCompare([]byte(apiKey.KeyHash), []byte(keyHash)) != 1 {
+		return authentication.Claims{}, http.StatusUnauthorized, errInvalidAPIKey
+	}
+
+	if apiKey.RevokedAt != nil {
+		return authentication.Claims{}, http.StatusUnauthorized, errors.New("Api key has been revoked")
+	}
+
+	user, error := store.Users().GetAccount(ctx, apiKey.UserID)
+
+	if error != nil {
+		return authentication.Claims{}, http.StatusInternalServerError, error
+	}
+
+	if user.ID == 0 || user.SuspendedAt != nil {
+		return authentication.Claims{}, http.StatusUnauthorized, errInvalidAPIKey
+	}
+
+	if error = repository.Touch(ctx, apiKey.ID); error != nil {
+		log.Printf("Could not register use of api key %d: %v", apiKey.ID, error)
+	}
+
+	claims := authentication.Claims{
+		TokenID: fmt.Sprintf("api_key:%d", apiKey.ID),
+		Type:    authentication.TokenTypeAPIKey,
+		UserID:  user.ID,
+		Roles:   user.Roles,
+	}
+
+	if len(apiKey.Scopes) > 0 {
+		claims.Scopes = apiKey.Scopes
+	}
+
+	return claims, 0, nil
+}
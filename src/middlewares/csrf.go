@@ -0,0 +1,28 @@
+package middlewares
+
+import (
+	"api/src/authentication"
+	"api/src/responses"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+)
+
+// CSRF require requests authenticated by cookie to send back the csrf cookie on the csrf
+// header, which other sites cannot read. Requests with a bearer token or api key pass through
+func CSRF(nextFunction http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if authentication.CookieAuthenticated(r) {
+			cookie, error := r.Cookie(authentication.CSRFCookie)
+			header := r.Header.Get(authentication.CSRFHeader)
+
+			if error != nil || cookie.Value == "" ||
+				subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) != 1 {
+				responses.Error(w, http.StatusForbidden, errors.New("Invalid csrf token"))
+				return
+			}
+		}
+
+		nextFunction(w, r)
+	}
+}
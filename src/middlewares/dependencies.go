@@ -0,0 +1,11 @@
+package middlewares
+
+import "api/src/container"
+
+// dependencies shared by the middlewares, set at startup by Inject
+var dependencies = &container.Container{}
+
+// Inject set the dependencies of the middlewares
+func Inject(c *container.Container) {
+	dependencies = c
+}
@@ -0,0 +1,72 @@
+package middlewares
+
+import (
+	"api/src/audit"
+	"api/src/authentication"
+	"api/src/network"
+	"api/src/responses"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+)
+
+// NotImpersonated refuse the request when an admin is acting as the user, for actions
+// only the user can take like changing the password or deleting the account
+func NotImpersonated(nextFunction http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		identity, error := authentication.GetIdentity(r)
+
+		if error != nil {
+			responses.Error(w, http.StatusUnauthorized, error)
+			return
+		}
+
+		if identity.Impersonated() {
+			responses.Error(w, http.StatusForbidden, errors.New("Not allowed while impersonating a user"))
+			return
+		}
+
+		nextFunction(w, r)
+	}
+}
+
+// statusRecorder keeps the status answered to a request
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (recorder *statusRecorder) WriteHeader(status int) {
+	recorder.status = status
+	recorder.ResponseWriter.WriteHeader(status)
+}
+
+// auditImpersonation run the request and write it to the audit log. The entry is given a place
+// in the log before the request runs, requests that could not be audited are refused
+func auditImpersonation(claims authentication.Claims, nextFunction http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	write, error := audit.Reserve(r.Context())
+
+	if error != nil {
+		log.Printf("Could not audit request of user %d acting as %d: %v", claims.ActorID, claims.UserID, error)
+		responses.Error(w, http.StatusServiceUnavailable, errors.New("Requests made as another user cannot be audited, try again later"))
+		return
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+	defer func() {
+		write(audit.Entry{
+			ActorID:   claims.ActorID,
+			UserID:    claims.UserID,
+			TokenID:   claims.TokenID,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Status:    recorder.status,
+			IP:        network.ClientIP(r),
+			CreatedAt: time.Now(),
+		})
+	}()
+
+	nextFunction(recorder, r)
+}
@@ -3,6 +3,8 @@ package middlewares
 import (
 	"api/src/authentication"
 	"api/src/responses"
+	"api/src/revocation"
+	"errors"
 	"log"
 	"net/http"
 )
@@ -17,15 +19,119 @@ func Logger(nextFunction http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
-// Authentication verify user authentication
+// Authentication verify user authentication, through a bearer token or a personal api key
+// on the X-API-Key header, and make the claims of the credential available to the next function.
+// Requests of admins impersonating a user are written to the audit log
 func Authentication(nextFunction http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		var (
+			claims authentication.Claims
+			status int
+			error  error
+		)
 
-		if error := authentication.ValidateToken(r); error != nil {
+		if apiKey := r.Header.Get("X-API-Key"); apiKey != "" {
+			claims, status, error = authenticateAPIKey(r.Context(), apiKey)
+		} else {
+			claims, status, error = authenticateToken(r)
+		}
+
+		if error != nil {
+			responses.Error(w, status, error)
+			return
+		}
+
+		if claims.SessionID != 0 {
+			recordActivity(r.Context(), claims.SessionID)
+		}
+
+		if claims.ActorID != 0 {
+			auditImpersonation(claims, nextFunction, w, authentication.WithClaims(r, claims))
+			return
+		}
+
+		nextFunction(w, authentication.WithClaims(r, claims))
+	}
+}
+
+// authenticateToken returns the claims of a valid and not revoked access token, with the status to answer on failure
+func authenticateToken(r *http.Request) (authentication.Claims, int, error) {
+	claims, error := authentication.GetClaims(r)
+
+	if error != nil {
+		return authentication.Claims{}, http.StatusUnauthorized, error
+	}
+
+	revoked, error := revocation.IsAccessRevoked(claims)
+
+	if error != nil {
+		return authentication.Claims{}, http.StatusInternalServerError, error
+	}
+
+	if revoked {
+		return authentication.Claims{}, http.StatusUnauthorized, errors.New("Token has been revoked")
+	}
+
+	return claims, 0, nil
+}
+
+// Scopes verify a restricted credential has every scope of the route, routes without
+// scopes can only be reached by unrestricted credentials
+func Scopes(scopes []string, nextFunction http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, error := authentication.GetClaims(r)
+
+		if error != nil {
 			responses.Error(w, http.StatusUnauthorized, error)
 			return
 		}
 
+		allowed := !claims.Restricted() || len(scopes) > 0
+
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				allowed = false
+			}
+		}
+
+		if !allowed {
+			responses.Error(w, http.StatusForbidden, errors.New("Credential scopes do not allow this resource"))
+			return
+		}
+
+		nextFunction(w, r)
+	}
+}
+
+// Authorization verify the user has any of the roles and all of the permissions of the route
+func Authorization(roles, permissions []string, nextFunction http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, error := authentication.GetClaims(r)
+
+		if error != nil {
+			responses.Error(w, http.StatusUnauthorized, error)
+			return
+		}
+
+		allowed := len(roles) == 0
+
+		for _, role := range roles {
+			if claims.HasRole(role) {
+				allowed = true
+			}
+		}
+
+		for _, permission := range permissions {
+			if !claims.HasPermission(permission) {
+				allowed = false
+			}
+		}
+
+		if !allowed {
+			responses.Error(w, http.StatusForbidden, errors.New("Not allowed to access this resource"))
+			return
+		}
+
 		nextFunction(w, r)
 	}
 }
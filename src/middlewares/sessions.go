@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"api/src/config"
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+// maxRecentSessions bounds the sessions remembered before the stale ones are forgotten
+const maxRecentSessions = 10000
+
+// activity remembers when this instance last wrote the activity of each session
+var activity = sessionActivity{written: map[uint64]time.Time{}}
+
+type sessionActivity struct {
+	mutex   sync.Mutex
+	written map[uint64]time.Time
+}
+
+// due report if the activity of the session was not written in the last interval, marking it
+// as written
+func (activity *sessionActivity) due(sessionID uint64, now time.Time) bool {
+	activity.mutex.Lock()
+	defer activity.mutex.Unlock()
+
+	if written, found := activity.written[sessionID]; found && now.Sub(written) < config.SessionActivityInterval {
+		return false
+	}
+
+	if len(activity.written) >= maxRecentSessions {
+		for ID, written := range activity.written {
+			if now.Sub(written) >= config.SessionActivityInterval {
+				delete(activity.written, ID)
+			}
+		}
+	}
+
+	activity.written[sessionID] = now
+
+	return true
+}
+
+// recordActivity update the last activity of a session about once per
+// config.SessionActivityInterval, the request goes on when it cannot be written
+func recordActivity(ctx context.Context, sessionID uint64) {
+	store := dependencies.Store
+	now := time.Now()
+
+	if store == nil || !activity.due(sessionID, now) {
+		return
+	}
+
+	// Other instances may have written it already, then the session is left untouched
+	if error := store.Sessions().Active(ctx, sessionID, now.Add(-config.SessionActivityInterval)); error != nil {
+		log.Printf("Could not record the activity of session %d: %v", sessionID, error)
+	}
+}
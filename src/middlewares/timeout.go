@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"api/src/config"
+	"context"
+	"net/http"
+)
+
+// Timeout give the request a deadline of config.RequestTimeout, the queries still running
+// for it when the deadline passes are cancelled
+func Timeout(nextFunction http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if config.RequestTimeout <= 0 {
+			nextFunction(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), config.RequestTimeout)
+		defer cancel()
+
+		nextFunction(w, r.WithContext(ctx))
+	}
+}
@@ -0,0 +1,175 @@
+package migrations
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Usage of the migrate command
+const Usage = `usage: migrate <command>
+
+  up            apply every pending migration
+  down [steps]  revert the last applied migrations, one by default
+  status        list the migrations and when they were applied
+  new <name>    create the files of a new migration for every database dialect`
+
+// invalidName matches what cannot be part of a migration name
+var invalidName = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// Command runs the migrate subcommand of the api with its arguments, writing what was done to output
+func Command(arguments []string, output io.Writer) error {
+	if len(arguments) == 0 {
+		return errors.New(Usage)
+	}
+
+	if arguments[0] == "new" {
+		if len(arguments) != 2 {
+			return errors.New(Usage)
+		}
+
+		files, error := Create(config.MigrationsDirectory, arguments[1])
+
+		if error != nil {
+			return error
+		}
+
+		for _, file := range files {
+			fmt.Fprintf(output, "Created %s\n", file)
+		}
+
+		return nil
+	}
+
+	steps := 1
+
+	switch {
+	case arguments[0] == "down" && len(arguments) == 2:
+		var error error
+
+		if steps, error = strconv.Atoi(arguments[1]); error != nil || steps < 1 {
+			return fmt.Errorf("Invalid number of steps %s", arguments[1])
+		}
+	case len(arguments) != 1:
+		return errors.New(Usage)
+	}
+
+	db, dialect, error := database.ConnectMigrations()
+
+	if error != nil {
+		return error
+	}
+
+	defer db.Close()
+
+	migrations, error := Load(dialect)
+
+	if error != nil {
+		return error
+	}
+
+	migrator := NewMigrator(db, dialect, migrations)
+	ctx := context.Background()
+
+	switch arguments[0] {
+	case "up":
+		applied, error := migrator.Up(ctx)
+
+		for _, migration := range applied {
+			fmt.Fprintf(output, "Applied %d_%s\n", migration.Version, migration.Name)
+		}
+
+		if error == nil && len(applied) == 0 {
+			fmt.Fprintln(output, "No pending migrations")
+		}
+
+		return error
+	case "down":
+		reverted, error := migrator.Down(ctx, steps)
+
+		for _, migration := range reverted {
+			fmt.Fprintf(output, "Reverted %d_%s\n", migration.Version, migration.Name)
+		}
+
+		if error == nil && len(reverted) == 0 {
+			fmt.Fprintln(output, "No applied migrations")
+		}
+
+		return error
+	case "status":
+		statuses, error := migrator.Status(ctx)
+
+		for _, status := range statuses {
+			state := "pending"
+
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Format(time.RFC3339)
+			}
+
+			if status.Missing {
+				state += ", missing from the binary"
+			}
+
+			fmt.Fprintf(output, "%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+
+		return error
+	}
+
+	return errors.New(Usage)
+}
+
+// Create write the empty up and down files of a new migration in the folder of every dialect in
+// the directory, numbered after the newest migration there, and returns their paths. The binary
+// must be built again to embed them
+func Create(directory, name string) ([]string, error) {
+	name = strings.Trim(invalidName.ReplaceAllString(strings.ToLower(name), "_"), "_")
+
+	if name == "" {
+		return nil, errors.New("Invalid migration name")
+	}
+
+	var version uint64 = 1
+
+	for _, dialect := range database.Dialects {
+		if error := os.MkdirAll(filepath.Join(directory, dialect.Name), 0755); error != nil {
+			return nil, error
+		}
+
+		existing, error := parse(os.DirFS(directory), dialect.Name)
+
+		if error != nil {
+			return nil, error
+		}
+
+		if len(existing) > 0 && existing[len(existing)-1].Version >= version {
+			version = existing[len(existing)-1].Version + 1
+		}
+	}
+
+	var files []string
+
+	for _, dialect := range database.Dialects {
+		for _, direction := range []string{"up", "down"} {
+			file := filepath.Join(directory, dialect.Name, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+			content := fmt.Sprintf("-- %s %s\n", strings.ReplaceAll(name, "_", " "), direction)
+
+			if error := os.WriteFile(file, []byte(content), 0644); error != nil {
+				return nil, error
+			}
+
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
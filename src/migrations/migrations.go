@@ -0,0 +1,99 @@
+package migrations
+
+import (
+	"api/src/database"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Migration is a versioned change of the schema, with the sql applying and reverting it
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+//go:embed sql
+var files embed.FS
+
+// fileName of a migration, like 0002_add_user_bio.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load returns the migrations of the dialect embedded in the binary, ordered by version
+func Load(dialect database.Dialect) ([]Migration, error) {
+	return parse(files, path.Join("sql", dialect.Name))
+}
+
+// parse read the migrations of a directory, every version needs an up file
+func parse(fileSystem fs.FS, directory string) ([]Migration, error) {
+	entries, error := fs.ReadDir(fileSystem, directory)
+
+	if error != nil {
+		return nil, error
+	}
+
+	byVersion := map[uint64]*Migration{}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		parts := fileName.FindStringSubmatch(entry.Name())
+
+		if parts == nil {
+			return nil, fmt.Errorf("Invalid migration file name %s", entry.Name())
+		}
+
+		version, error := strconv.ParseUint(parts[1], 10, 64)
+
+		if error != nil {
+			return nil, fmt.Errorf("Invalid migration version %s", parts[1])
+		}
+
+		content, error := fs.ReadFile(fileSystem, path.Join(directory, entry.Name()))
+
+		if error != nil {
+			return nil, error
+		}
+
+		migration, exists := byVersion[version]
+
+		if !exists {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		}
+
+		if migration.Name != parts[2] {
+			return nil, fmt.Errorf("Migrations %s and %s share the version %d", migration.Name, parts[2], version)
+		}
+
+		if parts[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+
+	for _, migration := range byVersion {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("Migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
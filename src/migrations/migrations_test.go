@@ -0,0 +1,184 @@
+package migrations_test
+
+import (
+	"api/src/database"
+	"api/src/migrations"
+	"api/src/repositories"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// baseline is the schema sql/sql.sql created before the migrations, in SQLite
+const baseline = `
+CREATE TABLE users(
+    id integer primary key autoincrement,
+    name varchar(50) not null,
+    nick varchar(50) not null,
+    email varchar(50) not null unique,
+    password varchar(100) not null,
+    createdAt datetime default current_timestamp
+);
+
+CREATE TABLE followers(
+    user_id int not null references users(id) on delete cascade,
+    follower_id int not null references users(id) on delete cascade,
+
+    primary key(user_id, follower_id)
+);
+
+CREATE TABLE publications(
+    id integer primary key autoincrement,
+    title varchar(50) not null,
+    content varchar(300) not null,
+    author_id int not null references users(id) on delete cascade,
+    likes int default 0,
+    createdAt datetime default current_timestamp
+);
+
+INSERT INTO users (name, nick, email, password) VALUES ('Maria', 'maria', 'maria@devbook.test', 'hash');
+`
+
+// openSQLite returns an empty database in a temporary file
+func openSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, error := database.Open(database.SQLite, "file:"+filepath.Join(t.TempDir(), "devbook.db"))
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+func TestDialectsShareTheVersions(t *testing.T) {
+	expected, error := migrations.Load(database.MySQL)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	for _, dialect := range database.Dialects {
+		loaded, error := migrations.Load(dialect)
+
+		if error != nil {
+			t.Fatalf("%s: %v", dialect.Name, error)
+		}
+
+		if len(loaded) != len(expected) {
+			t.Fatalf("%s has %d migrations, mysql has %d", dialect.Name, len(loaded), len(expected))
+		}
+
+		for index, migration := range loaded {
+			if migration.Version != expected[index].Version || migration.Name != expected[index].Name {
+				t.Errorf("%s has %d_%s where mysql has %d_%s", dialect.Name,
+					migration.Version, migration.Name, expected[index].Version, expected[index].Name)
+			}
+
+			if migration.Down == "" {
+				t.Errorf("%s migration %d_%s has no down file", dialect.Name, migration.Version, migration.Name)
+			}
+		}
+	}
+}
+
+func TestMigrateSQLiteUpAndDown(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSQLite(t)
+
+	loaded, error := migrations.Load(database.SQLite)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	migrator := migrations.NewMigrator(db, database.SQLite, loaded)
+
+	applied, error := migrator.Up(ctx)
+
+	if error != nil || len(applied) != len(loaded) {
+		t.Fatalf("Applied %d of %d migrations: %v", len(applied), len(loaded), error)
+	}
+
+	if applied, error = migrator.Up(ctx); error != nil || len(applied) != 0 {
+		t.Fatalf("Applied %d migrations twice: %v", len(applied), error)
+	}
+
+	statuses, error := migrator.Status(ctx)
+
+	if error != nil || len(statuses) != len(loaded) || statuses[0].AppliedAt == nil {
+		t.Fatalf("Unexpected statuses %+v: %v", statuses, error)
+	}
+
+	reverted, error := migrator.Down(ctx, len(loaded))
+
+	if error != nil || len(reverted) != len(loaded) {
+		t.Fatalf("Reverted %d of %d migrations: %v", len(reverted), len(loaded), error)
+	}
+
+	var tables int
+
+	if error = db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&tables); error != nil || tables != 0 {
+		t.Fatalf("The users table remains after reverting: %v", error)
+	}
+}
+
+func TestMigrateBaselineDatabase(t *testing.T) {
+	ctx := context.Background()
+
+	db := openSQLite(t)
+
+	if _, error := db.Exec(baseline); error != nil {
+		t.Fatal(error)
+	}
+
+	loaded, error := migrations.Load(database.SQLite)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if applied, error := migrations.NewMigrator(db, database.SQLite, loaded).Up(ctx); error != nil || len(applied) != len(loaded) {
+		t.Fatalf("Applied %d of %d migrations: %v", len(applied), len(loaded), error)
+	}
+
+	// The columns added since the baseline are read along with the existing rows
+	account, error := repositories.NewSQLStore(repositories.NewDB(db, database.SQLite)).Users().SearchByEmail(ctx, "maria@devbook.test")
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if account.ID == 0 || account.Password != "hash" || len(account.Roles) != 1 || account.Roles[0] != "user" || account.VerifiedAt != nil {
+		t.Fatalf("Unexpected account %+v", account)
+	}
+}
+
+func TestFailedMigrationLeavesNothing(t *testing.T) {
+	db := openSQLite(t)
+
+	migrator := migrations.NewMigrator(db, database.SQLite, []migrations.Migration{
+		{Version: 1, Name: "broken", Up: "CREATE TABLE halfway(id int); CREATE TABLE halfway(id int);"},
+	})
+
+	if _, error := migrator.Up(context.Background()); error == nil {
+		t.Fatal("Broken migration was applied")
+	}
+
+	var tables int
+
+	if error := db.QueryRow("SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = 'halfway'").Scan(&tables); error != nil || tables != 0 {
+		t.Fatalf("The first statement of the failed migration was kept: %v", error)
+	}
+
+	statuses, error := migrator.Status(context.Background())
+
+	if error != nil || len(statuses) != 1 || statuses[0].AppliedAt != nil {
+		t.Fatalf("Failed migration recorded %+v: %v", statuses, error)
+	}
+}
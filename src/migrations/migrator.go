@@ -0,0 +1,329 @@
+package migrations
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+)
+
+// lockName of the advisory lock held while migrating, so instances started together
+// do not apply the same migrations twice
+const lockName = "schema_migrations"
+
+// Status tells if a migration is applied, migrations applied but missing from the binary
+// are listed too
+type Status struct {
+	Version   uint64
+	Name      string
+	AppliedAt *time.Time
+	Missing   bool
+}
+
+// applied is a row of the schema_migrations table
+type applied struct {
+	name      string
+	appliedAt time.Time
+}
+
+// Migrator applies and reverts migrations, tracking them in the schema_migrations table
+type Migrator struct {
+	db         *sql.DB
+	dialect    database.Dialect
+	migrations []Migration
+}
+
+// NewMigrator returns a migrator of the migrations of the dialect, which must be ordered by version
+func NewMigrator(db *sql.DB, dialect database.Dialect, migrations []Migration) *Migrator {
+	return &Migrator{db, dialect, migrations}
+}
+
+// Up apply every pending migration in order, returning the ones applied. It stops on the
+// first failure, the migrations applied before it stay applied
+func (migrator Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+
+	error := migrator.locked(ctx, func(conn *sql.Conn, versions map[uint64]applied) error {
+		for _, migration := range migrator.migrations {
+			if _, isApplied := versions[migration.Version]; isApplied {
+				continue
+			}
+
+			if error := migrator.run(ctx, conn, migration.Up,
+				"insert into schema_migrations (version, name, applied_at) values (?, ?, ?)",
+				migration.Version, migration.Name, time.Now(),
+			); error != nil {
+				return fmt.Errorf("Migration %d_%s failed: %v", migration.Version, migration.Name, error)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, error
+}
+
+// Down revert the last steps applied migrations, newest first, returning the ones reverted
+func (migrator Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var done []Migration
+
+	error := migrator.locked(ctx, func(conn *sql.Conn, versions map[uint64]applied) error {
+		appliedVersions := make([]uint64, 0, len(versions))
+
+		for version := range versions {
+			appliedVersions = append(appliedVersions, version)
+		}
+
+		sort.Slice(appliedVersions, func(i, j int) bool {
+			return appliedVersions[i] > appliedVersions[j]
+		})
+
+		if steps < len(appliedVersions) {
+			appliedVersions = appliedVersions[:steps]
+		}
+
+		for _, version := range appliedVersions {
+			migration, found := migrator.find(version)
+
+			if !found {
+				return fmt.Errorf("Migration %d_%s is applied but missing from the binary", version, versions[version].name)
+			}
+
+			if migration.Down == "" {
+				return fmt.Errorf("Migration %d_%s has no down file", migration.Version, migration.Name)
+			}
+
+			if error := migrator.run(ctx, conn, migration.Down, "DELETE FROM schema_migrations WHERE version = ?", version); error != nil {
+				return fmt.Errorf("Reverting migration %d_%s failed: %v", migration.Version, migration.Name, error)
+			}
+
+			done = append(done, migration)
+		}
+
+		return nil
+	})
+
+	return done, error
+}
+
+// Status list every migration by version, telling when each was applied
+func (migrator Migrator) Status(ctx context.Context) ([]Status, error) {
+	var statuses []Status
+
+	error := migrator.locked(ctx, func(conn *sql.Conn, versions map[uint64]applied) error {
+		for _, migration := range migrator.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+
+			if row, isApplied := versions[migration.Version]; isApplied {
+				appliedAt := row.appliedAt
+				status.AppliedAt = &appliedAt
+			}
+
+			statuses = append(statuses, status)
+		}
+
+		for version, row := range versions {
+			if _, found := migrator.find(version); !found {
+				appliedAt := row.appliedAt
+				statuses = append(statuses, Status{Version: version, Name: row.name, AppliedAt: &appliedAt, Missing: true})
+			}
+		}
+
+		return nil
+	})
+
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, error
+}
+
+// run the statements of a migration along with the change of the schema_migrations table
+// tracking it. Where schema changes are transactional they are committed together, so a
+// migration failing halfway leaves nothing behind. MySQL commits each schema change on its own
+func (migrator Migrator) run(ctx context.Context, conn *sql.Conn, statements, tracking string, args ...interface{}) error {
+	tracking = migrator.dialect.Rebind(tracking)
+
+	if migrator.dialect == database.MySQL {
+		if _, error := conn.ExecContext(ctx, statements); error != nil {
+			return error
+		}
+
+		_, error := conn.ExecContext(ctx, tracking, args...)
+
+		return error
+	}
+
+	tx, error := conn.BeginTx(ctx, nil)
+
+	if error != nil {
+		return error
+	}
+
+	if _, error = tx.ExecContext(ctx, statements); error != nil {
+		tx.Rollback()
+		return error
+	}
+
+	if _, error = tx.ExecContext(ctx, tracking, args...); error != nil {
+		tx.Rollback()
+		return error
+	}
+
+	return tx.Commit()
+}
+
+// find the migration of a version
+func (migrator Migrator) find(version uint64) (Migration, bool) {
+	for _, migration := range migrator.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+
+	return Migration{}, false
+}
+
+// locked run the function holding the advisory lock, on the connection that holds it,
+// with the tracking table created and its applied versions
+func (migrator Migrator) locked(ctx context.Context, function func(*sql.Conn, map[uint64]applied) error) error {
+	conn, error := migrator.db.Conn(ctx)
+
+	if error != nil {
+		return error
+	}
+
+	defer conn.Close()
+
+	release, error := migrator.lock(ctx, conn)
+
+	if error != nil {
+		return error
+	}
+
+	defer release()
+
+	if _, error = conn.ExecContext(ctx, migrator.trackingTable()); error != nil {
+		return error
+	}
+
+	versions, error := appliedVersions(ctx, conn)
+
+	if error != nil {
+		return error
+	}
+
+	return function(conn, versions)
+}
+
+// lock acquire the advisory lock of the dialect on the connection, returning its release
+func (migrator Migrator) lock(ctx context.Context, conn *sql.Conn) (func(), error) {
+	var release string
+
+	switch migrator.dialect {
+	case database.MySQL:
+		var acquired sql.NullInt64
+
+		if error := conn.QueryRowContext(ctx,
+			"SELECT GET_LOCK(?, ?)", lockName, int(config.MigrationLockTimeout.Seconds()),
+		).Scan(&acquired); error != nil {
+			return nil, error
+		}
+
+		if acquired.Int64 != 1 {
+			return nil, errors.New("Another instance is migrating the database")
+		}
+
+		release = "DO RELEASE_LOCK(?)"
+	case database.PostgreSQL:
+		if _, error := conn.ExecContext(ctx,
+			"SELECT set_config('lock_timeout', $1, false)", fmt.Sprintf("%dms", config.MigrationLockTimeout.Milliseconds()),
+		); error != nil {
+			return nil, error
+		}
+
+		if _, error := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", lockName); error != nil {
+			return nil, fmt.Errorf("Another instance is migrating the database: %v", error)
+		}
+
+		// The timeout is only for the wait of the lock, not for the locks taken by the migrations
+		if _, error := conn.ExecContext(ctx, "SELECT set_config('lock_timeout', '0', false)"); error != nil {
+			return nil, error
+		}
+
+		release = "SELECT pg_advisory_unlock(hashtext($1))"
+	default:
+		// SQLite databases are files of a single instance, there is no one to wait for
+		return func() {}, nil
+	}
+
+	// The lock is also released when the connection closes, a failure here is only logged
+	return func() {
+		if _, error := conn.ExecContext(context.Background(), release, lockName); error != nil {
+			log.Printf("Could not release the migration lock: %v", error)
+		}
+	}, nil
+}
+
+// trackingTable returns the statement creating the schema_migrations table in the dialect
+func (migrator Migrator) trackingTable() string {
+	switch migrator.dialect {
+	case database.MySQL:
+		return `
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		version bigint primary key,
+		name varchar(255) not null,
+		applied_at datetime not null
+	) ENGINE=INNODB`
+	case database.PostgreSQL:
+		return `
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		version bigint primary key,
+		name varchar(255) not null,
+		applied_at timestamptz not null
+	)`
+	}
+
+	return `
+	CREATE TABLE IF NOT EXISTS schema_migrations(
+		version bigint primary key,
+		name varchar(255) not null,
+		applied_at datetime not null
+	)`
+}
+
+// appliedVersions read the schema_migrations table
+func appliedVersions(ctx context.Context, conn *sql.Conn) (map[uint64]applied, error) {
+	lines, error := conn.QueryContext(ctx, "SELECT version, name, applied_at FROM schema_migrations")
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer lines.Close()
+
+	versions := map[uint64]applied{}
+
+	for lines.Next() {
+		var (
+			version uint64
+			row     applied
+		)
+
+		if error = lines.Scan(&version, &row.name, &row.appliedAt); error != nil {
+			return nil, error
+		}
+
+		versions[version] = row
+	}
+
+	return versions, lines.Err()
+}
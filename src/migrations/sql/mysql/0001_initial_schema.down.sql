@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS publications;
+DROP TABLE IF EXISTS followers;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,40 @@
+-- Schema of the api before versioned migrations, the one sql/sql.sql created. Tables are
+-- only created when missing so databases created by it adopt the migrations.
+
+CREATE TABLE IF NOT EXISTS users(
+    id int auto_increment primary key,
+    name varchar(50) not null,
+    nick varchar(50) not null,
+    email varchar(50) not null unique,
+    password varchar(100) not null,
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
+
+CREATE TABLE IF NOT EXISTS followers(
+    user_id  int not null,
+    follower_id int not null,
+    
+    FOREIGN KEY (user_id) 
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    FOREIGN KEY (follower_id) 
+    REFERENCES users(id) 
+    ON DELETE CASCADE,
+
+    primary key(user_id, follower_id)
+) ENGINE=INNODB;
+
+CREATE TABLE IF NOT EXISTS publications (
+    id int auto_increment primary key,
+    title varchar(50) not null,
+    content varchar(300) not null,
+    
+    author_id int not null,
+    FOREIGN KEY (author_id) 
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    likes int default 0,
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
@@ -0,0 +1,23 @@
+-- Refresh tokens, rotated on every use and grouped in a family per login.
+
+CREATE TABLE refresh_tokens(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    family_id varchar(32) not null,
+    client_id varchar(32) null,
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    absolute_expires_at datetime not null,
+    scope varchar(255) null,
+    revoked_at datetime null,
+    replaced_by int null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (family_id),
+    INDEX (client_id)
+) ENGINE=INNODB;
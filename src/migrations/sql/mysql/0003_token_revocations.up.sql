@@ -0,0 +1,13 @@
+-- Access tokens revoked before their expiration, one by one or every token of a user.
+
+CREATE TABLE revoked_tokens(
+    token_id varchar(32) primary key,
+    expires_at datetime not null,
+
+    INDEX (expires_at)
+) ENGINE=INNODB;
+
+CREATE TABLE user_revocations(
+    user_id int primary key,
+    issued_until datetime not null
+) ENGINE=INNODB;
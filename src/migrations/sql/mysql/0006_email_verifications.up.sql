@@ -0,0 +1,21 @@
+-- Verification of the email of the users.
+
+ALTER TABLE users
+    ADD COLUMN verified_at datetime null;
+
+CREATE TABLE email_verifications(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    email varchar(50) not null,
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (user_id, createdAt)
+) ENGINE=INNODB;
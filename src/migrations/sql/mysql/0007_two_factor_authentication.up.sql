@@ -0,0 +1,20 @@
+-- Time based one time passwords of the users and their recovery codes.
+
+ALTER TABLE users
+    ADD COLUMN totp_secret varchar(64) null,
+    ADD COLUMN totp_enabled_at datetime null,
+    ADD COLUMN totp_last_step bigint not null default 0;
+
+CREATE TABLE recovery_codes(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    code_hash char(64) not null,
+    used_at datetime null,
+
+    INDEX (user_id, code_hash)
+) ENGINE=INNODB;
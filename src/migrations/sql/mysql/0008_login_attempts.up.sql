@@ -0,0 +1,14 @@
+-- Failed logins, counted to throttle guessing and kept for auditing.
+
+CREATE TABLE login_attempts(
+    id int auto_increment primary key,
+    email varchar(50) not null,
+    user_id int null,
+    ip varchar(45) not null,
+    user_agent varchar(255) not null,
+    reason varchar(30) not null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (email),
+    INDEX (ip)
+) ENGINE=INNODB;
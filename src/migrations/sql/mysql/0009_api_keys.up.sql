@@ -0,0 +1,18 @@
+-- Personal api keys of the users.
+
+CREATE TABLE api_keys(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    name varchar(50) not null,
+    prefix char(8) not null unique,
+    key_hash char(64) not null,
+    scopes varchar(255) not null default '',
+    last_used_at datetime null,
+    revoked_at datetime null,
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
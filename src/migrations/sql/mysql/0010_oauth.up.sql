@@ -0,0 +1,41 @@
+-- Clients of the oauth authorization server and their authorization codes.
+
+CREATE TABLE oauth_clients(
+    id int auto_increment primary key,
+    client_id varchar(32) not null unique,
+    secret_hash char(64) null,
+    name varchar(50) not null,
+    redirect_uris varchar(1000) not null,
+    scopes varchar(255) not null,
+
+    owner_id int not null,
+    FOREIGN KEY (owner_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
+
+CREATE TABLE oauth_codes(
+    id int auto_increment primary key,
+    code_hash char(64) not null unique,
+
+    client_id varchar(32) not null,
+    FOREIGN KEY (client_id)
+    REFERENCES oauth_clients(client_id)
+    ON DELETE CASCADE,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    redirect_uri varchar(255) not null,
+    scope varchar(255) not null,
+    code_challenge varchar(128) not null,
+    code_challenge_method varchar(10) not null,
+    expires_at datetime not null,
+    used_at datetime null,
+    family_id varchar(32) null,
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
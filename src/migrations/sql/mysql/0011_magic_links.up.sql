@@ -0,0 +1,14 @@
+-- Links of the passwordless logins sent by email.
+
+CREATE TABLE magic_links(
+    token_id varchar(32) primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    expires_at datetime not null,
+    used_at datetime null,
+    createdAt timestamp default current_timestamp()
+) ENGINE=INNODB;
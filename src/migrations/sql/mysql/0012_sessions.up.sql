@@ -0,0 +1,22 @@
+-- Login sessions the users can list and revoke.
+
+CREATE TABLE sessions(
+    id int auto_increment primary key,
+
+    user_id int not null,
+    FOREIGN KEY (user_id)
+    REFERENCES users(id)
+    ON DELETE CASCADE,
+
+    family_id varchar(32) not null unique,
+    client_id varchar(32) null,
+    token_id varchar(32) null,
+    user_agent varchar(255) not null,
+    ip varchar(45) not null,
+    expires_at datetime not null,
+    last_seen_at datetime not null,
+    revoked_at datetime null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (user_id)
+) ENGINE=INNODB;
@@ -0,0 +1,16 @@
+-- Requests made by admins impersonating users.
+
+CREATE TABLE impersonation_audit(
+    id int auto_increment primary key,
+    actor_id int not null,
+    user_id int not null,
+    token_id varchar(32) not null,
+    method varchar(10) not null,
+    path varchar(255) not null,
+    status int not null,
+    ip varchar(45) not null,
+    createdAt timestamp default current_timestamp(),
+
+    INDEX (actor_id, createdAt),
+    INDEX (user_id, createdAt)
+) ENGINE=INNODB;
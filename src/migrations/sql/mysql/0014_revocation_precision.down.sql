@@ -0,0 +1 @@
+ALTER TABLE user_revocations MODIFY issued_until datetime not null;
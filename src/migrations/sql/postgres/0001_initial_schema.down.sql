@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS publications;
+DROP TABLE IF EXISTS followers;
+DROP TABLE IF EXISTS users;
+DROP COLLATION IF EXISTS case_insensitive;
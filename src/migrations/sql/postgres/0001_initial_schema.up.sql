@@ -0,0 +1,30 @@
+-- Schema of the api on PostgreSQL. Emails compare ignoring case, like in the default
+-- collation of MySQL. Tables are only created when missing, as on MySQL where databases
+-- created by sql/sql.sql adopt the migrations.
+
+CREATE COLLATION IF NOT EXISTS case_insensitive (provider = icu, locale = 'und-u-ks-level2', deterministic = false);
+
+CREATE TABLE IF NOT EXISTS users(
+    id serial primary key,
+    name varchar(50) not null,
+    nick varchar(50) not null,
+    email varchar(50) collate case_insensitive not null unique,
+    password varchar(100) not null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE TABLE IF NOT EXISTS followers(
+    user_id int not null references users(id) on delete cascade,
+    follower_id int not null references users(id) on delete cascade,
+
+    primary key(user_id, follower_id)
+);
+
+CREATE TABLE IF NOT EXISTS publications(
+    id serial primary key,
+    title varchar(50) not null,
+    content varchar(300) not null,
+    author_id int not null references users(id) on delete cascade,
+    likes int default 0,
+    createdAt timestamptz default current_timestamp
+);
@@ -0,0 +1,19 @@
+-- Refresh tokens, rotated on every use and grouped in a family per login.
+
+CREATE TABLE refresh_tokens(
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    family_id varchar(32) not null,
+    client_id varchar(32) null,
+    token_hash char(64) not null unique,
+    expires_at timestamptz not null,
+    absolute_expires_at timestamptz not null,
+    scope varchar(255) null,
+    revoked_at timestamptz null,
+    replaced_by int null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE INDEX ON refresh_tokens (family_id);
+
+CREATE INDEX ON refresh_tokens (client_id);
@@ -0,0 +1,13 @@
+-- Access tokens revoked before their expiration, one by one or every token of a user.
+
+CREATE TABLE revoked_tokens(
+    token_id varchar(32) primary key,
+    expires_at timestamptz not null
+);
+
+CREATE INDEX ON revoked_tokens (expires_at);
+
+CREATE TABLE user_revocations(
+    user_id int primary key,
+    issued_until timestamptz not null
+);
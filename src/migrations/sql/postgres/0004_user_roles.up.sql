@@ -0,0 +1,5 @@
+-- Roles of the users and the suspension of their accounts.
+
+ALTER TABLE users
+    ADD COLUMN roles varchar(100) not null default 'user',
+    ADD COLUMN suspended_at timestamptz null;
@@ -0,0 +1,10 @@
+-- Tokens of the password resets sent by email.
+
+CREATE TABLE password_resets(
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    token_hash char(64) not null unique,
+    expires_at timestamptz not null,
+    used_at timestamptz null,
+    createdAt timestamptz default current_timestamp
+);
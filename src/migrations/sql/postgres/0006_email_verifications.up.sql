@@ -0,0 +1,16 @@
+-- Verification of the email of the users.
+
+ALTER TABLE users
+    ADD COLUMN verified_at timestamptz null;
+
+CREATE TABLE email_verifications(
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    email varchar(50) collate case_insensitive not null,
+    token_hash char(64) not null unique,
+    expires_at timestamptz not null,
+    used_at timestamptz null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE INDEX ON email_verifications (user_id, createdAt);
@@ -0,0 +1,15 @@
+-- Time based one time passwords of the users and their recovery codes.
+
+ALTER TABLE users
+    ADD COLUMN totp_secret varchar(64) null,
+    ADD COLUMN totp_enabled_at timestamptz null,
+    ADD COLUMN totp_last_step bigint not null default 0;
+
+CREATE TABLE recovery_codes(
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    code_hash char(64) not null,
+    used_at timestamptz null
+);
+
+CREATE INDEX ON recovery_codes (user_id, code_hash);
@@ -0,0 +1,15 @@
+-- Failed logins, counted to throttle guessing and kept for auditing.
+
+CREATE TABLE login_attempts(
+    id serial primary key,
+    email varchar(50) collate case_insensitive not null,
+    user_id int null,
+    ip varchar(45) not null,
+    user_agent varchar(255) not null,
+    reason varchar(30) not null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE INDEX ON login_attempts (email);
+
+CREATE INDEX ON login_attempts (ip);
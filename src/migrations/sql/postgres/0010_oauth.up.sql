@@ -0,0 +1,27 @@
+-- Clients of the oauth authorization server and their authorization codes.
+
+CREATE TABLE oauth_clients(
+    id serial primary key,
+    client_id varchar(32) not null unique,
+    secret_hash char(64) null,
+    name varchar(50) not null,
+    redirect_uris varchar(1000) not null,
+    scopes varchar(255) not null,
+    owner_id int not null references users(id) on delete cascade,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE TABLE oauth_codes(
+    id serial primary key,
+    code_hash char(64) not null unique,
+    client_id varchar(32) not null references oauth_clients(client_id) on delete cascade,
+    user_id int not null references users(id) on delete cascade,
+    redirect_uri varchar(255) not null,
+    scope varchar(255) not null,
+    code_challenge varchar(128) not null,
+    code_challenge_method varchar(10) not null,
+    expires_at timestamptz not null,
+    used_at timestamptz null,
+    family_id varchar(32) null,
+    createdAt timestamptz default current_timestamp
+);
@@ -0,0 +1,9 @@
+-- Links of the passwordless logins sent by email.
+
+CREATE TABLE magic_links(
+    token_id varchar(32) primary key,
+    user_id int not null references users(id) on delete cascade,
+    expires_at timestamptz not null,
+    used_at timestamptz null,
+    createdAt timestamptz default current_timestamp
+);
@@ -0,0 +1,17 @@
+-- Login sessions the users can list and revoke.
+
+CREATE TABLE sessions(
+    id serial primary key,
+    user_id int not null references users(id) on delete cascade,
+    family_id varchar(32) not null unique,
+    client_id varchar(32) null,
+    token_id varchar(32) null,
+    user_agent varchar(255) not null,
+    ip varchar(45) not null,
+    expires_at timestamptz not null,
+    last_seen_at timestamptz not null,
+    revoked_at timestamptz null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE INDEX ON sessions (user_id);
@@ -0,0 +1,17 @@
+-- Requests made by admins impersonating users.
+
+CREATE TABLE impersonation_audit(
+    id serial primary key,
+    actor_id int not null,
+    user_id int not null,
+    token_id varchar(32) not null,
+    method varchar(10) not null,
+    path varchar(255) not null,
+    status int not null,
+    ip varchar(45) not null,
+    createdAt timestamptz default current_timestamp
+);
+
+CREATE INDEX ON impersonation_audit (actor_id, createdAt);
+
+CREATE INDEX ON impersonation_audit (user_id, createdAt);
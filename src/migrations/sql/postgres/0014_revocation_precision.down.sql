@@ -0,0 +1 @@
+ALTER TABLE user_revocations ALTER COLUMN issued_until TYPE timestamptz;
@@ -0,0 +1,3 @@
+-- Revocations of every token of a user keep the microseconds of the iat claim.
+
+ALTER TABLE user_revocations ALTER COLUMN issued_until TYPE timestamptz(6);
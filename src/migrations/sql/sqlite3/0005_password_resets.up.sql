@@ -0,0 +1,10 @@
+-- Tokens of the password resets sent by email.
+
+CREATE TABLE password_resets(
+    id integer primary key autoincrement,
+    user_id int not null references users(id) on delete cascade,
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null,
+    createdAt datetime default current_timestamp
+);
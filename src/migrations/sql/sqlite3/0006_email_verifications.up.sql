@@ -0,0 +1,15 @@
+-- Verification of the email of the users.
+
+ALTER TABLE users ADD COLUMN verified_at datetime null;
+
+CREATE TABLE email_verifications(
+    id integer primary key autoincrement,
+    user_id int not null references users(id) on delete cascade,
+    email varchar(50) collate nocase not null,
+    token_hash char(64) not null unique,
+    expires_at datetime not null,
+    used_at datetime null,
+    createdAt datetime default current_timestamp
+);
+
+CREATE INDEX email_verifications_user_id_createdat ON email_verifications (user_id, createdAt);
@@ -0,0 +1,16 @@
+-- Time based one time passwords of the users and their recovery codes.
+
+ALTER TABLE users ADD COLUMN totp_secret varchar(64) null;
+
+ALTER TABLE users ADD COLUMN totp_enabled_at datetime null;
+
+ALTER TABLE users ADD COLUMN totp_last_step bigint not null default 0;
+
+CREATE TABLE recovery_codes(
+    id integer primary key autoincrement,
+    user_id int not null references users(id) on delete cascade,
+    code_hash char(64) not null,
+    used_at datetime null
+);
+
+CREATE INDEX recovery_codes_user_id_code_hash ON recovery_codes (user_id, code_hash);
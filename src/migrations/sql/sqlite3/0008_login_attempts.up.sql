@@ -0,0 +1,15 @@
+-- Failed logins, counted to throttle guessing and kept for auditing.
+
+CREATE TABLE login_attempts(
+    id integer primary key autoincrement,
+    email varchar(50) collate nocase not null,
+    user_id int null,
+    ip varchar(45) not null,
+    user_agent varchar(255) not null,
+    reason varchar(30) not null,
+    createdAt datetime default current_timestamp
+);
+
+CREATE INDEX login_attempts_email ON login_attempts (email);
+
+CREATE INDEX login_attempts_ip ON login_attempts (ip);
@@ -0,0 +1,13 @@
+-- Personal api keys of the users.
+
+CREATE TABLE api_keys(
+    id integer primary key autoincrement,
+    user_id int not null references users(id) on delete cascade,
+    name varchar(50) not null,
+    prefix char(8) not null unique,
+    key_hash char(64) not null,
+    scopes varchar(255) not null default '',
+    last_used_at datetime null,
+    revoked_at datetime null,
+    createdAt datetime default current_timestamp
+);
@@ -0,0 +1,9 @@
+-- Links of the passwordless logins sent by email.
+
+CREATE TABLE magic_links(
+    token_id varchar(32) primary key,
+    user_id int not null references users(id) on delete cascade,
+    expires_at datetime not null,
+    used_at datetime null,
+    createdAt datetime default current_timestamp
+);
@@ -0,0 +1,17 @@
+-- Login sessions the users can list and revoke.
+
+CREATE TABLE sessions(
+    id integer primary key autoincrement,
+    user_id int not null references users(id) on delete cascade,
+    family_id varchar(32) not null unique,
+    client_id varchar(32) null,
+    token_id varchar(32) null,
+    user_agent varchar(255) not null,
+    ip varchar(45) not null,
+    expires_at datetime not null,
+    last_seen_at datetime not null,
+    revoked_at datetime null,
+    createdAt datetime default current_timestamp
+);
+
+CREATE INDEX sessions_user_id ON sessions (user_id);
@@ -0,0 +1 @@
+-- SQLite keeps the times as they are written, there is nothing to revert.
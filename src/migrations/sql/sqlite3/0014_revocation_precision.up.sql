@@ -0,0 +1,2 @@
+-- Revocations of every token of a user keep the microseconds of the iat claim, which SQLite
+-- stores as they are written.
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// APIKey represents a long lived personal credential, only the hash of the key is stored
+type APIKey struct {
+	ID         uint64     `json:"id"`
+	UserID     uint64     `json:"userId"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	KeyHash    string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	Key        string     `json:"key,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// Prepare validate and format an api key
+func (apiKey *APIKey) Prepare() error {
+	apiKey.Name = strings.TrimSpace(apiKey.Name)
+
+	if apiKey.Name == "" {
+		return errors.New(errorMessage("name"))
+	}
+
+	if len(apiKey.Name) > 50 {
+		return errors.New("Field name cannot have more than 50 characters")
+	}
+
+	return nil
+}
@@ -0,0 +1,15 @@
+package models
+
+// DatabaseStats DTO with the state of the connection pool
+type DatabaseStats struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	// WaitDuration total time waited for a connection, in milliseconds
+	WaitDuration      int64 `json:"waitDuration"`
+	MaxIdleClosed     int64 `json:"maxIdleClosed"`
+	MaxIdleTimeClosed int64 `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed int64 `json:"maxLifetimeClosed"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"api/src/security"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FieldErrors is a validation error listing the problems of each field of a request
+type FieldErrors map[string][]string
+
+// Error summarize the fields with problems
+func (fieldErrors FieldErrors) Error() string {
+	fields := make([]string, 0, len(fieldErrors))
+
+	for field := range fieldErrors {
+		fields = append(fields, field)
+	}
+
+	sort.Strings(fields)
+
+	return fmt.Sprintf("Invalid fields: %s", strings.Join(fields, ", "))
+}
+
+// Fields returns the problems of each field
+func (fieldErrors FieldErrors) Fields() map[string][]string {
+	return fieldErrors
+}
+
+// ValidatePassword check a new password sent in the field against the password policy,
+// it must not contain the personal values of the user
+func ValidatePassword(field, password string, personal ...string) error {
+	problems, error := security.ValidatePassword(password, personal...)
+
+	if error != nil {
+		return error
+	}
+
+	if len(problems) > 0 {
+		return FieldErrors{field: problems}
+	}
+
+	return nil
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Reasons of a failed login
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureInvalidCode        = "invalid_code"
+	LoginFailureLocked             = "locked"
+)
+
+// LoginAttempt represents the audit record of a failed login
+type LoginAttempt struct {
+	ID        uint64    `json:"id"`
+	Email     string    `json:"email,omitempty"`
+	UserID    uint64    `json:"userId,omitempty"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"userAgent"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
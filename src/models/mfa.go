@@ -0,0 +1,37 @@
+package models
+
+// TOTP represents the time based one time password setup of a user
+type TOTP struct {
+	Secret  string
+	Enabled bool
+	// LastStep is the last period a code was accepted, codes are refused from it backwards
+	LastStep int64
+}
+
+// MFAEnrollment DTO with the secret to register in an authenticator app
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFACode DTO carrying a code of the authenticator app or a recovery code
+type MFACode struct {
+	Code string `json:"code"`
+}
+
+// RecoveryCodes DTO with the codes shown once to the user
+type RecoveryCodes struct {
+	RecoveryCodes []string `json:"recoveryCodes"`
+}
+
+// MFAChallenge DTO returned by the login of users with two factor authentication
+type MFAChallenge struct {
+	MFARequired bool   `json:"mfaRequired"`
+	MFAToken    string `json:"mfaToken"`
+}
+
+// MFALogin DTO of the second step of the login
+type MFALogin struct {
+	MFAToken string `json:"mfaToken"`
+	Code     string `json:"code"`
+}
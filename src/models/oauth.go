@@ -0,0 +1,162 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// OAuthClient represents a third party application allowed to act on behalf of users,
+// only the hash of the secret is stored and public clients have no secret
+type OAuthClient struct {
+	ID           uint64    `json:"id"`
+	ClientID     string    `json:"clientId"`
+	ClientSecret string    `json:"clientSecret,omitempty"`
+	SecretHash   string    `json:"-"`
+	Confidential bool      `json:"confidential"`
+	Name         string    `json:"name"`
+	RedirectURIs []string  `json:"redirectUris"`
+	Scopes       []string  `json:"scopes"`
+	OwnerID      uint64    `json:"ownerId"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// Prepare validate and format an oauth client
+func (client *OAuthClient) Prepare() error {
+	client.Name = strings.TrimSpace(client.Name)
+
+	if client.Name == "" {
+		return errors.New(errorMessage("name"))
+	}
+
+	if len(client.Name) > 50 {
+		return errors.New("Field name cannot have more than 50 characters")
+	}
+
+	if len(client.RedirectURIs) == 0 {
+		return errors.New(errorMessage("redirectUris"))
+	}
+
+	for _, redirectURI := range client.RedirectURIs {
+		address, error := url.Parse(redirectURI)
+
+		if error != nil || !address.IsAbs() || address.Fragment != "" || len(redirectURI) > 255 ||
+			strings.ContainsAny(redirectURI, " ") || !allowedRedirect(address, client.Confidential) {
+			return fmt.Errorf("Invalid redirect uri %s", redirectURI)
+		}
+	}
+
+	if len(strings.Join(client.RedirectURIs, " ")) > 1000 {
+		return errors.New("Field redirectUris cannot have more than 1000 characters")
+	}
+
+	return nil
+}
+
+// allowedRedirect report if codes can be sent to the address: over https, over http only to
+// the loopback interface of the device, and to the private scheme of a native app, named after
+// a domain it owns like com.example.app, as native apps are public clients
+func allowedRedirect(address *url.URL, confidential bool) bool {
+	switch address.Scheme {
+	case "https":
+		return address.Host != ""
+	case "http":
+		if address.Hostname() == "localhost" {
+			return true
+		}
+
+		ip := net.ParseIP(address.Hostname())
+
+		return ip != nil && ip.IsLoopback()
+	}
+
+	return !confidential && strings.Contains(address.Scheme, ".")
+}
+
+// HasRedirectURI report if the uri was registered by the client, uris are compared exactly
+func (client OAuthClient) HasRedirectURI(redirectURI string) bool {
+	for _, registered := range client.RedirectURIs {
+		if registered == redirectURI {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AllowsScope report if the client may be granted the scope
+func (client OAuthClient) AllowsScope(scope string) bool {
+	for _, allowed := range client.Scopes {
+		if allowed == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// OAuthCode represents an authorization code, exchanged once for tokens by the client
+type OAuthCode struct {
+	ID                  uint64
+	CodeHash            string
+	ClientID            string
+	UserID              uint64
+	RedirectURI         string
+	Scopes              []string
+	CodeChallenge       string
+	CodeChallengeMethod string
+	ExpiresAt           time.Time
+	Used                bool
+	// FamilyID of the refresh tokens issued for the code, revoked if the code is replayed
+	FamilyID  string
+	CreatedAt time.Time
+}
+
+// OAuthAuthorization DTO of an authorization request, approved or denied by the user
+type OAuthAuthorization struct {
+	ResponseType        string `json:"responseType"`
+	ClientID            string `json:"clientId"`
+	RedirectURI         string `json:"redirectUri"`
+	Scope               string `json:"scope"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"codeChallenge"`
+	CodeChallengeMethod string `json:"codeChallengeMethod"`
+	Approve             bool   `json:"approve"`
+}
+
+// OAuthConsent DTO describing what the client asks, shown to the user before approving
+type OAuthConsent struct {
+	ClientID    string   `json:"clientId"`
+	ClientName  string   `json:"clientName"`
+	RedirectURI string   `json:"redirectUri"`
+	Scopes      []string `json:"scopes"`
+	State       string   `json:"state,omitempty"`
+}
+
+// OAuthRedirect DTO with the address the user agent must be sent back to
+type OAuthRedirect struct {
+	RedirectURI string `json:"redirectUri"`
+}
+
+// OAuthTokens DTO answered by the token endpoint, field names follow RFC 6749
+type OAuthTokens struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthIntrospection DTO answered by the introspection endpoint, field names follow RFC 7662
+type OAuthIntrospection struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+}
@@ -0,0 +1,41 @@
+package models_test
+
+import (
+	"api/src/models"
+	"testing"
+)
+
+func TestOAuthClientRedirectURIs(t *testing.T) {
+	tests := []struct {
+		redirectURI  string
+		confidential bool
+		valid        bool
+	}{
+		{"https://client.test/callback", true, true},
+		{"https://client.test/callback?from=devbook", false, true},
+		{"http://localhost:8080/callback", false, true},
+		{"http://127.0.0.1:8080/callback", true, true},
+		{"http://[::1]:8080/callback", false, true},
+		{"com.client.app:/callback", false, true},
+		{"com.client.app:/callback", true, false},
+		{"http://client.test/callback", false, false},
+		{"https:///callback", false, false},
+		{"https://client.test/callback#fragment", false, false},
+		{"/callback", false, false},
+		{"javascript:alert(document.cookie)", false, false},
+		{"JavaScript:alert(document.cookie)", false, false},
+		{"data:text/html,<script>alert(1)</script>", false, false},
+		{"file:///etc/passwd", false, false},
+		{"vbscript:msgbox(1)", false, false},
+		{"app:/callback", false, false},
+	}
+
+	for _, test := range tests {
+		client := models.OAuthClient{Name: "Client", Confidential: test.confidential, RedirectURIs: []string{test.redirectURI}}
+
+		if error := client.Prepare(); (error == nil) != test.valid {
+			t.Errorf("Redirect uri %s of a client confidential %t: expected valid %t, got %v",
+				test.redirectURI, test.confidential, test.valid, error)
+		}
+	}
+}
@@ -5,3 +5,14 @@ type Password struct {
 	NewPassword     string `json:"newPassword"`
 	CurrentPassword string `json:"currentPassword"`
 }
+
+// ForgotPassword DTO of a password reset request
+type ForgotPassword struct {
+	Email string `json:"email"`
+}
+
+// ResetPassword DTO of a password reset
+type ResetPassword struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"newPassword"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// Session represents a login of a user, lasting as long as its family of refresh tokens
+type Session struct {
+	ID         uint64    `json:"id"`
+	UserID     uint64    `json:"userId"`
+	FamilyID   string    `json:"-"`
+	ClientID   string    `json:"clientId,omitempty"`
+	TokenID    string    `json:"-"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	Revoked    bool      `json:"-"`
+	CreatedAt  time.Time `json:"createdAt"`
+	// Current tells the session of the credential listing the sessions
+	Current bool `json:"current"`
+}
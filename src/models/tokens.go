@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Tokens DTO returned when a user authenticates
+type Tokens struct {
+	AccessToken  string    `json:"accessToken"`
+	TokenType    string    `json:"tokenType"`
+	ExpiresAt    time.Time `json:"expiresAt"`
+	RefreshToken string    `json:"refreshToken"`
+}
+
+// LoginResponse DTO answered by the logins, the tokens along with the profile of the user
+type LoginResponse struct {
+	Tokens
+	User Profile `json:"user"`
+}
+
+// Impersonation DTO of a token letting an admin act as another user
+type Impersonation struct {
+	AccessToken string    `json:"accessToken"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	UserID      uint64    `json:"userId"`
+	ActorID     uint64    `json:"actorId"`
+}
+
+// RefreshRequest DTO of token refresh
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// RefreshToken represents a stored refresh token, only its hash is persisted
+type RefreshToken struct {
+	ID       uint64
+	UserID   uint64
+	FamilyID string
+	// ClientID of the oauth client the family was issued to, empty for the api own login
+	ClientID          string
+	TokenHash         string
+	ExpiresAt         time.Time
+	AbsoluteExpiresAt time.Time
+	// Scopes restricting the tokens of the family, nil when they are not restricted
+	Scopes    []string
+	Revoked   bool
+	CreatedAt time.Time
+}
+
+// OneTimeToken represents a stored single use token sent by email, only its hash is persisted
+type OneTimeToken struct {
+	ID        uint64
+	UserID    uint64
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+	Used      bool
+	CreatedAt time.Time
+}
@@ -12,12 +12,57 @@ import (
 
 // User cria um usuário
 type User struct {
-	ID        uint64    `json:"id,omitempty"`
-	Name      string    `json:"name,omitempty"`
-	Email     string    `json:"email,omitempty"`
-	Nick      string    `json:"nick,omitempty"`
-	Password  string    `json:"password,omitEmpty"`
-	CreatedAt time.Time `json:"CreatedAt,omitEmpty"`
+	ID          uint64     `json:"id,omitempty"`
+	Name        string     `json:"name,omitempty"`
+	Email       string     `json:"email,omitempty"`
+	Nick        string     `json:"nick,omitempty"`
+	Password    string     `json:"password,omitEmpty"`
+	Roles       []string   `json:"roles,omitempty"`
+	SuspendedAt *time.Time `json:"suspendedAt,omitempty"`
+	VerifiedAt  *time.Time `json:"verifiedAt,omitempty"`
+	CreatedAt   time.Time  `json:"CreatedAt,omitEmpty"`
+}
+
+// Profile is the public information of a user
+type Profile struct {
+	ID        uint64    `json:"id"`
+	Name      string    `json:"name"`
+	Nick      string    `json:"nick"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Profile returns the public information of the user
+func (user User) Profile() Profile {
+	return Profile{
+		ID:        user.ID,
+		Name:      user.Name,
+		Nick:      user.Nick,
+		Email:     user.Email,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+// Credentials DTO of a login, scope optionally restricts the tokens issued
+type Credentials struct {
+	Email    string  `json:"email"`
+	Password string  `json:"password"`
+	Scope    *string `json:"scope,omitempty"`
+}
+
+// VerifyEmail DTO of an email verification
+type VerifyEmail struct {
+	Token string `json:"token"`
+}
+
+// MagicLinkRequest DTO of a passwordless login request
+type MagicLinkRequest struct {
+	Email string `json:"email"`
+}
+
+// Roles DTO of a role change
+type Roles struct {
+	Roles []string `json:"roles"`
 }
 
 // Prepare call the methods to validate and format user
@@ -54,6 +99,12 @@ func (user *User) validate(step string) error {
 		return errors.New(errorMessage("password"))
 	}
 
+	if step == "register" {
+		if error := ValidatePassword("password", user.Password, user.Nick, user.Email); error != nil {
+			return error
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,50 @@
+package network
+
+import (
+	"api/src/config"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the address of the client, read from X-Forwarded-For only when the
+// api is configured to trust its proxies
+func ClientIP(r *http.Request) string {
+	if config.TrustProxyHeaders {
+		if forwarded := forwardedFor(r); forwarded != "" {
+			return forwarded
+		}
+	}
+
+	host, _, error := net.SplitHostPort(r.RemoteAddr)
+
+	if error != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
+
+// forwardedFor returns the X-Forwarded-For entry appended by the outermost trusted proxy. The
+// entries on its left were sent by the client, which can write anything there
+func forwardedFor(r *http.Request) string {
+	var entries []string
+
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		entries = append(entries, strings.Split(header, ",")...)
+	}
+
+	hops := config.TrustedProxyHops
+
+	if hops < 1 || len(entries) < hops {
+		return ""
+	}
+
+	entry := strings.TrimSpace(entries[len(entries)-hops])
+
+	if net.ParseIP(entry) == nil {
+		return ""
+	}
+
+	return entry
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// APIKeys represents a repository of personal api keys
+type APIKeys struct {
+	db *DB
+}
+
+// NewAPIKeyRepository returns a new api key repository
+func NewAPIKeyRepository(db *DB) *APIKeys {
+	return &APIKeys{db}
+}
+
+// Create insert a new api key
+func (repository APIKeys) Create(ctx context.Context, apiKey models.APIKey) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx,
+		"insert into api_keys (user_id, name, prefix, key_hash, scopes) values (?, ?, ?, ?, ?)",
+		apiKey.UserID,
+		apiKey.Name,
+		apiKey.Prefix,
+		apiKey.KeyHash,
+		strings.Join(apiKey.Scopes, " "),
+	)
+}
+
+// GetByPrefix get an api key by its public prefix, an empty key is returned when it does not exist
+func (repository APIKeys) GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, createdAt
+	FROM api_keys WHERE prefix = ?`,
+		prefix)
+
+	if error != nil {
+		return models.APIKey{}, error
+	}
+
+	defer lines.Close()
+
+	apiKeys, error := scanAPIKeys(lines)
+
+	if error != nil || len(apiKeys) == 0 {
+		return models.APIKey{}, error
+	}
+
+	return apiKeys[0], nil
+}
+
+// ListByUser get the api keys of a user, revoked ones included
+func (repository APIKeys) ListByUser(ctx context.Context, userID uint64) ([]models.APIKey, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, name, prefix, key_hash, scopes, last_used_at, revoked_at, createdAt
+	FROM api_keys WHERE user_id = ? ORDER BY id`,
+		userID)
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer lines.Close()
+
+	return scanAPIKeys(lines)
+}
+
+// Revoke revoke an api key of a user, it returns false when the user has no such active key
+func (repository APIKeys) Revoke(ctx context.Context, ID, userID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE api_keys SET revoked_at = ? WHERE id = ? AND user_id = ? AND revoked_at IS NULL",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, time.Now(), ID, userID)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// Touch register the use of an api key, at most once a minute to spare writes
+func (repository APIKeys) Touch(ctx context.Context, ID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE api_keys SET last_used_at = ? WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	now := time.Now()
+
+	if _, error = statement.ExecContext(ctx, now, ID, now.Add(-time.Minute)); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+func scanAPIKeys(lines *sql.Rows) ([]models.APIKey, error) {
+	var apiKeys []models.APIKey
+
+	for lines.Next() {
+		var (
+			apiKey     models.APIKey
+			scopes     string
+			lastUsedAt sql.NullTime
+			revokedAt  sql.NullTime
+		)
+
+		if error := lines.Scan(
+			&apiKey.ID,
+			&apiKey.UserID,
+			&apiKey.Name,
+			&apiKey.Prefix,
+			&apiKey.KeyHash,
+			&scopes,
+			&lastUsedAt,
+			&revokedAt,
+			&apiKey.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+
+		apiKey.Scopes = strings.Fields(scopes)
+		apiKey.LastUsedAt = nullTime(lastUsedAt)
+		apiKey.RevokedAt = nullTime(revokedAt)
+
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, nil
+}
@@ -0,0 +1,126 @@
+// Package conformance checks that a store behaves like the database the api was written for,
+// so every dialect and the in memory store can be verified by the same tests
+package conformance
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+	"testing"
+)
+
+// Run the conformance tests, each on an empty store returned by open
+func Run(t *testing.T, open func(t *testing.T) repositories.Store) {
+	tests := []struct {
+		name string
+		test func(*testing.T, repositories.Store)
+	}{
+		{"UsersCreateAndGet", testUsersCreateAndGet},
+		{"UsersUniqueEmail", testUsersUniqueEmail},
+		{"UsersSearch", testUsersSearch},
+		{"UsersUpdate", testUsersUpdate},
+		{"UsersRolesAndSuspension", testUsersRolesAndSuspension},
+		{"UsersFollowers", testUsersFollowers},
+		{"UsersTOTP", testUsersTOTP},
+		{"UsersDeleteCascades", testUsersDeleteCascades},
+		{"PublicationsCreateAndGet", testPublicationsCreateAndGet},
+		{"PublicationsFeed", testPublicationsFeed},
+		{"PublicationsUpdateAndDelete", testPublicationsUpdateAndDelete},
+		{"PublicationsLikes", testPublicationsLikes},
+		{"SessionsActivity", testSessionsActivity},
+		{"UnitOfWorkCommit", testUnitOfWorkCommit},
+		{"UnitOfWorkRollback", testUnitOfWorkRollback},
+		{"UnitOfWorkPanic", testUnitOfWorkPanic},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run(test.name, func(t *testing.T) {
+			test.test(t, open(t))
+		})
+	}
+}
+
+// createUser insert a user named after the nick
+func createUser(t *testing.T, store repositories.Store, nick string) uint64 {
+	t.Helper()
+
+	ID, error := store.Users().Create(context.Background(), models.User{
+		Name:     "User " + nick,
+		Nick:     nick,
+		Email:    nick + "@devbook.test",
+		Password: "hash of " + nick,
+	})
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	if ID == 0 {
+		t.Fatal("Create returned no id")
+	}
+
+	return ID
+}
+
+// createPublication insert a publication of the author
+func createPublication(t *testing.T, store repositories.Store, authorID uint64, title string) uint64 {
+	t.Helper()
+
+	ID, error := store.Publications().CreatePublication(context.Background(), models.Publication{
+		Title:    title,
+		Content:  "Content of " + title,
+		AuthorID: authorID,
+	})
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	return ID
+}
+
+// check fail the test on an error
+func check(t *testing.T, error error) {
+	t.Helper()
+
+	if error != nil {
+		t.Fatal(error)
+	}
+}
+
+// ids of the users or publications, in order
+func userIDs(users []models.User) []uint64 {
+	var IDs []uint64
+
+	for _, user := range users {
+		IDs = append(IDs, user.ID)
+	}
+
+	return IDs
+}
+
+func publicationIDs(publications []models.Publication) []uint64 {
+	var IDs []uint64
+
+	for _, publication := range publications {
+		IDs = append(IDs, publication.ID)
+	}
+
+	return IDs
+}
+
+func sameIDs(got []uint64, expected ...uint64) bool {
+	if len(got) != len(expected) {
+		return false
+	}
+
+	for index := range got {
+		if got[index] != expected[index] {
+			return false
+		}
+	}
+
+	return true
+}
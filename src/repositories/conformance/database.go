@@ -0,0 +1,77 @@
+package conformance
+
+import (
+	"api/src/database"
+	"api/src/migrations"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ServerVariables name the environment variables holding the connection string of a test
+// database for the dialects that run on a server
+var ServerVariables = map[string]string{
+	database.MySQL.Name:      "MYSQL_TEST_DSN",
+	database.PostgreSQL.Name: "POSTGRES_TEST_DSN",
+}
+
+// OpenDatabase returns an empty database of the dialect migrated to the latest schema. SQLite
+// databases are temporary files, the others are reached through ServerVariables and the test is
+// skipped when the variable is not set. Their schema is reverted before being migrated again,
+// so the test database must not hold anything worth keeping
+func OpenDatabase(t *testing.T, dialect database.Dialect) *sql.DB {
+	t.Helper()
+
+	connectionString := "file:" + filepath.Join(t.TempDir(), "devbook.db")
+
+	if dialect != database.SQLite {
+		variable := ServerVariables[dialect.Name]
+
+		if connectionString = os.Getenv(variable); connectionString == "" {
+			t.Skipf("Set %s to run the test on %s", variable, dialect.Name)
+		}
+	}
+
+	if error := migrate(dialect, connectionString); error != nil {
+		t.Fatal(error)
+	}
+
+	db, error := database.Open(dialect, connectionString)
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// migrate revert every migration of the database then apply them again
+func migrate(dialect database.Dialect, connectionString string) error {
+	db, error := database.OpenMigrations(dialect, connectionString)
+
+	if error != nil {
+		return error
+	}
+
+	defer db.Close()
+
+	loaded, error := migrations.Load(dialect)
+
+	if error != nil {
+		return error
+	}
+
+	migrator := migrations.NewMigrator(db, dialect, loaded)
+
+	if _, error = migrator.Down(context.Background(), len(loaded)); error != nil {
+		return error
+	}
+
+	_, error = migrator.Up(context.Background())
+
+	return error
+}
@@ -0,0 +1,126 @@
+package conformance
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+	"testing"
+)
+
+func testPublicationsCreateAndGet(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+
+	ID := createPublication(t, store, maria, "First")
+
+	publication, error := store.Publications().GetPublication(ctx, ID)
+	check(t, error)
+
+	if publication.ID != ID || publication.Title != "First" || publication.Content != "Content of First" ||
+		publication.AuthorID != maria || publication.AuthorNick != "maria" || publication.Likes != 0 ||
+		publication.CreatedAt.IsZero() {
+		t.Fatalf("Unexpected publication %+v", publication)
+	}
+
+	if publication, error = store.Publications().GetPublication(ctx, ID+1); error != nil || publication.ID != 0 {
+		t.Fatalf("Missing publication found %+v %v", publication, error)
+	}
+
+	if _, error = store.Publications().CreatePublication(ctx, models.Publication{Title: "Orphan", Content: "No author", AuthorID: maria + 1}); error == nil {
+		t.Fatal("Publication of a missing author was accepted")
+	}
+}
+
+func testPublicationsFeed(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	joao := createUser(t, store, "joao")
+	ana := createUser(t, store, "ana")
+
+	// Followed by two users, the publications of joao must still be listed once
+	check(t, store.Users().Follow(ctx, joao, maria))
+	check(t, store.Users().Follow(ctx, joao, ana))
+
+	first := createPublication(t, store, maria, "First")
+	second := createPublication(t, store, joao, "Second")
+	createPublication(t, store, ana, "Of ana")
+	third := createPublication(t, store, maria, "Third")
+
+	feed, error := store.Publications().ListPublications(ctx, maria)
+	check(t, error)
+
+	if !sameIDs(publicationIDs(feed), third, second, first) {
+		t.Fatalf("Unexpected feed %+v", feed)
+	}
+
+	if feed[1].AuthorNick != "joao" || feed[1].Title != "Second" {
+		t.Fatalf("Unexpected publication in the feed %+v", feed[1])
+	}
+
+	own, error := store.Publications().ListUserPublications(ctx, maria)
+	check(t, error)
+
+	IDs := publicationIDs(own)
+	sortIDs(IDs)
+
+	if !sameIDs(IDs, first, third) {
+		t.Fatalf("Unexpected publications of the user %+v", own)
+	}
+
+	all, error := store.Publications().ListAllPublications(ctx)
+	check(t, error)
+
+	if len(all) != 4 || all[0].ID != third || all[3].ID != first {
+		t.Fatalf("Unexpected publications %+v", all)
+	}
+}
+
+func testPublicationsUpdateAndDelete(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	ID := createPublication(t, store, maria, "Draft")
+
+	check(t, store.Publications().UpdatePublication(ctx, models.Publication{Title: "Final", Content: "Edited"}, ID))
+
+	publication, error := store.Publications().GetPublication(ctx, ID)
+	check(t, error)
+
+	if publication.Title != "Final" || publication.Content != "Edited" || publication.AuthorID != maria {
+		t.Fatalf("Publication was not updated %+v", publication)
+	}
+
+	check(t, store.Publications().DeletePublication(ctx, ID))
+
+	if publication, _ = store.Publications().GetPublication(ctx, ID); publication.ID != 0 {
+		t.Fatalf("Deleted publication found %+v", publication)
+	}
+}
+
+func testPublicationsLikes(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	ID := createPublication(t, store, maria, "Liked")
+
+	check(t, store.Publications().LikePublication(ctx, ID))
+	check(t, store.Publications().LikePublication(ctx, ID))
+	check(t, store.Publications().UnLikePublication(ctx, ID))
+
+	publication, error := store.Publications().GetPublication(ctx, ID)
+	check(t, error)
+
+	if publication.Likes != 1 {
+		t.Fatalf("Expected 1 like, got %d", publication.Likes)
+	}
+
+	// Likes never go below zero
+	check(t, store.Publications().UnLikePublication(ctx, ID))
+	check(t, store.Publications().UnLikePublication(ctx, ID))
+
+	if publication, _ = store.Publications().GetPublication(ctx, ID); publication.Likes != 0 {
+		t.Fatalf("Expected no likes, got %d", publication.Likes)
+	}
+}
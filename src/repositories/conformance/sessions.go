@@ -0,0 +1,41 @@
+package conformance
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+	"testing"
+	"time"
+)
+
+func testSessionsActivity(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	repository := store.Sessions()
+
+	sessionID, error := repository.Create(ctx, models.Session{
+		UserID:    maria,
+		FamilyID:  "family",
+		ExpiresAt: time.Now().Add(time.Hour),
+	})
+	check(t, error)
+
+	created, error := repository.Get(ctx, sessionID)
+	check(t, error)
+
+	// Seen after the moment, the session is left as it is
+	check(t, repository.Active(ctx, sessionID, created.LastSeenAt.Add(-time.Minute)))
+
+	if session, _ := repository.Get(ctx, sessionID); !session.LastSeenAt.Equal(created.LastSeenAt) {
+		t.Fatalf("Activity written within the interval %v %v", created.LastSeenAt, session.LastSeenAt)
+	}
+
+	time.Sleep(time.Second)
+
+	check(t, repository.Active(ctx, sessionID, time.Now()))
+
+	if session, _ := repository.Get(ctx, sessionID); !session.LastSeenAt.After(created.LastSeenAt) {
+		t.Fatalf("Activity not written %v %v", created.LastSeenAt, session.LastSeenAt)
+	}
+}
@@ -0,0 +1,96 @@
+package conformance
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+	"errors"
+	"testing"
+)
+
+func testUnitOfWorkCommit(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	var maria, publication uint64
+
+	check(t, store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		var error error
+
+		if maria, error = work.Users().Create(ctx, models.User{Name: "Maria", Nick: "maria", Email: "maria@devbook.test", Password: "hash"}); error != nil {
+			return error
+		}
+
+		// The writes of the unit are visible inside it
+		if user, error := work.Users().Get(ctx, maria); error != nil || user.ID != maria {
+			t.Errorf("User created in the unit not found %+v %v", user, error)
+		}
+
+		publication, error = work.Publications().CreatePublication(ctx, models.Publication{Title: "First", Content: "Committed", AuthorID: maria})
+
+		return error
+	}))
+
+	if found, _ := store.Publications().GetPublication(ctx, publication); found.ID != publication || found.AuthorNick != "maria" {
+		t.Fatalf("Committed publication not found %+v", found)
+	}
+}
+
+func testUnitOfWorkRollback(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	failure := errors.New("failure")
+
+	error := store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+		if _, error := work.Publications().CreatePublication(ctx, models.Publication{Title: "Lost", Content: "Rolled back", AuthorID: maria}); error != nil {
+			return error
+		}
+
+		if error := work.Users().UpdateRoles(ctx, maria, []string{"user", "admin"}); error != nil {
+			return error
+		}
+
+		return failure
+	})
+
+	if error != failure {
+		t.Fatalf("Expected the error of the work, got %v", error)
+	}
+
+	if publications, _ := store.Publications().ListUserPublications(ctx, maria); len(publications) != 0 {
+		t.Fatalf("Publications of a failed unit remain %+v", publications)
+	}
+
+	if account, _ := store.Users().GetAccount(ctx, maria); len(account.Roles) != 1 {
+		t.Fatalf("Roles of a failed unit remain %+v", account)
+	}
+
+	// The store keeps working after a rollback
+	createPublication(t, store, maria, "After")
+}
+
+func testUnitOfWorkPanic(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+
+	func() {
+		defer func() {
+			if recovered := recover(); recovered != "broken" {
+				t.Fatalf("Expected the panic of the work, got %v", recovered)
+			}
+		}()
+
+		store.WithTx(ctx, func(work repositories.UnitOfWork) error {
+			if error := work.Users().Delete(ctx, maria); error != nil {
+				return error
+			}
+
+			panic("broken")
+		})
+	}()
+
+	if user, _ := store.Users().Get(ctx, maria); user.ID != maria {
+		t.Fatalf("User deleted by a unit that panicked %+v", user)
+	}
+}
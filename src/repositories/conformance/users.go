@@ -0,0 +1,277 @@
+package conformance
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+	"sort"
+	"testing"
+)
+
+func testUsersCreateAndGet(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	joao := createUser(t, store, "joao")
+
+	if maria == joao {
+		t.Fatalf("Users share the id %d", maria)
+	}
+
+	user, error := store.Users().Get(ctx, maria)
+	check(t, error)
+
+	if user.ID != maria || user.Name != "User maria" || user.Nick != "maria" || user.Email != "maria@devbook.test" ||
+		user.Password != "" || user.CreatedAt.IsZero() {
+		t.Fatalf("Unexpected user %+v", user)
+	}
+
+	if user, error = store.Users().Get(ctx, joao+maria); error != nil || user.ID != 0 {
+		t.Fatalf("Missing user found %+v %v", user, error)
+	}
+
+	password, error := store.Users().GetPassword(ctx, maria)
+	check(t, error)
+
+	if password != "hash of maria" {
+		t.Fatalf("Unexpected password %q", password)
+	}
+
+	check(t, store.Users().UpdatePassword(ctx, "new hash", maria))
+
+	if password, _ = store.Users().GetPassword(ctx, maria); password != "new hash" {
+		t.Fatalf("Password not updated %q", password)
+	}
+
+	// Emails are found regardless of case
+	account, error := store.Users().SearchByEmail(ctx, "MARIA@devbook.test")
+	check(t, error)
+
+	if account.ID != maria || account.Password != "new hash" || len(account.Roles) != 1 || account.Roles[0] != "user" ||
+		account.SuspendedAt != nil {
+		t.Fatalf("Unexpected account %+v", account)
+	}
+
+	if account, error = store.Users().SearchByEmail(ctx, "nobody@devbook.test"); error != nil || account.ID != 0 {
+		t.Fatalf("Missing email found %+v %v", account, error)
+	}
+}
+
+func testUsersUniqueEmail(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	createUser(t, store, "maria")
+	joao := createUser(t, store, "joao")
+
+	if _, error := store.Users().Create(ctx, models.User{Name: "Maria", Nick: "other", Email: "Maria@Devbook.test", Password: "hash"}); error == nil {
+		t.Fatal("Email taken with another case was accepted")
+	}
+
+	if error := store.Users().Update(ctx, joao, models.User{Name: "Joao", Nick: "joao", Email: "maria@devbook.test"}); error == nil {
+		t.Fatal("Email of another user was accepted")
+	}
+}
+
+func testUsersSearch(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	mariana := createUser(t, store, "Mariana")
+	createUser(t, store, "joao")
+
+	// The controllers search with the query in lower case
+	users, error := store.Users().Search(ctx, "mari")
+	check(t, error)
+
+	IDs := userIDs(users)
+	sortIDs(IDs)
+
+	if !sameIDs(IDs, maria, mariana) {
+		t.Fatalf("Unexpected users %+v", users)
+	}
+
+	if users, error = store.Users().Search(ctx, "user joao"); error != nil || len(users) != 1 || users[0].Nick != "joao" {
+		t.Fatalf("Unexpected users by name %+v %v", users, error)
+	}
+
+	if users, error = store.Users().Search(ctx, "nobody"); error != nil || len(users) != 0 {
+		t.Fatalf("Unexpected users %+v %v", users, error)
+	}
+}
+
+func testUsersUpdate(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+
+	verified, error := store.Users().MarkVerified(ctx, maria, "MARIA@devbook.test")
+	check(t, error)
+
+	if !verified {
+		t.Fatal("Email was not verified")
+	}
+
+	if verified, _ = store.Users().MarkVerified(ctx, maria, "other@devbook.test"); verified {
+		t.Fatal("Another email was verified")
+	}
+
+	// Keeping the email keeps it verified
+	check(t, store.Users().Update(ctx, maria, models.User{Name: "Maria", Nick: "mary", Email: "maria@devbook.test"}))
+
+	account, error := store.Users().GetAccount(ctx, maria)
+	check(t, error)
+
+	if account.Email != "maria@devbook.test" || account.VerifiedAt == nil {
+		t.Fatalf("Unexpected account %+v", account)
+	}
+
+	check(t, store.Users().Update(ctx, maria, models.User{Name: "Maria", Nick: "mary", Email: "mary@devbook.test"}))
+
+	if account, _ = store.Users().GetAccount(ctx, maria); account.Email != "mary@devbook.test" || account.VerifiedAt != nil {
+		t.Fatalf("Changed email is still verified %+v", account)
+	}
+
+	user, error := store.Users().Get(ctx, maria)
+	check(t, error)
+
+	if user.Name != "Maria" || user.Nick != "mary" {
+		t.Fatalf("User was not updated %+v", user)
+	}
+}
+
+func testUsersRolesAndSuspension(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	joao := createUser(t, store, "joao")
+
+	check(t, store.Users().UpdateRoles(ctx, maria, []string{"user", "admin"}))
+	check(t, store.Users().Suspend(ctx, joao, true))
+
+	users, error := store.Users().List(ctx)
+	check(t, error)
+
+	if !sameIDs(userIDs(users), maria, joao) {
+		t.Fatalf("Users are not ordered by id %+v", users)
+	}
+
+	if len(users[0].Roles) != 2 || users[0].Roles[1] != "admin" || users[0].SuspendedAt != nil {
+		t.Fatalf("Unexpected user %+v", users[0])
+	}
+
+	if len(users[1].Roles) != 1 || users[1].SuspendedAt == nil {
+		t.Fatalf("Unexpected user %+v", users[1])
+	}
+
+	check(t, store.Users().Suspend(ctx, joao, false))
+
+	if account, _ := store.Users().GetAccount(ctx, joao); account.SuspendedAt != nil {
+		t.Fatalf("User still suspended %+v", account)
+	}
+}
+
+func testUsersFollowers(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	joao := createUser(t, store, "joao")
+	ana := createUser(t, store, "ana")
+
+	check(t, store.Users().Follow(ctx, maria, joao))
+	check(t, store.Users().Follow(ctx, maria, ana))
+
+	// Following twice is ignored
+	check(t, store.Users().Follow(ctx, maria, joao))
+
+	followers, error := store.Users().GetFollowers(ctx, maria)
+	check(t, error)
+
+	IDs := userIDs(followers)
+	sortIDs(IDs)
+
+	if !sameIDs(IDs, joao, ana) {
+		t.Fatalf("Unexpected followers %+v", followers)
+	}
+
+	following, error := store.Users().GetFollowing(ctx, joao)
+	check(t, error)
+
+	if !sameIDs(userIDs(following), maria) || following[0].Nick != "maria" {
+		t.Fatalf("Unexpected following %+v", following)
+	}
+
+	check(t, store.Users().UnFollow(ctx, maria, joao))
+
+	if followers, _ = store.Users().GetFollowers(ctx, maria); !sameIDs(userIDs(followers), ana) {
+		t.Fatalf("Unexpected followers after unfollowing %+v", followers)
+	}
+}
+
+func testUsersTOTP(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+
+	totp, error := store.Users().GetTOTP(ctx, maria)
+	check(t, error)
+
+	if totp.Secret != "" || totp.Enabled || totp.LastStep != 0 {
+		t.Fatalf("Unexpected totp %+v", totp)
+	}
+
+	check(t, store.Users().SetTOTPSecret(ctx, maria, "SECRET"))
+	check(t, store.Users().EnableTOTP(ctx, maria))
+
+	used, error := store.Users().UseTOTPStep(ctx, maria, 10)
+	check(t, error)
+
+	if !used {
+		t.Fatal("Step was not used")
+	}
+
+	// Codes of a period are accepted only once
+	if used, _ = store.Users().UseTOTPStep(ctx, maria, 10); used {
+		t.Fatal("Step was used twice")
+	}
+
+	if totp, _ = store.Users().GetTOTP(ctx, maria); totp.Secret != "SECRET" || !totp.Enabled || totp.LastStep != 10 {
+		t.Fatalf("Unexpected totp %+v", totp)
+	}
+
+	check(t, store.Users().SetTOTPSecret(ctx, maria, ""))
+
+	if totp, _ = store.Users().GetTOTP(ctx, maria); totp.Secret != "" || totp.Enabled {
+		t.Fatalf("Totp still enabled %+v", totp)
+	}
+}
+
+func testUsersDeleteCascades(t *testing.T, store repositories.Store) {
+	ctx := context.Background()
+
+	maria := createUser(t, store, "maria")
+	joao := createUser(t, store, "joao")
+
+	check(t, store.Users().Follow(ctx, joao, maria))
+	publication := createPublication(t, store, maria, "Of maria")
+
+	check(t, store.Users().Delete(ctx, maria))
+
+	if user, _ := store.Users().Get(ctx, maria); user.ID != 0 {
+		t.Fatalf("Deleted user found %+v", user)
+	}
+
+	if followers, _ := store.Users().GetFollowers(ctx, joao); len(followers) != 0 {
+		t.Fatalf("Follows of a deleted user remain %+v", followers)
+	}
+
+	if found, _ := store.Publications().GetPublication(ctx, publication); found.ID != 0 {
+		t.Fatalf("Publication of a deleted user remains %+v", found)
+	}
+}
+
+// sortIDs order ids the store returns in no particular order
+func sortIDs(IDs []uint64) {
+	sort.Slice(IDs, func(i, j int) bool {
+		return IDs[i] < IDs[j]
+	})
+}
@@ -0,0 +1,128 @@
+package repositories
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// conn runs the queries of the repositories, it is the pool or a transaction of it
+type conn interface {
+	PrepareContext(ctx context.Context, query string) (*sql.Stmt, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// DB runs the queries of the repositories, written for MySQL, on a database of any dialect
+type DB struct {
+	pool    *sql.DB
+	conn    conn
+	dialect database.Dialect
+}
+
+// NewDB returns the database of the repositories on a connection pool of the dialect
+func NewDB(db *sql.DB, dialect database.Dialect) *DB {
+	return &DB{db, db, dialect}
+}
+
+// Bind returns the database running its queries in the transaction, which its user commits
+func (db *DB) Bind(tx *sql.Tx) *DB {
+	return &DB{db.pool, tx, db.dialect}
+}
+
+// WithTx run the work in a transaction, committed when it returns no error and rolled back
+// when it fails or panics. Work aborted by a deadlock runs again, up to config.TransactionAttempts
+// times. Work started on a database already bound to a transaction joins it
+func (db *DB) WithTx(ctx context.Context, work func(*DB) error) error {
+	if _, bound := db.conn.(*sql.Tx); bound {
+		return work(db)
+	}
+
+	for attempt := 1; ; attempt++ {
+		error := db.runTx(ctx, work)
+
+		if error == nil || attempt >= config.TransactionAttempts || !db.dialect.IsDeadlock(error) {
+			return error
+		}
+
+		// The transaction that won the conflict is given time to finish
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * 10 * time.Millisecond):
+		}
+	}
+}
+
+// runTx run the work once in a new transaction
+func (db *DB) runTx(ctx context.Context, work func(*DB) error) error {
+	tx, error := db.pool.BeginTx(ctx, nil)
+
+	if error != nil {
+		return error
+	}
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			tx.Rollback()
+			panic(recovered)
+		}
+	}()
+
+	if error = work(db.Bind(tx)); error != nil {
+		tx.Rollback()
+		return error
+	}
+
+	return tx.Commit()
+}
+
+// PrepareContext prepare a statement in the dialect of the database
+func (db *DB) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return db.conn.PrepareContext(ctx, db.dialect.Rebind(query))
+}
+
+// QueryContext run a query in the dialect of the database
+func (db *DB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return db.conn.QueryContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// QueryRowContext run a query returning a single row in the dialect of the database
+func (db *DB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return db.conn.QueryRowContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// ExecContext run a statement in the dialect of the database
+func (db *DB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return db.conn.ExecContext(ctx, db.dialect.Rebind(query), args...)
+}
+
+// insert run an insert into a table with an id column and returns the id of the new row
+func (db *DB) insert(ctx context.Context, query string, args ...interface{}) (uint64, error) {
+	if db.dialect.Returning() {
+		var ID uint64
+
+		if error := db.QueryRowContext(ctx, query+" returning id", args...).Scan(&ID); error != nil {
+			return 0, error
+		}
+
+		return ID, nil
+	}
+
+	result, error := db.ExecContext(ctx, query, args...)
+
+	if error != nil {
+		return 0, error
+	}
+
+	lastInsertedID, error := result.LastInsertId()
+
+	if error != nil {
+		return 0, error
+	}
+
+	return uint64(lastInsertedID), nil
+}
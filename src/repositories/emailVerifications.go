@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// EmailVerifications represents a repository of email verification tokens
+type EmailVerifications struct {
+	db *DB
+}
+
+// NewEmailVerificationRepository returns a new email verification repository
+func NewEmailVerificationRepository(db *DB) *EmailVerifications {
+	return &EmailVerifications{db}
+}
+
+// Create insert a new verification token for an email
+func (repository EmailVerifications) Create(ctx context.Context, token models.OneTimeToken) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx,
+		"insert into email_verifications (user_id, email, token_hash, expires_at, createdAt) values (?, ?, ?, ?, ?)",
+		token.UserID, token.Email, token.TokenHash, token.ExpiresAt, time.Now())
+}
+
+// GetByHash get a verification token by its hash, an empty token is returned when it does not exist
+func (repository EmailVerifications) GetByHash(ctx context.Context, tokenHash string) (models.OneTimeToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, email, token_hash, expires_at, used_at IS NOT NULL, createdAt
+	FROM email_verifications WHERE token_hash = ?`,
+		tokenHash)
+
+	if error != nil {
+		return models.OneTimeToken{}, error
+	}
+
+	defer line.Close()
+
+	var token models.OneTimeToken
+
+	if line.Next() {
+		if error = line.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.Email,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.Used,
+			&token.CreatedAt,
+		); error != nil {
+			return models.OneTimeToken{}, error
+		}
+	}
+
+	return token, nil
+}
+
+// Use mark a valid token as used, it returns false when the token was already used or expired
+func (repository EmailVerifications) Use(ctx context.Context, ID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE email_verifications SET used_at = ? WHERE id = ? AND used_at IS NULL AND expires_at > ?",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	now := time.Now()
+
+	result, error := statement.ExecContext(ctx, now, ID, now)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// LastSentAt get when the last verification token of a user was created, zero when none was
+func (repository EmailVerifications) LastSentAt(ctx context.Context, userID uint64) (time.Time, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx,
+		"SELECT createdAt FROM email_verifications WHERE user_id = ? ORDER BY createdAt DESC LIMIT 1",
+		userID)
+
+	if error != nil {
+		return time.Time{}, error
+	}
+
+	defer line.Close()
+
+	var sentAt time.Time
+
+	if line.Next() {
+		if error = line.Scan(&sentAt); error != nil {
+			return time.Time{}, error
+		}
+	}
+
+	return sentAt, nil
+}
@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"database/sql"
+)
+
+// LoginAttempts represents a repository of failed logins
+type LoginAttempts struct {
+	db *DB
+}
+
+// NewLoginAttemptRepository returns a new login attempt repository
+func NewLoginAttemptRepository(db *DB) *LoginAttempts {
+	return &LoginAttempts{db}
+}
+
+// Create insert the record of a failed login
+func (repository LoginAttempts) Create(ctx context.Context, attempt models.LoginAttempt) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"insert into login_attempts (email, user_id, ip, user_agent, reason) values (?, ?, ?, ?, ?)",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	userID := sql.NullInt64{Int64: int64(attempt.UserID), Valid: attempt.UserID != 0}
+
+	if _, error = statement.ExecContext(ctx, attempt.Email, userID, attempt.IP, attempt.UserAgent, attempt.Reason); error != nil {
+		return error
+	}
+
+	return nil
+}
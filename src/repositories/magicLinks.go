@@ -0,0 +1,71 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// MagicLinks represents a repository of the passwordless login links sent, which
+// makes them single use
+type MagicLinks struct {
+	db *DB
+}
+
+// NewMagicLinkRepository returns a new magic link repository
+func NewMagicLinkRepository(db *DB) *MagicLinks {
+	return &MagicLinks{db}
+}
+
+// Create register a magic link by the id of its token
+func (repository MagicLinks) Create(ctx context.Context, tokenID string, userID uint64, expiresAt time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"insert into magic_links (token_id, user_id, expires_at) values (?, ?, ?)",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, tokenID, userID, expiresAt); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// Use mark a valid link as used, it returns false when the link is unknown, used or expired
+func (repository MagicLinks) Use(ctx context.Context, tokenID string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE magic_links SET used_at = ? WHERE token_id = ? AND used_at IS NULL AND expires_at > ?",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	now := time.Now()
+
+	result, error := statement.ExecContext(ctx, now, tokenID, now)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
@@ -0,0 +1,142 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// apiKey is a row of the api_keys table
+type apiKey struct {
+	models.APIKey
+	scopes string
+}
+
+// APIKeys is the in memory repository of personal api keys
+type APIKeys struct {
+	store *Store
+}
+
+// Create insert a new api key
+func (repository APIKeys) Create(ctx context.Context, key models.APIKey) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[key.UserID]; !found {
+		return 0, errForeignKey
+	}
+
+	for _, stored := range repository.store.apiKeys {
+		if stored.Prefix == key.Prefix {
+			return 0, errDuplicate
+		}
+	}
+
+	row := &apiKey{
+		APIKey: models.APIKey{
+			ID:        repository.store.nextID("api_keys"),
+			UserID:    key.UserID,
+			Name:      key.Name,
+			Prefix:    key.Prefix,
+			KeyHash:   key.KeyHash,
+			CreatedAt: time.Now(),
+		},
+		scopes: strings.Join(key.Scopes, " "),
+	}
+
+	repository.store.apiKeys[row.ID] = row
+
+	return row.ID, nil
+}
+
+// GetByPrefix get an api key by its public prefix, an empty key is returned when it does not exist
+func (repository APIKeys) GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.APIKey{}, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, row := range repository.store.apiKeys {
+		if row.Prefix == prefix {
+			return row.copy(), nil
+		}
+	}
+
+	return models.APIKey{}, nil
+}
+
+// ListByUser get the api keys of a user, revoked ones included
+func (repository APIKeys) ListByUser(ctx context.Context, userID uint64) ([]models.APIKey, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	var apiKeys []models.APIKey
+
+	for _, row := range repository.store.apiKeys {
+		if row.UserID == userID {
+			apiKeys = append(apiKeys, row.copy())
+		}
+	}
+
+	sort.Slice(apiKeys, func(i, j int) bool {
+		return apiKeys[i].ID < apiKeys[j].ID
+	})
+
+	return apiKeys, nil
+}
+
+// Revoke revoke an api key of a user, it returns false when the user has no such active key
+func (repository APIKeys) Revoke(ctx context.Context, ID, userID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.apiKeys[ID]
+
+	if !found || row.UserID != userID || row.RevokedAt != nil {
+		return false, nil
+	}
+
+	revokedAt := time.Now()
+	row.RevokedAt = &revokedAt
+
+	return true, nil
+}
+
+// Touch register the use of an api key, at most once a minute to spare writes
+func (repository APIKeys) Touch(ctx context.Context, ID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	now := time.Now()
+
+	if row, found := repository.store.apiKeys[ID]; found && (row.LastUsedAt == nil || row.LastUsedAt.Before(now.Add(-time.Minute))) {
+		row.LastUsedAt = &now
+	}
+
+	return nil
+}
+
+// copy the key with its scopes decoded like the database column is
+func (row *apiKey) copy() models.APIKey {
+	key := row.APIKey
+	key.Scopes = strings.Fields(row.scopes)
+	key.LastUsedAt = nullableTime(row.LastUsedAt)
+	key.RevokedAt = nullableTime(row.RevokedAt)
+
+	return key
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// EmailVerifications is the in memory repository of email verification tokens
+type EmailVerifications struct {
+	store *Store
+}
+
+// Create insert a new verification token for an email
+func (repository EmailVerifications) Create(ctx context.Context, token models.OneTimeToken) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	return insertOneTimeToken(repository.store, "email_verifications", repository.store.emailVerifications, models.OneTimeToken{
+		UserID:    token.UserID,
+		Email:     token.Email,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+// GetByHash get a verification token by its hash, an empty token is returned when it does not exist
+func (repository EmailVerifications) GetByHash(ctx context.Context, tokenHash string) (models.OneTimeToken, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.OneTimeToken{}, error
+	}
+
+	defer repository.store.unlock()
+
+	return oneTimeTokenByHash(repository.store.emailVerifications, tokenHash), nil
+}
+
+// Use mark a valid token as used, it returns false when the token was already used or expired
+func (repository EmailVerifications) Use(ctx context.Context, ID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	return useOneTimeToken(repository.store.emailVerifications, ID), nil
+}
+
+// LastSentAt get when the last verification token of a user was created, zero when none was
+func (repository EmailVerifications) LastSentAt(ctx context.Context, userID uint64) (time.Time, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return time.Time{}, error
+	}
+
+	defer repository.store.unlock()
+
+	var sentAt time.Time
+
+	for _, token := range repository.store.emailVerifications {
+		if token.UserID == userID && token.CreatedAt.After(sentAt) {
+			sentAt = token.CreatedAt
+		}
+	}
+
+	return sentAt, nil
+}
+
+// insertOneTimeToken insert a token of an existing user with a unique hash in one of the token tables
+func insertOneTimeToken(store *Store, table string, tokens map[uint64]*models.OneTimeToken, token models.OneTimeToken) (uint64, error) {
+	if _, found := store.users[token.UserID]; !found {
+		return 0, errForeignKey
+	}
+
+	for _, stored := range tokens {
+		if stored.TokenHash == token.TokenHash {
+			return 0, errDuplicate
+		}
+	}
+
+	token.ID = store.nextID(table)
+	token.CreatedAt = time.Now()
+
+	tokens[token.ID] = &token
+
+	return token.ID, nil
+}
+
+// oneTimeTokenByHash find a token by its hash, an empty token is returned when it does not exist
+func oneTimeTokenByHash(tokens map[uint64]*models.OneTimeToken, tokenHash string) models.OneTimeToken {
+	for _, token := range tokens {
+		if token.TokenHash == tokenHash {
+			return *token
+		}
+	}
+
+	return models.OneTimeToken{}
+}
+
+// useOneTimeToken mark a token as used, unless it was already used or expired
+func useOneTimeToken(tokens map[uint64]*models.OneTimeToken, ID uint64) bool {
+	token, found := tokens[ID]
+
+	if !found || token.Used || !token.ExpiresAt.After(time.Now()) {
+		return false
+	}
+
+	token.Used = true
+
+	return true
+}
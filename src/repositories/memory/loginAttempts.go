@@ -0,0 +1,28 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// LoginAttempts is the in memory repository of failed logins
+type LoginAttempts struct {
+	store *Store
+}
+
+// Create insert the record of a failed login
+func (repository LoginAttempts) Create(ctx context.Context, attempt models.LoginAttempt) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	attempt.ID = repository.store.nextID("login_attempts")
+	attempt.CreatedAt = time.Now()
+
+	repository.store.loginAttempts = append(repository.store.loginAttempts, attempt)
+
+	return nil
+}
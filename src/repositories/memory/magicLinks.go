@@ -0,0 +1,58 @@
+package memory
+
+import (
+	"context"
+	"time"
+)
+
+// magicLink is a row of the magic_links table
+type magicLink struct {
+	userID    uint64
+	expiresAt time.Time
+	used      bool
+}
+
+// MagicLinks is the in memory repository of the passwordless login links sent
+type MagicLinks struct {
+	store *Store
+}
+
+// Create register a magic link by the id of its token
+func (repository MagicLinks) Create(ctx context.Context, tokenID string, userID uint64, expiresAt time.Time) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[userID]; !found {
+		return errForeignKey
+	}
+
+	if _, found := repository.store.magicLinks[tokenID]; found {
+		return errDuplicate
+	}
+
+	repository.store.magicLinks[tokenID] = &magicLink{userID: userID, expiresAt: expiresAt}
+
+	return nil
+}
+
+// Use mark a valid link as used, it returns false when the link is unknown, used or expired
+func (repository MagicLinks) Use(ctx context.Context, tokenID string) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	link, found := repository.store.magicLinks[tokenID]
+
+	if !found || link.used || !link.expiresAt.After(time.Now()) {
+		return false, nil
+	}
+
+	link.used = true
+
+	return true, nil
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OAuthClients is the in memory repository of oauth clients
+type OAuthClients struct {
+	store *Store
+}
+
+// Create insert a new oauth client
+func (repository OAuthClients) Create(ctx context.Context, client models.OAuthClient) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[client.OwnerID]; !found {
+		return 0, errForeignKey
+	}
+
+	for _, stored := range repository.store.oauthClients {
+		if stored.ClientID == client.ClientID {
+			return 0, errDuplicate
+		}
+	}
+
+	row := &models.OAuthClient{
+		ID:           repository.store.nextID("oauth_clients"),
+		ClientID:     client.ClientID,
+		SecretHash:   client.SecretHash,
+		Confidential: client.SecretHash != "",
+		Name:         client.Name,
+		RedirectURIs: strings.Fields(strings.Join(client.RedirectURIs, " ")),
+		Scopes:       strings.Fields(strings.Join(client.Scopes, " ")),
+		OwnerID:      client.OwnerID,
+		CreatedAt:    time.Now(),
+	}
+
+	repository.store.oauthClients[row.ID] = row
+
+	return row.ID, nil
+}
+
+// GetByClientID get a client by its public id, an empty client is returned when it does not exist
+func (repository OAuthClients) GetByClientID(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.OAuthClient{}, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, row := range repository.store.oauthClients {
+		if row.ClientID == clientID {
+			return copyOAuthClient(row), nil
+		}
+	}
+
+	return models.OAuthClient{}, nil
+}
+
+// ListByOwner get the clients registered by a user
+func (repository OAuthClients) ListByOwner(ctx context.Context, ownerID uint64) ([]models.OAuthClient, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	var clients []models.OAuthClient
+
+	for _, row := range repository.store.oauthClients {
+		if row.OwnerID == ownerID {
+			clients = append(clients, copyOAuthClient(row))
+		}
+	}
+
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ID < clients[j].ID
+	})
+
+	return clients, nil
+}
+
+// Delete delete a client of a user with its codes, it returns false when the user has no such client
+func (repository OAuthClients) Delete(ctx context.Context, clientID string, ownerID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	for ID, row := range repository.store.oauthClients {
+		if row.ClientID == clientID && row.OwnerID == ownerID {
+			repository.store.deleteOAuthClient(ID)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// copyOAuthClient copy a client, so callers cannot change the stored lists
+func copyOAuthClient(row *models.OAuthClient) models.OAuthClient {
+	client := *row
+	client.RedirectURIs = append([]string{}, row.RedirectURIs...)
+	client.Scopes = append([]string{}, row.Scopes...)
+
+	return client
+}
@@ -0,0 +1,113 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"strings"
+	"time"
+)
+
+// OAuthCodes is the in memory repository of oauth authorization codes
+type OAuthCodes struct {
+	store *Store
+}
+
+// Create insert a new authorization code of an existing client and user
+func (repository OAuthCodes) Create(ctx context.Context, code models.OAuthCode) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[code.UserID]; !found {
+		return 0, errForeignKey
+	}
+
+	clientFound := false
+
+	for _, client := range repository.store.oauthClients {
+		clientFound = clientFound || client.ClientID == code.ClientID
+	}
+
+	if !clientFound {
+		return 0, errForeignKey
+	}
+
+	for _, stored := range repository.store.oauthCodes {
+		if stored.CodeHash == code.CodeHash {
+			return 0, errDuplicate
+		}
+	}
+
+	row := &models.OAuthCode{
+		ID:                  repository.store.nextID("oauth_codes"),
+		CodeHash:            code.CodeHash,
+		ClientID:            code.ClientID,
+		UserID:              code.UserID,
+		RedirectURI:         code.RedirectURI,
+		Scopes:              strings.Fields(strings.Join(code.Scopes, " ")),
+		CodeChallenge:       code.CodeChallenge,
+		CodeChallengeMethod: code.CodeChallengeMethod,
+		ExpiresAt:           code.ExpiresAt,
+		CreatedAt:           time.Now(),
+	}
+
+	repository.store.oauthCodes[row.ID] = row
+
+	return row.ID, nil
+}
+
+// GetByHash get an authorization code by its hash, an empty code is returned when it does not exist
+func (repository OAuthCodes) GetByHash(ctx context.Context, codeHash string) (models.OAuthCode, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.OAuthCode{}, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, row := range repository.store.oauthCodes {
+		if row.CodeHash == codeHash {
+			code := *row
+			code.Scopes = append([]string{}, row.Scopes...)
+
+			return code, nil
+		}
+	}
+
+	return models.OAuthCode{Scopes: []string{}}, nil
+}
+
+// Use mark a code as used, it returns false when the code was already used
+func (repository OAuthCodes) Use(ctx context.Context, ID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	code, found := repository.store.oauthCodes[ID]
+
+	if !found || code.Used {
+		return false, nil
+	}
+
+	code.Used = true
+
+	return true, nil
+}
+
+// SetFamily register the refresh token family issued in exchange for a code
+func (repository OAuthCodes) SetFamily(ctx context.Context, ID uint64, familyID string) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if code, found := repository.store.oauthCodes[ID]; found {
+		code.FamilyID = familyID
+	}
+
+	return nil
+}
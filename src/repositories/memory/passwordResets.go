@@ -0,0 +1,65 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+)
+
+// PasswordResets is the in memory repository of password reset tokens
+type PasswordResets struct {
+	store *Store
+}
+
+// Create insert a new password reset token
+func (repository PasswordResets) Create(ctx context.Context, token models.OneTimeToken) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	return insertOneTimeToken(repository.store, "password_resets", repository.store.passwordResets, models.OneTimeToken{
+		UserID:    token.UserID,
+		TokenHash: token.TokenHash,
+		ExpiresAt: token.ExpiresAt,
+	})
+}
+
+// GetByHash get a password reset token by its hash, an empty token is returned when it does not exist
+func (repository PasswordResets) GetByHash(ctx context.Context, tokenHash string) (models.OneTimeToken, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.OneTimeToken{}, error
+	}
+
+	defer repository.store.unlock()
+
+	return oneTimeTokenByHash(repository.store.passwordResets, tokenHash), nil
+}
+
+// Use mark a valid token as used, it returns false when the token was already used or expired
+func (repository PasswordResets) Use(ctx context.Context, ID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	return useOneTimeToken(repository.store.passwordResets, ID), nil
+}
+
+// InvalidateUser mark every pending token of a user as used
+func (repository PasswordResets) InvalidateUser(ctx context.Context, userID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	for _, token := range repository.store.passwordResets {
+		if token.UserID == userID {
+			token.Used = true
+		}
+	}
+
+	return nil
+}
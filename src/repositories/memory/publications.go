@@ -0,0 +1,183 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// Publications is the in memory repository of publications
+type Publications struct {
+	store *Store
+}
+
+// CreatePublication insert a publication of an existing author
+func (repository Publications) CreatePublication(ctx context.Context, publication models.Publication) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[publication.AuthorID]; !found {
+		return 0, errForeignKey
+	}
+
+	ID := repository.store.nextID("publications")
+
+	repository.store.publications[ID] = &models.Publication{
+		ID:        ID,
+		Title:     publication.Title,
+		Content:   publication.Content,
+		AuthorID:  publication.AuthorID,
+		CreatedAt: time.Now(),
+	}
+
+	return ID, nil
+}
+
+// ListPublications get the publications of the user and of the users it follows, newest first
+func (repository Publications) ListPublications(ctx context.Context, userID uint64) ([]models.Publication, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	return repository.list(func(publication *models.Publication) bool {
+		return publication.AuthorID == userID || repository.store.followers[follower{publication.AuthorID, userID}]
+	}, true), nil
+}
+
+// GetPublication get a publication by id
+func (repository Publications) GetPublication(ctx context.Context, publicationID uint64) (models.Publication, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.Publication{}, error
+	}
+
+	defer repository.store.unlock()
+
+	publication, found := repository.store.publications[publicationID]
+
+	if !found {
+		return models.Publication{}, nil
+	}
+
+	return repository.withAuthor(publication), nil
+}
+
+// ListAllPublications get every publication, for moderation
+func (repository Publications) ListAllPublications(ctx context.Context) ([]models.Publication, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	return repository.list(func(*models.Publication) bool { return true }, true), nil
+}
+
+// UpdatePublication
+func (repository Publications) UpdatePublication(ctx context.Context, publication models.Publication, publicationID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if stored, found := repository.store.publications[publicationID]; found {
+		stored.Title = publication.Title
+		stored.Content = publication.Content
+	}
+
+	return nil
+}
+
+// DeletePublication
+func (repository Publications) DeletePublication(ctx context.Context, publicationID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	delete(repository.store.publications, publicationID)
+
+	return nil
+}
+
+// ListUserPublications get the publications of an author, oldest first
+func (repository Publications) ListUserPublications(ctx context.Context, userID uint64) ([]models.Publication, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	return repository.list(func(publication *models.Publication) bool {
+		return publication.AuthorID == userID
+	}, false), nil
+}
+
+// LikePublication
+func (repository Publications) LikePublication(ctx context.Context, publicationID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if publication, found := repository.store.publications[publicationID]; found {
+		publication.Likes++
+	}
+
+	return nil
+}
+
+// UnLikePublication remove a like, the likes never go below zero
+func (repository Publications) UnLikePublication(ctx context.Context, publicationID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if publication, found := repository.store.publications[publicationID]; found && publication.Likes > 0 {
+		publication.Likes--
+	}
+
+	return nil
+}
+
+// list the publications matching the filter by id, newest first when descending
+func (repository Publications) list(filter func(*models.Publication) bool, descending bool) []models.Publication {
+	var publications []models.Publication
+
+	for _, publication := range repository.store.publications {
+		if filter(publication) {
+			publications = append(publications, repository.withAuthor(publication))
+		}
+	}
+
+	sort.Slice(publications, func(i, j int) bool {
+		if descending {
+			return publications[i].ID > publications[j].ID
+		}
+
+		return publications[i].ID < publications[j].ID
+	})
+
+	return publications
+}
+
+// withAuthor copy a publication along with the nick of its author
+func (repository Publications) withAuthor(publication *models.Publication) models.Publication {
+	copied := *publication
+
+	if author, found := repository.store.users[publication.AuthorID]; found {
+		copied.AuthorNick = author.Nick
+	}
+
+	return copied
+}
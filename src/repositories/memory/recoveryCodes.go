@@ -0,0 +1,75 @@
+package memory
+
+import "context"
+
+// recoveryCode is a row of the recovery_codes table
+type recoveryCode struct {
+	userID   uint64
+	codeHash string
+	used     bool
+}
+
+// RecoveryCodes is the in memory repository of two factor authentication recovery codes
+type RecoveryCodes struct {
+	store *Store
+}
+
+// Replace discard the codes of a user and store the hashes of new ones
+func (repository RecoveryCodes) Replace(ctx context.Context, userID uint64, codeHashes []string) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[userID]; !found && len(codeHashes) > 0 {
+		return errForeignKey
+	}
+
+	repository.deleteUser(userID)
+
+	for _, codeHash := range codeHashes {
+		repository.store.recoveryCodes[repository.store.nextID("recovery_codes")] = &recoveryCode{userID: userID, codeHash: codeHash}
+	}
+
+	return nil
+}
+
+// Use mark a code of the user as used, it returns false when the code does not exist or was used
+func (repository RecoveryCodes) Use(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, code := range repository.store.recoveryCodes {
+		if code.userID == userID && code.codeHash == codeHash && !code.used {
+			code.used = true
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// DeleteUser delete every code of a user
+func (repository RecoveryCodes) DeleteUser(ctx context.Context, userID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	repository.deleteUser(userID)
+
+	return nil
+}
+
+func (repository RecoveryCodes) deleteUser(userID uint64) {
+	for ID, code := range repository.store.recoveryCodes {
+		if code.userID == userID {
+			delete(repository.store.recoveryCodes, ID)
+		}
+	}
+}
@@ -0,0 +1,163 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"strings"
+	"time"
+)
+
+// refreshToken is a row of the refresh_tokens table
+type refreshToken struct {
+	models.RefreshToken
+	scope      string
+	restricted bool
+	revokedAt  *time.Time
+	replacedBy uint64
+}
+
+// RefreshTokens is the in memory repository of refresh tokens
+type RefreshTokens struct {
+	store *Store
+}
+
+// Create insert a new refresh token
+func (repository RefreshTokens) Create(ctx context.Context, token models.RefreshToken) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[token.UserID]; !found {
+		return 0, errForeignKey
+	}
+
+	for _, stored := range repository.store.refreshTokens {
+		if stored.TokenHash == token.TokenHash {
+			return 0, errDuplicate
+		}
+	}
+
+	row := &refreshToken{
+		RefreshToken: models.RefreshToken{
+			ID:                repository.store.nextID("refresh_tokens"),
+			UserID:            token.UserID,
+			FamilyID:          token.FamilyID,
+			ClientID:          token.ClientID,
+			TokenHash:         token.TokenHash,
+			ExpiresAt:         token.ExpiresAt,
+			AbsoluteExpiresAt: token.AbsoluteExpiresAt,
+			CreatedAt:         time.Now(),
+		},
+		scope:      strings.Join(token.Scopes, " "),
+		restricted: token.Scopes != nil,
+	}
+
+	repository.store.refreshTokens[row.ID] = row
+
+	return row.ID, nil
+}
+
+// GetByHash get a refresh token by its hash, an empty token is returned when it does not exist
+func (repository RefreshTokens) GetByHash(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.RefreshToken{}, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, row := range repository.store.refreshTokens {
+		if row.TokenHash != tokenHash {
+			continue
+		}
+
+		token := row.RefreshToken
+		token.Revoked = row.revokedAt != nil
+		token.Scopes = nil
+
+		if row.restricted {
+			token.Scopes = append([]string{}, strings.Fields(row.scope)...)
+		}
+
+		return token, nil
+	}
+
+	return models.RefreshToken{}, nil
+}
+
+// Consume revoke a refresh token that was not revoked yet, it returns false when
+// the token was already used, which means it is being replayed
+func (repository RefreshTokens) Consume(ctx context.Context, ID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.refreshTokens[ID]
+
+	if !found || row.revokedAt != nil {
+		return false, nil
+	}
+
+	revokedAt := time.Now()
+	row.revokedAt = &revokedAt
+
+	return true, nil
+}
+
+// SetReplacement register which token replaced a rotated one
+func (repository RefreshTokens) SetReplacement(ctx context.Context, ID, replacedBy uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.refreshTokens[ID]; found {
+		row.replacedBy = replacedBy
+	}
+
+	return nil
+}
+
+// RevokeFamily revoke every token descending from the same login
+func (repository RefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	return repository.revoke(ctx, func(row *refreshToken) bool {
+		return row.FamilyID == familyID
+	})
+}
+
+// RevokeUser revoke every refresh token of a user
+func (repository RefreshTokens) RevokeUser(ctx context.Context, userID uint64) error {
+	return repository.revoke(ctx, func(row *refreshToken) bool {
+		return row.UserID == userID
+	})
+}
+
+// RevokeClient revoke every refresh token issued to an oauth client
+func (repository RefreshTokens) RevokeClient(ctx context.Context, clientID string) error {
+	return repository.revoke(ctx, func(row *refreshToken) bool {
+		return row.ClientID == clientID
+	})
+}
+
+// revoke the tokens matching the filter that are not revoked yet
+func (repository RefreshTokens) revoke(ctx context.Context, filter func(*refreshToken) bool) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	revokedAt := time.Now()
+
+	for _, row := range repository.store.refreshTokens {
+		if row.revokedAt == nil && filter(row) {
+			row.revokedAt = &revokedAt
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"sort"
+	"time"
+)
+
+// Sessions is the in memory repository of login sessions
+type Sessions struct {
+	store *Store
+}
+
+// Create insert a new session
+func (repository Sessions) Create(ctx context.Context, session models.Session) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if _, found := repository.store.users[session.UserID]; !found {
+		return 0, errForeignKey
+	}
+
+	for _, stored := range repository.store.sessions {
+		if stored.FamilyID == session.FamilyID {
+			return 0, errDuplicate
+		}
+	}
+
+	now := time.Now()
+
+	row := &models.Session{
+		ID:         repository.store.nextID("sessions"),
+		UserID:     session.UserID,
+		FamilyID:   session.FamilyID,
+		ClientID:   session.ClientID,
+		UserAgent:  session.UserAgent,
+		IP:         session.IP,
+		ExpiresAt:  session.ExpiresAt,
+		LastSeenAt: now,
+		CreatedAt:  now,
+	}
+
+	repository.store.sessions[row.ID] = row
+
+	return row.ID, nil
+}
+
+// Get get a session by its id, an empty session is returned when it does not exist
+func (repository Sessions) Get(ctx context.Context, ID uint64) (models.Session, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.Session{}, error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.sessions[ID]; found {
+		return *row, nil
+	}
+
+	return models.Session{}, nil
+}
+
+// GetByFamily get the session of a family of refresh tokens, an empty session is
+// returned when there is none
+func (repository Sessions) GetByFamily(ctx context.Context, familyID string) (models.Session, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.Session{}, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, row := range repository.store.sessions {
+		if row.FamilyID == familyID {
+			return *row, nil
+		}
+	}
+
+	return models.Session{}, nil
+}
+
+// ListActive get the sessions of a user which can still be refreshed, most recently seen first
+func (repository Sessions) ListActive(ctx context.Context, userID uint64) ([]models.Session, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	now := time.Now()
+
+	var sessions []models.Session
+
+	for _, row := range repository.store.sessions {
+		if row.UserID == userID && !row.Revoked && row.ExpiresAt.After(now) && repository.refreshable(row.FamilyID, now) {
+			sessions = append(sessions, *row)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+
+	return sessions, nil
+}
+
+// Seen register the last token issued in a session
+func (repository Sessions) Seen(ctx context.Context, ID uint64, tokenID string) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.sessions[ID]; found {
+		row.TokenID = tokenID
+		row.LastSeenAt = time.Now()
+	}
+
+	return nil
+}
+
+// Active register a session was just used, unless it was already seen after since
+func (repository Sessions) Active(ctx context.Context, ID uint64, since time.Time) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.sessions[ID]; found && row.LastSeenAt.Before(since) {
+		row.LastSeenAt = time.Now()
+	}
+
+	return nil
+}
+
+// Revoke mark a session as revoked, it returns false when it was already revoked
+func (repository Sessions) Revoke(ctx context.Context, ID uint64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.sessions[ID]
+
+	if !found || row.Revoked {
+		return false, nil
+	}
+
+	row.Revoked = true
+
+	return true, nil
+}
+
+// refreshable report if a family still has a refresh token that can be used
+func (repository Sessions) refreshable(familyID string, now time.Time) bool {
+	for _, token := range repository.store.refreshTokens {
+		if token.FamilyID == familyID && token.revokedAt == nil && token.ExpiresAt.After(now) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,237 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// errDuplicate is returned where the database would break a unique key
+	errDuplicate = errors.New("Duplicate entry")
+	// errForeignKey is returned where the database would break a foreign key
+	errForeignKey = errors.New("Foreign key constraint fails")
+)
+
+// Store keeps every table in memory behind a single lock, so its repositories join and
+// cascade deletes the way the database does. It is meant for tests
+type Store struct {
+	mutex sync.Mutex
+	// transaction is held by the unit of work running, one at a time
+	transaction sync.Mutex
+
+	lastIDs map[string]uint64
+
+	users              map[uint64]*user
+	followers          map[follower]bool
+	publications       map[uint64]*models.Publication
+	refreshTokens      map[uint64]*refreshToken
+	sessions           map[uint64]*models.Session
+	apiKeys            map[uint64]*apiKey
+	emailVerifications map[uint64]*models.OneTimeToken
+	loginAttempts      []models.LoginAttempt
+	magicLinks         map[string]*magicLink
+	oauthClients       map[uint64]*models.OAuthClient
+	oauthCodes         map[uint64]*models.OAuthCode
+	passwordResets     map[uint64]*models.OneTimeToken
+	recoveryCodes      map[uint64]*recoveryCode
+}
+
+// NewStore returns an empty store
+func NewStore() *Store {
+	return &Store{
+		lastIDs:            map[string]uint64{},
+		users:              map[uint64]*user{},
+		followers:          map[follower]bool{},
+		publications:       map[uint64]*models.Publication{},
+		refreshTokens:      map[uint64]*refreshToken{},
+		sessions:           map[uint64]*models.Session{},
+		apiKeys:            map[uint64]*apiKey{},
+		emailVerifications: map[uint64]*models.OneTimeToken{},
+		magicLinks:         map[string]*magicLink{},
+		oauthClients:       map[uint64]*models.OAuthClient{},
+		oauthCodes:         map[uint64]*models.OAuthCode{},
+		passwordResets:     map[uint64]*models.OneTimeToken{},
+		recoveryCodes:      map[uint64]*recoveryCode{},
+	}
+}
+
+// lock take the store for a repository call, failing like a query would when the context is done
+func (store *Store) lock(ctx context.Context) error {
+	if error := ctx.Err(); error != nil {
+		return error
+	}
+
+	store.mutex.Lock()
+
+	return nil
+}
+
+func (store *Store) unlock() {
+	store.mutex.Unlock()
+}
+
+// nextID returns the next auto increment id of a table
+func (store *Store) nextID(table string) uint64 {
+	store.lastIDs[table]++
+
+	return store.lastIDs[table]
+}
+
+// deleteUser remove a user with every row that references it
+func (store *Store) deleteUser(ID uint64) {
+	delete(store.users, ID)
+
+	for relation := range store.followers {
+		if relation.userID == ID || relation.followerID == ID {
+			delete(store.followers, relation)
+		}
+	}
+
+	for publicationID, publication := range store.publications {
+		if publication.AuthorID == ID {
+			delete(store.publications, publicationID)
+		}
+	}
+
+	for tokenID, token := range store.refreshTokens {
+		if token.UserID == ID {
+			delete(store.refreshTokens, tokenID)
+		}
+	}
+
+	for sessionID, session := range store.sessions {
+		if session.UserID == ID {
+			delete(store.sessions, sessionID)
+		}
+	}
+
+	for apiKeyID, apiKey := range store.apiKeys {
+		if apiKey.UserID == ID {
+			delete(store.apiKeys, apiKeyID)
+		}
+	}
+
+	for tokenID, token := range store.emailVerifications {
+		if token.UserID == ID {
+			delete(store.emailVerifications, tokenID)
+		}
+	}
+
+	for tokenID, token := range store.passwordResets {
+		if token.UserID == ID {
+			delete(store.passwordResets, tokenID)
+		}
+	}
+
+	for codeID, code := range store.recoveryCodes {
+		if code.userID == ID {
+			delete(store.recoveryCodes, codeID)
+		}
+	}
+
+	for tokenID, link := range store.magicLinks {
+		if link.userID == ID {
+			delete(store.magicLinks, tokenID)
+		}
+	}
+
+	for codeID, code := range store.oauthCodes {
+		if code.UserID == ID {
+			delete(store.oauthCodes, codeID)
+		}
+	}
+
+	for clientID, client := range store.oauthClients {
+		if client.OwnerID == ID {
+			store.deleteOAuthClient(clientID)
+		}
+	}
+}
+
+// deleteOAuthClient remove a client with its authorization codes
+func (store *Store) deleteOAuthClient(ID uint64) {
+	client := store.oauthClients[ID]
+
+	delete(store.oauthClients, ID)
+
+	for codeID, code := range store.oauthCodes {
+		if code.ClientID == client.ClientID {
+			delete(store.oauthCodes, codeID)
+		}
+	}
+}
+
+// nullableTime copy a nullable time, so callers cannot change the stored one
+func nullableTime(value *time.Time) *time.Time {
+	if value == nil {
+		return nil
+	}
+
+	copied := *value
+
+	return &copied
+}
+
+// Users returns the user repository
+func (store *Store) Users() repositories.UserRepository {
+	return &Users{store}
+}
+
+// Publications returns the publication repository
+func (store *Store) Publications() repositories.PublicationRepository {
+	return &Publications{store}
+}
+
+// RefreshTokens returns the refresh token repository
+func (store *Store) RefreshTokens() repositories.RefreshTokenRepository {
+	return &RefreshTokens{store}
+}
+
+// Sessions returns the session repository
+func (store *Store) Sessions() repositories.SessionRepository {
+	return &Sessions{store}
+}
+
+// APIKeys returns the api key repository
+func (store *Store) APIKeys() repositories.APIKeyRepository {
+	return &APIKeys{store}
+}
+
+// EmailVerifications returns the email verification repository
+func (store *Store) EmailVerifications() repositories.EmailVerificationRepository {
+	return &EmailVerifications{store}
+}
+
+// LoginAttempts returns the login attempt repository
+func (store *Store) LoginAttempts() repositories.LoginAttemptRepository {
+	return &LoginAttempts{store}
+}
+
+// MagicLinks returns the magic link repository
+func (store *Store) MagicLinks() repositories.MagicLinkRepository {
+	return &MagicLinks{store}
+}
+
+// OAuthClients returns the oauth client repository
+func (store *Store) OAuthClients() repositories.OAuthClientRepository {
+	return &OAuthClients{store}
+}
+
+// OAuthCodes returns the oauth authorization code repository
+func (store *Store) OAuthCodes() repositories.OAuthCodeRepository {
+	return &OAuthCodes{store}
+}
+
+// PasswordResets returns the password reset repository
+func (store *Store) PasswordResets() repositories.PasswordResetRepository {
+	return &PasswordResets{store}
+}
+
+// RecoveryCodes returns the recovery code repository
+func (store *Store) RecoveryCodes() repositories.RecoveryCodeRepository {
+	return &RecoveryCodes{store}
+}
@@ -0,0 +1,14 @@
+package memory_test
+
+import (
+	"api/src/repositories"
+	"api/src/repositories/conformance"
+	"api/src/repositories/memory"
+	"testing"
+)
+
+func TestMemoryConformance(t *testing.T) {
+	conformance.Run(t, func(t *testing.T) repositories.Store {
+		return memory.NewStore()
+	})
+}
@@ -0,0 +1,155 @@
+package memory
+
+import (
+	"api/src/models"
+	"api/src/repositories"
+	"context"
+)
+
+// tables is a copy of the rows of the store
+type tables struct {
+	users              map[uint64]*user
+	followers          map[follower]bool
+	publications       map[uint64]*models.Publication
+	refreshTokens      map[uint64]*refreshToken
+	sessions           map[uint64]*models.Session
+	apiKeys            map[uint64]*apiKey
+	emailVerifications map[uint64]*models.OneTimeToken
+	loginAttempts      []models.LoginAttempt
+	magicLinks         map[string]*magicLink
+	oauthClients       map[uint64]*models.OAuthClient
+	oauthCodes         map[uint64]*models.OAuthCode
+	passwordResets     map[uint64]*models.OneTimeToken
+	recoveryCodes      map[uint64]*recoveryCode
+}
+
+// WithTx run the work on the store, restoring the rows it had when the work fails or panics.
+// Units of work run one at a time, but the calls made outside of them are not isolated
+func (store *Store) WithTx(ctx context.Context, work func(repositories.UnitOfWork) error) error {
+	if error := ctx.Err(); error != nil {
+		return error
+	}
+
+	store.transaction.Lock()
+	defer store.transaction.Unlock()
+
+	store.mutex.Lock()
+	saved := store.save()
+	store.mutex.Unlock()
+
+	committed := false
+
+	defer func() {
+		if !committed {
+			store.mutex.Lock()
+			store.restore(saved)
+			store.mutex.Unlock()
+		}
+	}()
+
+	if error := work(store); error != nil {
+		return error
+	}
+
+	committed = true
+
+	return nil
+}
+
+// save copy the rows of every table, ids are not saved so they are never reused like the
+// auto increments of the database
+func (store *Store) save() tables {
+	saved := tables{
+		users:              map[uint64]*user{},
+		followers:          map[follower]bool{},
+		publications:       map[uint64]*models.Publication{},
+		refreshTokens:      map[uint64]*refreshToken{},
+		sessions:           map[uint64]*models.Session{},
+		apiKeys:            map[uint64]*apiKey{},
+		emailVerifications: map[uint64]*models.OneTimeToken{},
+		loginAttempts:      append([]models.LoginAttempt(nil), store.loginAttempts...),
+		magicLinks:         map[string]*magicLink{},
+		oauthClients:       map[uint64]*models.OAuthClient{},
+		oauthCodes:         map[uint64]*models.OAuthCode{},
+		passwordResets:     map[uint64]*models.OneTimeToken{},
+		recoveryCodes:      map[uint64]*recoveryCode{},
+	}
+
+	for ID, row := range store.users {
+		copied := *row
+		saved.users[ID] = &copied
+	}
+
+	for relation, following := range store.followers {
+		saved.followers[relation] = following
+	}
+
+	for ID, row := range store.publications {
+		copied := *row
+		saved.publications[ID] = &copied
+	}
+
+	for ID, row := range store.refreshTokens {
+		copied := *row
+		saved.refreshTokens[ID] = &copied
+	}
+
+	for ID, row := range store.sessions {
+		copied := *row
+		saved.sessions[ID] = &copied
+	}
+
+	for ID, row := range store.apiKeys {
+		copied := *row
+		saved.apiKeys[ID] = &copied
+	}
+
+	for ID, row := range store.emailVerifications {
+		copied := *row
+		saved.emailVerifications[ID] = &copied
+	}
+
+	for tokenID, row := range store.magicLinks {
+		copied := *row
+		saved.magicLinks[tokenID] = &copied
+	}
+
+	for ID, row := range store.oauthClients {
+		copied := *row
+		saved.oauthClients[ID] = &copied
+	}
+
+	for ID, row := range store.oauthCodes {
+		copied := *row
+		saved.oauthCodes[ID] = &copied
+	}
+
+	for ID, row := range store.passwordResets {
+		copied := *row
+		saved.passwordResets[ID] = &copied
+	}
+
+	for ID, row := range store.recoveryCodes {
+		copied := *row
+		saved.recoveryCodes[ID] = &copied
+	}
+
+	return saved
+}
+
+// restore bring back the rows saved
+func (store *Store) restore(saved tables) {
+	store.users = saved.users
+	store.followers = saved.followers
+	store.publications = saved.publications
+	store.refreshTokens = saved.refreshTokens
+	store.sessions = saved.sessions
+	store.apiKeys = saved.apiKeys
+	store.emailVerifications = saved.emailVerifications
+	store.loginAttempts = saved.loginAttempts
+	store.magicLinks = saved.magicLinks
+	store.oauthClients = saved.oauthClients
+	store.oauthCodes = saved.oauthCodes
+	store.passwordResets = saved.passwordResets
+	store.recoveryCodes = saved.recoveryCodes
+}
@@ -0,0 +1,500 @@
+package memory
+
+import (
+	"api/src/models"
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// user is a row of the users table
+type user struct {
+	models.User
+	roles        string
+	totpSecret   string
+	totpEnabled  bool
+	totpLastStep int64
+}
+
+// follower is a row of the followers table
+type follower struct {
+	userID     uint64
+	followerID uint64
+}
+
+// Users is the in memory repository of users
+type Users struct {
+	store *Store
+}
+
+// Create insert a new user, emails are unique regardless of case like in the database
+func (repository Users) Create(ctx context.Context, newUser models.User) (uint64, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return 0, error
+	}
+
+	defer repository.store.unlock()
+
+	if repository.emailTaken(newUser.Email, 0) {
+		return 0, errDuplicate
+	}
+
+	row := &user{User: models.User{
+		ID:        repository.store.nextID("users"),
+		Name:      newUser.Name,
+		Nick:      newUser.Nick,
+		Email:     newUser.Email,
+		Password:  newUser.Password,
+		CreatedAt: time.Now(),
+	}, roles: "user"}
+
+	repository.store.users[row.ID] = row
+
+	return row.ID, nil
+}
+
+// Search find the users whose name or nick contain the query, ignoring case
+func (repository Users) Search(ctx context.Context, userQuery string) ([]models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	pattern := like(fmt.Sprintf("%%%s%%", userQuery))
+
+	var users []models.User
+
+	for _, row := range repository.sorted() {
+		if pattern.MatchString(row.Name) || pattern.MatchString(row.Nick) {
+			users = append(users, row.public())
+		}
+	}
+
+	return users, nil
+}
+
+// Get get a user by id
+func (repository Users) Get(ctx context.Context, ID uint64) (models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.User{}, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found {
+		return models.User{}, nil
+	}
+
+	return row.public(), nil
+}
+
+// Update update a user, its email stops being verified when it changes
+func (repository Users) Update(ctx context.Context, ID uint64, changes models.User) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found {
+		return nil
+	}
+
+	if repository.emailTaken(changes.Email, ID) {
+		return errDuplicate
+	}
+
+	if !strings.EqualFold(row.Email, changes.Email) {
+		row.VerifiedAt = nil
+	}
+
+	row.Name = changes.Name
+	row.Nick = changes.Nick
+	row.Email = changes.Email
+
+	return nil
+}
+
+// Delete delete a user along with everything that belongs to it
+func (repository Users) Delete(ctx context.Context, ID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	repository.store.deleteUser(ID)
+
+	return nil
+}
+
+// SearchByEmail get a user by email
+func (repository Users) SearchByEmail(ctx context.Context, email string) (models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.User{}, error
+	}
+
+	defer repository.store.unlock()
+
+	for _, row := range repository.store.users {
+		if strings.EqualFold(row.Email, email) {
+			return models.User{
+				ID:          row.ID,
+				Password:    row.Password,
+				Roles:       splitRoles(row.roles),
+				SuspendedAt: nullableTime(row.SuspendedAt),
+			}, nil
+		}
+	}
+
+	return models.User{}, nil
+}
+
+// GetAccount get the fields that control the access of a user: email, roles, suspension and verification
+func (repository Users) GetAccount(ctx context.Context, ID uint64) (models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.User{}, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found {
+		return models.User{}, nil
+	}
+
+	return models.User{
+		ID:          row.ID,
+		Email:       row.Email,
+		Roles:       splitRoles(row.roles),
+		SuspendedAt: nullableTime(row.SuspendedAt),
+		VerifiedAt:  nullableTime(row.VerifiedAt),
+	}, nil
+}
+
+// MarkVerified register the email of a user as verified, it returns false when the
+// user no longer has that email
+func (repository Users) MarkVerified(ctx context.Context, ID uint64, email string) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found || !strings.EqualFold(row.Email, email) {
+		return false, nil
+	}
+
+	verifiedAt := time.Now()
+	row.VerifiedAt = &verifiedAt
+
+	return true, nil
+}
+
+// List get every user with its roles and suspension, for administration
+func (repository Users) List(ctx context.Context) ([]models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	var users []models.User
+
+	for _, row := range repository.sorted() {
+		listed := row.public()
+		listed.Roles = splitRoles(row.roles)
+		listed.SuspendedAt = nullableTime(row.SuspendedAt)
+
+		users = append(users, listed)
+	}
+
+	return users, nil
+}
+
+// UpdateRoles replace the roles of a user
+func (repository Users) UpdateRoles(ctx context.Context, ID uint64, roles []string) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.users[ID]; found {
+		row.roles = strings.Join(roles, ",")
+	}
+
+	return nil
+}
+
+// Suspend block or unblock the access of a user
+func (repository Users) Suspend(ctx context.Context, ID uint64, suspended bool) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found {
+		return nil
+	}
+
+	row.SuspendedAt = nil
+
+	if suspended {
+		suspendedAt := time.Now()
+		row.SuspendedAt = &suspendedAt
+	}
+
+	return nil
+}
+
+// Follow register the follower of a user, following twice or following a missing user is ignored
+// like the insert ignore of the database does
+func (repository Users) Follow(ctx context.Context, userID, followerID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	_, userFound := repository.store.users[userID]
+	_, followerFound := repository.store.users[followerID]
+
+	if userFound && followerFound {
+		repository.store.followers[follower{userID, followerID}] = true
+	}
+
+	return nil
+}
+
+// UnFollow permits unfollow a user
+func (repository Users) UnFollow(ctx context.Context, userID, followerID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	delete(repository.store.followers, follower{userID, followerID})
+
+	return nil
+}
+
+// GetFollowers get the users following a user
+func (repository Users) GetFollowers(ctx context.Context, userID uint64) ([]models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	var users []models.User
+
+	for _, row := range repository.sorted() {
+		if repository.store.followers[follower{userID, row.ID}] {
+			users = append(users, row.public())
+		}
+	}
+
+	return users, nil
+}
+
+// GetFollowing get the users a user follows
+func (repository Users) GetFollowing(ctx context.Context, userID uint64) ([]models.User, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return nil, error
+	}
+
+	defer repository.store.unlock()
+
+	var users []models.User
+
+	for _, row := range repository.sorted() {
+		if repository.store.followers[follower{row.ID, userID}] {
+			users = append(users, row.public())
+		}
+	}
+
+	return users, nil
+}
+
+// GetPassword get password of a user
+func (repository Users) GetPassword(ctx context.Context, userID uint64) (string, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return "", error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.users[userID]; found {
+		return row.Password, nil
+	}
+
+	return "", nil
+}
+
+// UpdatePassword update a user password
+func (repository Users) UpdatePassword(ctx context.Context, password string, userID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.users[userID]; found {
+		row.Password = password
+	}
+
+	return nil
+}
+
+// GetTOTP get the two factor authentication setup of a user
+func (repository Users) GetTOTP(ctx context.Context, ID uint64) (models.TOTP, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return models.TOTP{}, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found {
+		return models.TOTP{}, nil
+	}
+
+	return models.TOTP{Secret: row.totpSecret, Enabled: row.totpEnabled, LastStep: row.totpLastStep}, nil
+}
+
+// SetTOTPSecret store a secret pending confirmation, an empty secret disables two factor authentication
+func (repository Users) SetTOTPSecret(ctx context.Context, ID uint64, secret string) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.users[ID]; found {
+		row.totpSecret = secret
+		row.totpEnabled = false
+		row.totpLastStep = 0
+	}
+
+	return nil
+}
+
+// EnableTOTP start requiring codes of the stored secret on login
+func (repository Users) EnableTOTP(ctx context.Context, ID uint64) error {
+	if error := repository.store.lock(ctx); error != nil {
+		return error
+	}
+
+	defer repository.store.unlock()
+
+	if row, found := repository.store.users[ID]; found && row.totpSecret != "" {
+		row.totpEnabled = true
+	}
+
+	return nil
+}
+
+// UseTOTPStep register the period of an accepted code, it returns false when a code
+// of that period or a later one was already used
+func (repository Users) UseTOTPStep(ctx context.Context, ID uint64, step int64) (bool, error) {
+	if error := repository.store.lock(ctx); error != nil {
+		return false, error
+	}
+
+	defer repository.store.unlock()
+
+	row, found := repository.store.users[ID]
+
+	if !found || row.totpLastStep >= step {
+		return false, nil
+	}
+
+	row.totpLastStep = step
+
+	return true, nil
+}
+
+// emailTaken report if another user than the informed one has the email
+func (repository Users) emailTaken(email string, ID uint64) bool {
+	for _, row := range repository.store.users {
+		if row.ID != ID && strings.EqualFold(row.Email, email) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sorted returns the users by id
+func (repository Users) sorted() []*user {
+	rows := make([]*user, 0, len(repository.store.users))
+
+	for _, row := range repository.store.users {
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ID < rows[j].ID
+	})
+
+	return rows
+}
+
+// public returns the columns of the user shown to everyone
+func (row *user) public() models.User {
+	return models.User{
+		ID:        row.ID,
+		Name:      row.Name,
+		Nick:      row.Nick,
+		Email:     row.Email,
+		CreatedAt: row.CreatedAt,
+	}
+}
+
+// splitRoles decode the comma separated roles
+func splitRoles(roles string) []string {
+	if roles == "" {
+		return []string{}
+	}
+
+	return strings.Split(roles, ",")
+}
+
+// like compile a pattern of the LIKE operator, which ignores case in the database collation
+func like(pattern string) *regexp.Regexp {
+	var expression strings.Builder
+
+	expression.WriteString("(?is)^")
+
+	for _, character := range pattern {
+		switch character {
+		case '%':
+			expression.WriteString(".*")
+		case '_':
+			expression.WriteString(".")
+		default:
+			expression.WriteString(regexp.QuoteMeta(string(character)))
+		}
+	}
+
+	expression.WriteString("$")
+
+	return regexp.MustCompile(expression.String())
+}
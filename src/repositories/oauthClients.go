@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// OAuthClients represents a repository of oauth clients
+type OAuthClients struct {
+	db *DB
+}
+
+// NewOAuthClientRepository returns a new oauth client repository
+func NewOAuthClientRepository(db *DB) *OAuthClients {
+	return &OAuthClients{db}
+}
+
+// Create insert a new oauth client
+func (repository OAuthClients) Create(ctx context.Context, client models.OAuthClient) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx,
+		"insert into oauth_clients (client_id, secret_hash, name, redirect_uris, scopes, owner_id) values (?, ?, ?, ?, ?, ?)",
+		client.ClientID,
+		sql.NullString{String: client.SecretHash, Valid: client.SecretHash != ""},
+		client.Name,
+		strings.Join(client.RedirectURIs, " "),
+		strings.Join(client.Scopes, " "),
+		client.OwnerID,
+	)
+}
+
+// GetByClientID get a client by its public id, an empty client is returned when it does not exist
+func (repository OAuthClients) GetByClientID(ctx context.Context, clientID string) (models.OAuthClient, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT id, client_id, secret_hash, name, redirect_uris, scopes, owner_id, createdAt
+	FROM oauth_clients WHERE client_id = ?`,
+		clientID)
+
+	if error != nil {
+		return models.OAuthClient{}, error
+	}
+
+	defer lines.Close()
+
+	clients, error := scanOAuthClients(lines)
+
+	if error != nil || len(clients) == 0 {
+		return models.OAuthClient{}, error
+	}
+
+	return clients[0], nil
+}
+
+// ListByOwner get the clients registered by a user
+func (repository OAuthClients) ListByOwner(ctx context.Context, ownerID uint64) ([]models.OAuthClient, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT id, client_id, secret_hash, name, redirect_uris, scopes, owner_id, createdAt
+	FROM oauth_clients WHERE owner_id = ? ORDER BY id`,
+		ownerID)
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer lines.Close()
+
+	return scanOAuthClients(lines)
+}
+
+// Delete delete a client of a user, it returns false when the user has no such client
+func (repository OAuthClients) Delete(ctx context.Context, clientID string, ownerID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"DELETE FROM oauth_clients WHERE client_id = ? AND owner_id = ?",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, clientID, ownerID)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+func scanOAuthClients(lines *sql.Rows) ([]models.OAuthClient, error) {
+	var clients []models.OAuthClient
+
+	for lines.Next() {
+		var (
+			client       models.OAuthClient
+			secretHash   sql.NullString
+			redirectURIs string
+			scopes       string
+		)
+
+		if error := lines.Scan(
+			&client.ID,
+			&client.ClientID,
+			&secretHash,
+			&client.Name,
+			&redirectURIs,
+			&scopes,
+			&client.OwnerID,
+			&client.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+
+		client.SecretHash = secretHash.String
+		client.Confidential = secretHash.Valid
+		client.RedirectURIs = strings.Fields(redirectURIs)
+		client.Scopes = strings.Fields(scopes)
+
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
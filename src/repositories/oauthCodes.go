@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// OAuthCodes represents a repository of oauth authorization codes
+type OAuthCodes struct {
+	db *DB
+}
+
+// NewOAuthCodeRepository returns a new oauth authorization code repository
+func NewOAuthCodeRepository(db *DB) *OAuthCodes {
+	return &OAuthCodes{db}
+}
+
+// Create insert a new authorization code
+func (repository OAuthCodes) Create(ctx context.Context, code models.OAuthCode) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx, `
+	insert into oauth_codes (code_hash, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method, expires_at)
+	values (?, ?, ?, ?, ?, ?, ?, ?)`,
+		code.CodeHash,
+		code.ClientID,
+		code.UserID,
+		code.RedirectURI,
+		strings.Join(code.Scopes, " "),
+		code.CodeChallenge,
+		code.CodeChallengeMethod,
+		code.ExpiresAt,
+	)
+}
+
+// GetByHash get an authorization code by its hash, an empty code is returned when it does not exist
+func (repository OAuthCodes) GetByHash(ctx context.Context, codeHash string) (models.OAuthCode, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, `
+	SELECT id, code_hash, client_id, user_id, redirect_uri, scope, code_challenge, code_challenge_method,
+	expires_at, used_at IS NOT NULL, family_id, createdAt
+	FROM oauth_codes WHERE code_hash = ?`,
+		codeHash)
+
+	if error != nil {
+		return models.OAuthCode{}, error
+	}
+
+	defer line.Close()
+
+	var (
+		code     models.OAuthCode
+		scope    string
+		familyID sql.NullString
+	)
+
+	if line.Next() {
+		if error = line.Scan(
+			&code.ID,
+			&code.CodeHash,
+			&code.ClientID,
+			&code.UserID,
+			&code.RedirectURI,
+			&scope,
+			&code.CodeChallenge,
+			&code.CodeChallengeMethod,
+			&code.ExpiresAt,
+			&code.Used,
+			&familyID,
+			&code.CreatedAt,
+		); error != nil {
+			return models.OAuthCode{}, error
+		}
+	}
+
+	code.Scopes = strings.Fields(scope)
+	code.FamilyID = familyID.String
+
+	return code, nil
+}
+
+// Use mark a code as used, it returns false when the code was already used
+func (repository OAuthCodes) Use(ctx context.Context, ID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE oauth_codes SET used_at = ? WHERE id = ? AND used_at IS NULL",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, time.Now(), ID)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// SetFamily register the refresh token family issued in exchange for a code
+func (repository OAuthCodes) SetFamily(ctx context.Context, ID uint64, familyID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE oauth_codes SET family_id = ? WHERE id = ?",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, familyID, ID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// PasswordResets represents a repository of password reset tokens
+type PasswordResets struct {
+	db *DB
+}
+
+// NewPasswordResetRepository returns a new password reset repository
+func NewPasswordResetRepository(db *DB) *PasswordResets {
+	return &PasswordResets{db}
+}
+
+// Create insert a new password reset token
+func (repository PasswordResets) Create(ctx context.Context, token models.OneTimeToken) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx,
+		"insert into password_resets (user_id, token_hash, expires_at) values (?, ?, ?)",
+		token.UserID, token.TokenHash, token.ExpiresAt)
+}
+
+// GetByHash get a password reset token by its hash, an empty token is returned when it does not exist
+func (repository PasswordResets) GetByHash(ctx context.Context, tokenHash string) (models.OneTimeToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, token_hash, expires_at, used_at IS NOT NULL, createdAt
+	FROM password_resets WHERE token_hash = ?`,
+		tokenHash)
+
+	if error != nil {
+		return models.OneTimeToken{}, error
+	}
+
+	defer line.Close()
+
+	var token models.OneTimeToken
+
+	if line.Next() {
+		if error = line.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.Used,
+			&token.CreatedAt,
+		); error != nil {
+			return models.OneTimeToken{}, error
+		}
+	}
+
+	return token, nil
+}
+
+// Use mark a valid token as used, it returns false when the token was already used or expired
+func (repository PasswordResets) Use(ctx context.Context, ID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE password_resets SET used_at = ? WHERE id = ? AND used_at IS NULL AND expires_at > ?",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	now := time.Now()
+
+	result, error := statement.ExecContext(ctx, now, ID, now)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// InvalidateUser mark every pending token of a user as used
+func (repository PasswordResets) InvalidateUser(ctx context.Context, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE password_resets SET used_at = ? WHERE user_id = ? AND used_at IS NULL",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, time.Now(), userID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -2,51 +2,36 @@ package repositories
 
 import (
 	"api/src/models"
-	"database/sql"
+	"context"
 )
 
 // Publications represents a repository of publications
 type Publications struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewPublicationRepository returns a new publication repository
-func NewPublicationRepository(db *sql.DB) *Publications {
+func NewPublicationRepository(db *DB) *Publications {
 	return &Publications{db}
 }
 
 // CreatePublication
-func (repository Publications) CreatePublication(publication models.Publication) (uint64, error) {
-	statement, error := repository.db.Prepare("insert into publications (title, content, author_id) values (?, ?, ?)")
+func (repository Publications) CreatePublication(ctx context.Context, publication models.Publication) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	if error != nil {
-		return 0, error
-	}
-
-	defer statement.Close()
-
-	result, error := statement.Exec(publication.Title, publication.Content, publication.AuthorID)
-
-	if error != nil {
-		return 0, error
-	}
-
-	lastInsertedId, error := result.LastInsertId()
-
-	if error != nil {
-		return 0, error
-	}
-
-	return uint64(lastInsertedId), nil
+	return repository.db.insert(ctx, "insert into publications (title, content, author_id) values (?, ?, ?)", publication.Title, publication.Content, publication.AuthorID)
 }
 
-// ListPublications
-func (repository Publications) ListPublications(userID uint64) ([]models.Publication, error) {
+// ListPublications get the publications of the user and of the users it follows, newest first
+func (repository Publications) ListPublications(ctx context.Context, userID uint64) ([]models.Publication, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	lines, error := repository.db.Query(`
-	SELECT distinct p.*, u.nick from publications p 
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT distinct p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.nick from publications p 
 	inner join users u on u.id = p.author_id 
-	inner join followers f on p.author_id = f.user_id 
+	left join followers f on p.author_id = f.user_id 
 	where u.id = ? or f.follower_id = ?
 	order by 1 desc`,
 		userID, userID,
@@ -82,9 +67,12 @@ func (repository Publications) ListPublications(userID uint64) ([]models.Publica
 }
 
 // GetPublication
-func (repository Publications) GetPublication(publicationID uint64) (models.Publication, error) {
-	line, error := repository.db.Query(
-		`SELECT p.*, u.nick from 
+func (repository Publications) GetPublication(ctx context.Context, publicationID uint64) (models.Publication, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx,
+		`SELECT p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.nick from 
 		publications p inner join users u
 		on u.id = p.author_id where p.id = ?`,
 		publicationID)
@@ -114,10 +102,51 @@ func (repository Publications) GetPublication(publicationID uint64) (models.Publ
 	return publication, nil
 }
 
+// ListAllPublications get every publication, for moderation
+func (repository Publications) ListAllPublications(ctx context.Context) ([]models.Publication, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx,
+		`select p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.nick from publications p 
+		join users u on u.id = p.author_id 
+		order by 1 desc`)
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer lines.Close()
+
+	var publications []models.Publication
+
+	for lines.Next() {
+		var publication models.Publication
+
+		if error = lines.Scan(
+			&publication.ID,
+			&publication.Title,
+			&publication.Content,
+			&publication.AuthorID,
+			&publication.Likes,
+			&publication.CreatedAt,
+			&publication.AuthorNick,
+		); error != nil {
+			return nil, error
+		}
+
+		publications = append(publications, publication)
+	}
+
+	return publications, nil
+}
+
 // UpdatePublication
-func (repository Publications) UpdatePublication(publication models.Publication, publicationID uint64) error {
+func (repository Publications) UpdatePublication(ctx context.Context, publication models.Publication, publicationID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, error := repository.db.Prepare("update publications set title = ?, content = ? where id = ?")
+	statement, error := repository.db.PrepareContext(ctx, "update publications set title = ?, content = ? where id = ?")
 
 	if error != nil {
 		return error
@@ -125,7 +154,7 @@ func (repository Publications) UpdatePublication(publication models.Publication,
 
 	defer statement.Close()
 
-	if _, error := statement.Exec(publication.Title, publication.Content, publicationID); error != nil {
+	if _, error := statement.ExecContext(ctx, publication.Title, publication.Content, publicationID); error != nil {
 		return error
 	}
 
@@ -133,8 +162,11 @@ func (repository Publications) UpdatePublication(publication models.Publication,
 }
 
 // DeletePublication
-func (repository Publications) DeletePublication(publicationID uint64) error {
-	statement, error := repository.db.Prepare("DELETE FROM publications WHERE id = ?")
+func (repository Publications) DeletePublication(ctx context.Context, publicationID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "DELETE FROM publications WHERE id = ?")
 
 	if error != nil {
 		return error
@@ -142,7 +174,7 @@ func (repository Publications) DeletePublication(publicationID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(publicationID); error != nil {
+	if _, error = statement.ExecContext(ctx, publicationID); error != nil {
 		return error
 	}
 
@@ -150,9 +182,12 @@ func (repository Publications) DeletePublication(publicationID uint64) error {
 }
 
 // ListUserPublications
-func (repository Publications) ListUserPublications(userID uint64) ([]models.Publication, error) {
-	lines, error := repository.db.Query(
-		`select p.*, u.nick from publications p 
+func (repository Publications) ListUserPublications(ctx context.Context, userID uint64) ([]models.Publication, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx,
+		`select p.id, p.title, p.content, p.author_id, p.likes, p.createdAt, u.nick from publications p 
 		join users u on u.id = p.author_id 
 		where p.author_id = ?`,
 		userID)
@@ -188,8 +223,11 @@ func (repository Publications) ListUserPublications(userID uint64) ([]models.Pub
 }
 
 // LikePublication
-func (repository Publications) LikePublication(publicationID uint64) error {
-	statement, error := repository.db.Prepare("UPDATE publications SET likes = likes + 1 WHERE id = ?")
+func (repository Publications) LikePublication(ctx context.Context, publicationID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "UPDATE publications SET likes = likes + 1 WHERE id = ?")
 
 	if error != nil {
 		return error
@@ -197,7 +235,7 @@ func (repository Publications) LikePublication(publicationID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(publicationID); error != nil {
+	if _, error = statement.ExecContext(ctx, publicationID); error != nil {
 		return error
 	}
 
@@ -205,8 +243,11 @@ func (repository Publications) LikePublication(publicationID uint64) error {
 }
 
 // UnLikePublication
-func (repository Publications) UnLikePublication(publicationID uint64) error {
-	statement, error := repository.db.Prepare(`
+func (repository Publications) UnLikePublication(ctx context.Context, publicationID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, `
 	UPDATE publications SET likes = 
 	CASE 
 		WHEN likes > 0 THEN likes - 1 
@@ -220,7 +261,7 @@ func (repository Publications) UnLikePublication(publicationID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(publicationID); error != nil {
+	if _, error = statement.ExecContext(ctx, publicationID); error != nil {
 		return error
 	}
 
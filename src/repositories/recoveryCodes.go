@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+// RecoveryCodes represents a repository of two factor authentication recovery codes
+type RecoveryCodes struct {
+	db *DB
+}
+
+// NewRecoveryCodeRepository returns a new recovery code repository
+func NewRecoveryCodeRepository(db *DB) *RecoveryCodes {
+	return &RecoveryCodes{db}
+}
+
+// Replace discard the codes of a user and store the hashes of new ones
+func (repository RecoveryCodes) Replace(ctx context.Context, userID uint64, codeHashes []string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	// A failure must not leave the user without codes
+	return repository.db.WithTx(ctx, func(tx *DB) error {
+		codes := NewRecoveryCodeRepository(tx)
+
+		if error := codes.DeleteUser(ctx, userID); error != nil {
+			return error
+		}
+
+		statement, error := tx.PrepareContext(ctx, "insert into recovery_codes (user_id, code_hash) values (?, ?)")
+
+		if error != nil {
+			return error
+		}
+
+		defer statement.Close()
+
+		for _, codeHash := range codeHashes {
+			if _, error = statement.ExecContext(ctx, userID, codeHash); error != nil {
+				return error
+			}
+		}
+
+		return nil
+	})
+}
+
+// Use mark a code of the user as used, it returns false when the code does not exist or was used
+func (repository RecoveryCodes) Use(ctx context.Context, userID uint64, codeHash string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, time.Now(), userID, codeHash)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// DeleteUser delete every code of a user
+func (repository RecoveryCodes) DeleteUser(ctx context.Context, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "DELETE FROM recovery_codes WHERE user_id = ?")
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, userID); error != nil {
+		return error
+	}
+
+	return nil
+}
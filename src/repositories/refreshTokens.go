@@ -0,0 +1,202 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// RefreshTokens represents a repository of refresh tokens
+type RefreshTokens struct {
+	db *DB
+}
+
+// NewRefreshTokenRepository returns a new refresh token repository
+func NewRefreshTokenRepository(db *DB) *RefreshTokens {
+	return &RefreshTokens{db}
+}
+
+// Create insert a new refresh token
+func (repository RefreshTokens) Create(ctx context.Context, token models.RefreshToken) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx, `
+	insert into refresh_tokens (user_id, family_id, client_id, token_hash, expires_at, absolute_expires_at, scope)
+	values (?, ?, ?, ?, ?, ?, ?)`,
+		token.UserID,
+		token.FamilyID,
+		sql.NullString{String: token.ClientID, Valid: token.ClientID != ""},
+		token.TokenHash,
+		token.ExpiresAt,
+		token.AbsoluteExpiresAt,
+		sql.NullString{String: strings.Join(token.Scopes, " "), Valid: token.Scopes != nil},
+	)
+}
+
+// GetByHash get a refresh token by its hash, an empty token is returned when it does not exist
+func (repository RefreshTokens) GetByHash(ctx context.Context, tokenHash string) (models.RefreshToken, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, family_id, client_id, token_hash, expires_at, absolute_expires_at, scope, revoked_at IS NOT NULL, createdAt
+	FROM refresh_tokens WHERE token_hash = ?`,
+		tokenHash)
+
+	if error != nil {
+		return models.RefreshToken{}, error
+	}
+
+	defer line.Close()
+
+	var (
+		token    models.RefreshToken
+		clientID sql.NullString
+		scope    sql.NullString
+	)
+
+	if line.Next() {
+		if error = line.Scan(
+			&token.ID,
+			&token.UserID,
+			&token.FamilyID,
+			&clientID,
+			&token.TokenHash,
+			&token.ExpiresAt,
+			&token.AbsoluteExpiresAt,
+			&scope,
+			&token.Revoked,
+			&token.CreatedAt,
+		); error != nil {
+			return models.RefreshToken{}, error
+		}
+	}
+
+	token.ClientID = clientID.String
+
+	if scope.Valid {
+		token.Scopes = append([]string{}, strings.Fields(scope.String)...)
+	}
+
+	return token, nil
+}
+
+// Consume revoke a refresh token that was not revoked yet, it returns false when
+// the token was already used, which means it is being replayed
+func (repository RefreshTokens) Consume(ctx context.Context, ID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, time.Now(), ID)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// SetReplacement register which token replaced a rotated one
+func (repository RefreshTokens) SetReplacement(ctx context.Context, ID, replacedBy uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "UPDATE refresh_tokens SET replaced_by = ? WHERE id = ?")
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, replacedBy, ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// RevokeFamily revoke every token descending from the same login
+func (repository RefreshTokens) RevokeFamily(ctx context.Context, familyID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE family_id = ? AND revoked_at IS NULL",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, time.Now(), familyID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// RevokeUser revoke every refresh token of a user
+func (repository RefreshTokens) RevokeUser(ctx context.Context, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE user_id = ? AND revoked_at IS NULL",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, time.Now(), userID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// RevokeClient revoke every refresh token issued to an oauth client
+func (repository RefreshTokens) RevokeClient(ctx context.Context, clientID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE refresh_tokens SET revoked_at = ? WHERE client_id = ? AND revoked_at IS NULL",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, time.Now(), clientID); error != nil {
+		return error
+	}
+
+	return nil
+}
@@ -0,0 +1,228 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"time"
+)
+
+// UserRepository stores the users, their followers and their two factor authentication
+type UserRepository interface {
+	Create(ctx context.Context, user models.User) (uint64, error)
+	Search(ctx context.Context, userQuery string) ([]models.User, error)
+	Get(ctx context.Context, ID uint64) (models.User, error)
+	Update(ctx context.Context, ID uint64, user models.User) error
+	Delete(ctx context.Context, ID uint64) error
+	SearchByEmail(ctx context.Context, email string) (models.User, error)
+	GetAccount(ctx context.Context, ID uint64) (models.User, error)
+	MarkVerified(ctx context.Context, ID uint64, email string) (bool, error)
+	List(ctx context.Context) ([]models.User, error)
+	UpdateRoles(ctx context.Context, ID uint64, roles []string) error
+	Suspend(ctx context.Context, ID uint64, suspended bool) error
+	Follow(ctx context.Context, userID, followerID uint64) error
+	UnFollow(ctx context.Context, userID, followerID uint64) error
+	GetFollowers(ctx context.Context, userID uint64) ([]models.User, error)
+	GetFollowing(ctx context.Context, userID uint64) ([]models.User, error)
+	GetPassword(ctx context.Context, userID uint64) (string, error)
+	UpdatePassword(ctx context.Context, password string, userID uint64) error
+	GetTOTP(ctx context.Context, ID uint64) (models.TOTP, error)
+	SetTOTPSecret(ctx context.Context, ID uint64, secret string) error
+	EnableTOTP(ctx context.Context, ID uint64) error
+	UseTOTPStep(ctx context.Context, ID uint64, step int64) (bool, error)
+}
+
+// PublicationRepository stores the publications and their likes
+type PublicationRepository interface {
+	CreatePublication(ctx context.Context, publication models.Publication) (uint64, error)
+	ListPublications(ctx context.Context, userID uint64) ([]models.Publication, error)
+	GetPublication(ctx context.Context, publicationID uint64) (models.Publication, error)
+	ListAllPublications(ctx context.Context) ([]models.Publication, error)
+	UpdatePublication(ctx context.Context, publication models.Publication, publicationID uint64) error
+	DeletePublication(ctx context.Context, publicationID uint64) error
+	ListUserPublications(ctx context.Context, userID uint64) ([]models.Publication, error)
+	LikePublication(ctx context.Context, publicationID uint64) error
+	UnLikePublication(ctx context.Context, publicationID uint64) error
+}
+
+// RefreshTokenRepository stores the refresh tokens and their families
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token models.RefreshToken) (uint64, error)
+	GetByHash(ctx context.Context, tokenHash string) (models.RefreshToken, error)
+	Consume(ctx context.Context, ID uint64) (bool, error)
+	SetReplacement(ctx context.Context, ID, replacedBy uint64) error
+	RevokeFamily(ctx context.Context, familyID string) error
+	RevokeUser(ctx context.Context, userID uint64) error
+	RevokeClient(ctx context.Context, clientID string) error
+}
+
+// SessionRepository stores the login sessions
+type SessionRepository interface {
+	Create(ctx context.Context, session models.Session) (uint64, error)
+	Get(ctx context.Context, ID uint64) (models.Session, error)
+	GetByFamily(ctx context.Context, familyID string) (models.Session, error)
+	ListActive(ctx context.Context, userID uint64) ([]models.Session, error)
+	Seen(ctx context.Context, ID uint64, tokenID string) error
+	Active(ctx context.Context, ID uint64, since time.Time) error
+	Revoke(ctx context.Context, ID uint64) (bool, error)
+}
+
+// APIKeyRepository stores the personal api keys
+type APIKeyRepository interface {
+	Create(ctx context.Context, apiKey models.APIKey) (uint64, error)
+	GetByPrefix(ctx context.Context, prefix string) (models.APIKey, error)
+	ListByUser(ctx context.Context, userID uint64) ([]models.APIKey, error)
+	Revoke(ctx context.Context, ID, userID uint64) (bool, error)
+	Touch(ctx context.Context, ID uint64) error
+}
+
+// EmailVerificationRepository stores the email verification tokens
+type EmailVerificationRepository interface {
+	Create(ctx context.Context, token models.OneTimeToken) (uint64, error)
+	GetByHash(ctx context.Context, tokenHash string) (models.OneTimeToken, error)
+	Use(ctx context.Context, ID uint64) (bool, error)
+	LastSentAt(ctx context.Context, userID uint64) (time.Time, error)
+}
+
+// LoginAttemptRepository stores the failed logins
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt models.LoginAttempt) error
+}
+
+// MagicLinkRepository stores the passwordless login links sent
+type MagicLinkRepository interface {
+	Create(ctx context.Context, tokenID string, userID uint64, expiresAt time.Time) error
+	Use(ctx context.Context, tokenID string) (bool, error)
+}
+
+// OAuthClientRepository stores the oauth clients
+type OAuthClientRepository interface {
+	Create(ctx context.Context, client models.OAuthClient) (uint64, error)
+	GetByClientID(ctx context.Context, clientID string) (models.OAuthClient, error)
+	ListByOwner(ctx context.Context, ownerID uint64) ([]models.OAuthClient, error)
+	Delete(ctx context.Context, clientID string, ownerID uint64) (bool, error)
+}
+
+// OAuthCodeRepository stores the oauth authorization codes
+type OAuthCodeRepository interface {
+	Create(ctx context.Context, code models.OAuthCode) (uint64, error)
+	GetByHash(ctx context.Context, codeHash string) (models.OAuthCode, error)
+	Use(ctx context.Context, ID uint64) (bool, error)
+	SetFamily(ctx context.Context, ID uint64, familyID string) error
+}
+
+// PasswordResetRepository stores the password reset tokens
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token models.OneTimeToken) (uint64, error)
+	GetByHash(ctx context.Context, tokenHash string) (models.OneTimeToken, error)
+	Use(ctx context.Context, ID uint64) (bool, error)
+	InvalidateUser(ctx context.Context, userID uint64) error
+}
+
+// RecoveryCodeRepository stores the two factor authentication recovery codes
+type RecoveryCodeRepository interface {
+	Replace(ctx context.Context, userID uint64, codeHashes []string) error
+	Use(ctx context.Context, userID uint64, codeHash string) (bool, error)
+	DeleteUser(ctx context.Context, userID uint64) error
+}
+
+// UnitOfWork gives the repositories of a storage whose writes are committed together
+type UnitOfWork interface {
+	Users() UserRepository
+	Publications() PublicationRepository
+	RefreshTokens() RefreshTokenRepository
+	Sessions() SessionRepository
+	APIKeys() APIKeyRepository
+	EmailVerifications() EmailVerificationRepository
+	LoginAttempts() LoginAttemptRepository
+	MagicLinks() MagicLinkRepository
+	OAuthClients() OAuthClientRepository
+	OAuthCodes() OAuthCodeRepository
+	PasswordResets() PasswordResetRepository
+	RecoveryCodes() RecoveryCodeRepository
+}
+
+// Store gives the repositories of a storage, the controllers reach the data only through it
+type Store interface {
+	UnitOfWork
+	// WithTx run the work on repositories whose writes are all kept when it returns no error,
+	// and all discarded when it fails or panics. The work may run again after a deadlock, so
+	// its effects outside of the store must be safe to repeat
+	WithTx(ctx context.Context, work func(UnitOfWork) error) error
+}
+
+// SQLStore gives the repositories backed by the database
+type SQLStore struct {
+	db *DB
+}
+
+// NewSQLStore returns the store of the repositories of a database
+func NewSQLStore(db *DB) *SQLStore {
+	return &SQLStore{db}
+}
+
+// WithTx run the work in a transaction of the database
+func (store SQLStore) WithTx(ctx context.Context, work func(UnitOfWork) error) error {
+	return store.db.WithTx(ctx, func(tx *DB) error {
+		return work(NewSQLStore(tx))
+	})
+}
+
+// Users returns the user repository
+func (store SQLStore) Users() UserRepository {
+	return NewUserRepository(store.db)
+}
+
+// Publications returns the publication repository
+func (store SQLStore) Publications() PublicationRepository {
+	return NewPublicationRepository(store.db)
+}
+
+// RefreshTokens returns the refresh token repository
+func (store SQLStore) RefreshTokens() RefreshTokenRepository {
+	return NewRefreshTokenRepository(store.db)
+}
+
+// Sessions returns the session repository
+func (store SQLStore) Sessions() SessionRepository {
+	return NewSessionRepository(store.db)
+}
+
+// APIKeys returns the api key repository
+func (store SQLStore) APIKeys() APIKeyRepository {
+	return NewAPIKeyRepository(store.db)
+}
+
+// EmailVerifications returns the email verification repository
+func (store SQLStore) EmailVerifications() EmailVerificationRepository {
+	return NewEmailVerificationRepository(store.db)
+}
+
+// LoginAttempts returns the login attempt repository
+func (store SQLStore) LoginAttempts() LoginAttemptRepository {
+	return NewLoginAttemptRepository(store.db)
+}
+
+// MagicLinks returns the magic link repository
+func (store SQLStore) MagicLinks() MagicLinkRepository {
+	return NewMagicLinkRepository(store.db)
+}
+
+// OAuthClients returns the oauth client repository
+func (store SQLStore) OAuthClients() OAuthClientRepository {
+	return NewOAuthClientRepository(store.db)
+}
+
+// OAuthCodes returns the oauth authorization code repository
+func (store SQLStore) OAuthCodes() OAuthCodeRepository {
+	return NewOAuthCodeRepository(store.db)
+}
+
+// PasswordResets returns the password reset repository
+func (store SQLStore) PasswordResets() PasswordResetRepository {
+	return NewPasswordResetRepository(store.db)
+}
+
+// RecoveryCodes returns the recovery code repository
+func (store SQLStore) RecoveryCodes() RecoveryCodeRepository {
+	return NewRecoveryCodeRepository(store.db)
+}
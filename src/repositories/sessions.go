@@ -0,0 +1,225 @@
+package repositories
+
+import (
+	"api/src/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// Sessions represents a repository of login sessions
+type Sessions struct {
+	db *DB
+}
+
+// NewSessionRepository returns a new session repository
+func NewSessionRepository(db *DB) *Sessions {
+	return &Sessions{db}
+}
+
+// Create insert a new session
+func (repository Sessions) Create(ctx context.Context, session models.Session) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	return repository.db.insert(ctx, `
+	insert into sessions (user_id, family_id, client_id, user_agent, ip, expires_at, last_seen_at)
+	values (?, ?, ?, ?, ?, ?, ?)`,
+		session.UserID,
+		session.FamilyID,
+		sql.NullString{String: session.ClientID, Valid: session.ClientID != ""},
+		session.UserAgent,
+		session.IP,
+		session.ExpiresAt,
+		time.Now(),
+	)
+}
+
+// Get get a session by its id, an empty session is returned when it does not exist
+func (repository Sessions) Get(ctx context.Context, ID uint64) (models.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, family_id, client_id, token_id, user_agent, ip, expires_at, last_seen_at,
+	revoked_at IS NOT NULL, createdAt
+	FROM sessions WHERE id = ?`,
+		ID)
+
+	if error != nil {
+		return models.Session{}, error
+	}
+
+	defer lines.Close()
+
+	sessions, error := scanSessions(lines)
+
+	if error != nil || len(sessions) == 0 {
+		return models.Session{}, error
+	}
+
+	return sessions[0], nil
+}
+
+// GetByFamily get the session of a family of refresh tokens, an empty session is
+// returned for families started before sessions were recorded
+func (repository Sessions) GetByFamily(ctx context.Context, familyID string) (models.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT id, user_id, family_id, client_id, token_id, user_agent, ip, expires_at, last_seen_at,
+	revoked_at IS NOT NULL, createdAt
+	FROM sessions WHERE family_id = ?`,
+		familyID)
+
+	if error != nil {
+		return models.Session{}, error
+	}
+
+	defer lines.Close()
+
+	sessions, error := scanSessions(lines)
+
+	if error != nil || len(sessions) == 0 {
+		return models.Session{}, error
+	}
+
+	return sessions[0], nil
+}
+
+// ListActive get the sessions of a user which can still be refreshed, most recently seen first
+func (repository Sessions) ListActive(ctx context.Context, userID uint64) ([]models.Session, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	now := time.Now()
+
+	lines, error := repository.db.QueryContext(ctx, `
+	SELECT s.id, s.user_id, s.family_id, s.client_id, s.token_id, s.user_agent, s.ip, s.expires_at,
+	s.last_seen_at, s.revoked_at IS NOT NULL, s.createdAt
+	FROM sessions s
+	WHERE s.user_id = ? AND s.revoked_at IS NULL AND s.expires_at > ? AND EXISTS (
+		SELECT 1 FROM refresh_tokens r
+		WHERE r.family_id = s.family_id AND r.revoked_at IS NULL AND r.expires_at > ?
+	)
+	ORDER BY s.last_seen_at DESC`,
+		userID, now, now)
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer lines.Close()
+
+	return scanSessions(lines)
+}
+
+// Seen register the last token issued in a session
+func (repository Sessions) Seen(ctx context.Context, ID uint64, tokenID string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE sessions SET token_id = ?, last_seen_at = ? WHERE id = ?",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, tokenID, time.Now(), ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// Active register a session was just used, unless it was already seen after since
+func (repository Sessions) Active(ctx context.Context, ID uint64, since time.Time) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE sessions SET last_seen_at = ? WHERE id = ? AND last_seen_at < ?",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, time.Now(), ID, since); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// Revoke mark a session as revoked, it returns false when it was already revoked
+func (repository Sessions) Revoke(ctx context.Context, ID uint64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, time.Now(), ID)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+func scanSessions(lines *sql.Rows) ([]models.Session, error) {
+	var sessions []models.Session
+
+	for lines.Next() {
+		var (
+			session  models.Session
+			clientID sql.NullString
+			tokenID  sql.NullString
+		)
+
+		if error := lines.Scan(
+			&session.ID,
+			&session.UserID,
+			&session.FamilyID,
+			&clientID,
+			&tokenID,
+			&session.UserAgent,
+			&session.IP,
+			&session.ExpiresAt,
+			&session.LastSeenAt,
+			&session.Revoked,
+			&session.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+
+		session.ClientID = clientID.String
+		session.TokenID = tokenID.String
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
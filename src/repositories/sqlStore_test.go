@@ -0,0 +1,145 @@
+package repositories_test
+
+import (
+	"api/src/config"
+	"api/src/database"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/repositories/conformance"
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// openSQLite returns a store on a new SQLite database, migrated to the latest schema
+func openSQLite(t *testing.T) repositories.Store {
+	t.Helper()
+
+	return repositories.NewSQLStore(openSQLiteDB(t))
+}
+
+// openSQLiteDB returns a new SQLite database, migrated to the latest schema
+func openSQLiteDB(t *testing.T) *repositories.DB {
+	t.Helper()
+
+	return repositories.NewDB(conformance.OpenDatabase(t, database.SQLite), database.SQLite)
+}
+
+func TestSQLiteConformance(t *testing.T) {
+	conformance.Run(t, openSQLite)
+}
+
+// TestServerConformance runs on the databases of conformance.ServerVariables, skipped without them
+func TestServerConformance(t *testing.T) {
+	for _, dialect := range []database.Dialect{database.MySQL, database.PostgreSQL} {
+		dialect := dialect
+
+		t.Run(dialect.Name, func(t *testing.T) {
+			if variable := conformance.ServerVariables[dialect.Name]; os.Getenv(variable) == "" {
+				t.Skipf("Set %s to run the conformance tests on %s", variable, dialect.Name)
+			}
+
+			conformance.Run(t, func(t *testing.T) repositories.Store {
+				return repositories.NewSQLStore(repositories.NewDB(conformance.OpenDatabase(t, dialect), dialect))
+			})
+		})
+	}
+}
+
+func TestWithTxRetriesDeadlocks(t *testing.T) {
+	db := openSQLiteDB(t)
+	ctx := context.Background()
+
+	attempts := 0
+
+	if error := db.WithTx(ctx, func(tx *repositories.DB) error {
+		attempts++
+
+		if _, error := repositories.NewUserRepository(tx).Create(ctx, models.User{
+			Name: "Maria", Nick: "maria", Email: "maria@devbook.test", Password: "hash",
+		}); error != nil {
+			return error
+		}
+
+		if attempts == 1 {
+			return sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusySnapshot}
+		}
+
+		return nil
+	}); error != nil || attempts != 2 {
+		t.Fatalf("Expected a second attempt to succeed, got %d attempts: %v", attempts, error)
+	}
+
+	// Emails are unique, the second attempt could only create the user after the first was rolled back
+	if user, _ := repositories.NewUserRepository(db).SearchByEmail(ctx, "maria@devbook.test"); user.ID == 0 {
+		t.Fatal("User of the successful attempt not found")
+	}
+
+	failure := errors.New("failure")
+	attempts = 0
+
+	if error := db.WithTx(ctx, func(tx *repositories.DB) error {
+		attempts++
+		return failure
+	}); error != failure || attempts != 1 {
+		t.Fatalf("Other errors must not be retried, got %d attempts: %v", attempts, error)
+	}
+
+	attempts = 0
+
+	if error := db.WithTx(ctx, func(tx *repositories.DB) error {
+		attempts++
+		return sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusySnapshot}
+	}); error == nil || attempts != config.TransactionAttempts {
+		t.Fatalf("Expected %d attempts, got %d: %v", config.TransactionAttempts, attempts, error)
+	}
+}
+
+// openBusySQLite returns a new SQLite database with a table of entries, whose connections wait
+// for each other briefly
+func openBusySQLite(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, error := database.Open(database.SQLite, "file:"+filepath.Join(t.TempDir(), "devbook.db")+"?_busy_timeout=100")
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	if _, error = db.Exec("CREATE TABLE entries(id integer primary key)"); error != nil {
+		t.Fatal(error)
+	}
+
+	return db
+}
+
+func TestWithTxDoesNotRetryItsOwnLock(t *testing.T) {
+	db := openBusySQLite(t)
+	ctx := context.Background()
+
+	attempts := 0
+
+	error := repositories.NewDB(db, database.SQLite).WithTx(ctx, func(tx *repositories.DB) error {
+		attempts++
+
+		if _, error := tx.ExecContext(ctx, "INSERT INTO entries (id) values (1)"); error != nil {
+			return error
+		}
+
+		// A write through the pool waits for the lock the transaction holds
+		_, error := db.ExecContext(ctx, "INSERT INTO entries (id) values (2)")
+
+		return error
+	})
+
+	if error == nil || attempts != 1 {
+		t.Fatalf("Expected a single failed attempt, got %d: %v", attempts, error)
+	}
+}
@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"api/src/config"
+	"context"
+)
+
+// withQueryTimeout bound the queries of a repository call by config.QueryTimeout, on top of
+// any deadline the caller context already has. Zero disables the limit
+func withQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if config.QueryTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, config.QueryTimeout)
+}
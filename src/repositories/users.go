@@ -2,52 +2,40 @@ package repositories
 
 import (
 	"api/src/models"
+	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 )
 
 // Users is a repository od users
 type Users struct {
-	db *sql.DB
+	db *DB
 }
 
 // NewUserRepository generate a new repository of user
-func NewUserRepository(db *sql.DB) *Users {
+func NewUserRepository(db *DB) *Users {
 
 	return &Users{db}
 }
 
 // Insert a new user in database
-func (repository Users) Create(user models.User) (uint64, error) {
+func (repository Users) Create(ctx context.Context, user models.User) (uint64, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, error := repository.db.Prepare("insert into users (name, nick, email, password) values(?,?,?,?)")
-
-	if error != nil {
-		return 0, error
-	}
-
-	defer statement.Close()
-
-	result, error := statement.Exec(user.Name, user.Nick, user.Email, user.Password)
-
-	if error != nil {
-		return 0, error
-	}
-
-	lastInsertedID, error := result.LastInsertId()
-
-	if error != nil {
-		return 0, error
-	}
-
-	return uint64(lastInsertedID), nil
+	return repository.db.insert(ctx, "insert into users (name, nick, email, password) values(?,?,?,?)", user.Name, user.Nick, user.Email, user.Password)
 }
 
 // Search find all users that has the parameter userQuery
-func (repository Users) Search(userQuery string) ([]models.User, error) {
+func (repository Users) Search(ctx context.Context, userQuery string) ([]models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
 	userQuery = fmt.Sprintf("%%%s%%", userQuery) // %% serve para o escape de caracteres
 
-	lines, error := repository.db.Query("SELECT id, name, nick, email, createdAt FROM users WHERE name LIKE ? OR nick LIKE ?",
+	lines, error := repository.db.QueryContext(ctx, "SELECT id, name, nick, email, createdAt FROM users WHERE LOWER(name) LIKE ? OR LOWER(nick) LIKE ?",
 		userQuery, userQuery)
 
 	if error != nil {
@@ -64,8 +52,8 @@ func (repository Users) Search(userQuery string) ([]models.User, error) {
 		if error = lines.Scan(
 			&user.ID,
 			&user.Name,
-			&user.Email,
 			&user.Nick,
+			&user.Email,
 			&user.CreatedAt,
 		); error != nil {
 			return nil, error
@@ -78,8 +66,11 @@ func (repository Users) Search(userQuery string) ([]models.User, error) {
 }
 
 // Get get a user by id
-func (repository Users) Get(ID uint64) (models.User, error) {
-	line, error := repository.db.Query("SELECT id, name, nick, email, createdAt from users where id = ?",
+func (repository Users) Get(ctx context.Context, ID uint64) (models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, "SELECT id, name, nick, email, createdAt from users where id = ?",
 		ID)
 
 	if error != nil {
@@ -106,9 +97,16 @@ func (repository Users) Get(ID uint64) (models.User, error) {
 }
 
 // Update update a user
-func (repository Users) Update(ID uint64, user models.User) error {
+func (repository Users) Update(ctx context.Context, ID uint64, user models.User) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, error := repository.db.Prepare("UPDATE users SET name = ?, nick = ?, email = ? where id = ?")
+	// verified_at is assigned first so it is compared with the email being replaced
+	statement, error := repository.db.PrepareContext(ctx, `
+	UPDATE users SET 
+		verified_at = CASE WHEN email = ? THEN verified_at ELSE NULL END,
+		name = ?, nick = ?, email = ?
+	where id = ?`)
 
 	if error != nil {
 		return error
@@ -116,7 +114,7 @@ func (repository Users) Update(ID uint64, user models.User) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(user.Name, user.Nick, user.Email, ID); error != nil {
+	if _, error = statement.ExecContext(ctx, user.Email, user.Name, user.Nick, user.Email, ID); error != nil {
 		return error
 	}
 
@@ -124,8 +122,11 @@ func (repository Users) Update(ID uint64, user models.User) error {
 }
 
 // Delete delete a user from database
-func (repository Users) Delete(ID uint64) error {
-	statement, error := repository.db.Prepare("DELETE FROM users WHERE id = ?")
+func (repository Users) Delete(ctx context.Context, ID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "DELETE FROM users WHERE id = ?")
 
 	if error != nil {
 		return error
@@ -133,7 +134,7 @@ func (repository Users) Delete(ID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(ID); error != nil {
+	if _, error = statement.ExecContext(ctx, ID); error != nil {
 		return error
 	}
 
@@ -141,8 +142,11 @@ func (repository Users) Delete(ID uint64) error {
 }
 
 // SearchByEmail get a user by email
-func (repository Users) SearchByEmail(email string) (models.User, error) {
-	line, error := repository.db.Query("SELECT id, password FROM users where email = ?", email)
+func (repository Users) SearchByEmail(ctx context.Context, email string) (models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, "SELECT id, password, roles, suspended_at FROM users where email = ?", email)
 
 	if error != nil {
 		return models.User{}, error
@@ -153,19 +157,198 @@ func (repository Users) SearchByEmail(email string) (models.User, error) {
 	var user models.User
 
 	if line.Next() {
-		if error = line.Scan(&user.ID, &user.Password); error != nil {
+		var (
+			roles       string
+			suspendedAt sql.NullTime
+		)
+
+		if error = line.Scan(&user.ID, &user.Password, &roles, &suspendedAt); error != nil {
 			return models.User{}, error
 		}
+
+		user.Roles = splitRoles(roles)
+		user.SuspendedAt = nullTime(suspendedAt)
 	}
 
 	return user, nil
 }
 
+// GetAccount get the fields that control the access of a user: email, roles, suspension and verification
+func (repository Users) GetAccount(ctx context.Context, ID uint64) (models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx,
+		"SELECT id, email, roles, suspended_at, verified_at FROM users where id = ?",
+		ID)
+
+	if error != nil {
+		return models.User{}, error
+	}
+
+	defer line.Close()
+
+	var user models.User
+
+	if line.Next() {
+		var (
+			roles       string
+			suspendedAt sql.NullTime
+			verifiedAt  sql.NullTime
+		)
+
+		if error = line.Scan(&user.ID, &user.Email, &roles, &suspendedAt, &verifiedAt); error != nil {
+			return models.User{}, error
+		}
+
+		user.Roles = splitRoles(roles)
+		user.SuspendedAt = nullTime(suspendedAt)
+		user.VerifiedAt = nullTime(verifiedAt)
+	}
+
+	return user, nil
+}
+
+// MarkVerified register the email of a user as verified, it returns false when the
+// user no longer has that email
+func (repository Users) MarkVerified(ctx context.Context, ID uint64, email string) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "UPDATE users SET verified_at = ? where id = ? AND email = ?")
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, time.Now(), ID, email)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
+
+// List get every user with its roles and suspension, for administration
+func (repository Users) List(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx,
+		"SELECT id, name, nick, email, roles, suspended_at, createdAt FROM users ORDER BY id",
+	)
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer lines.Close()
+
+	var users []models.User
+
+	for lines.Next() {
+		var (
+			user        models.User
+			roles       string
+			suspendedAt sql.NullTime
+		)
+
+		if error = lines.Scan(
+			&user.ID,
+			&user.Name,
+			&user.Nick,
+			&user.Email,
+			&roles,
+			&suspendedAt,
+			&user.CreatedAt,
+		); error != nil {
+			return nil, error
+		}
+
+		user.Roles = splitRoles(roles)
+		user.SuspendedAt = nullTime(suspendedAt)
+
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// UpdateRoles replace the roles of a user
+func (repository Users) UpdateRoles(ctx context.Context, ID uint64, roles []string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "UPDATE users SET roles = ? where id = ?")
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, strings.Join(roles, ","), ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// Suspend block or unblock the access of a user
+func (repository Users) Suspend(ctx context.Context, ID uint64, suspended bool) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "UPDATE users SET suspended_at = ? where id = ?")
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	suspendedAt := sql.NullTime{Time: time.Now(), Valid: suspended}
+
+	if _, error = statement.ExecContext(ctx, suspendedAt, ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// splitRoles decode the comma separated roles column
+func splitRoles(roles string) []string {
+	if roles == "" {
+		return []string{}
+	}
+
+	return strings.Split(roles, ",")
+}
+
+func nullTime(value sql.NullTime) *time.Time {
+	if !value.Valid {
+		return nil
+	}
+
+	return &value.Time
+}
+
 // Follow register the follower of a user
-func (repository Users) Follow(userID, followerID uint64) error {
+func (repository Users) Follow(ctx context.Context, userID, followerID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
 
-	statement, error := repository.db.Prepare(
-		"insert ignore into followers (user_id, follower_id) values (?, ?)", // Ignore if already exists
+	statement, error := repository.db.PrepareContext(ctx,
+		repository.db.dialect.InsertIgnore("followers (user_id, follower_id) values (?, ?)"), // Ignore if already exists
 	)
 
 	if error != nil {
@@ -174,7 +357,7 @@ func (repository Users) Follow(userID, followerID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(userID, followerID); error != nil {
+	if _, error = statement.ExecContext(ctx, userID, followerID); error != nil {
 		return error
 	}
 
@@ -182,8 +365,11 @@ func (repository Users) Follow(userID, followerID uint64) error {
 }
 
 // UnFollow permits unfollow a user
-func (repository Users) UnFollow(userID, followerID uint64) error {
-	statement, error := repository.db.Prepare("DELETE FROM followers WHERE user_id = ? and follower_id = ?")
+func (repository Users) UnFollow(ctx context.Context, userID, followerID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "DELETE FROM followers WHERE user_id = ? and follower_id = ?")
 
 	if error != nil {
 		return error
@@ -191,15 +377,18 @@ func (repository Users) UnFollow(userID, followerID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(userID, followerID); error != nil {
+	if _, error = statement.ExecContext(ctx, userID, followerID); error != nil {
 		return error
 	}
 
 	return nil
 }
 
-func (repository Users) GetFollowers(userID uint64) ([]models.User, error) {
-	lines, error := repository.db.Query(`
+func (repository Users) GetFollowers(ctx context.Context, userID uint64) ([]models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
 		select u.id, u.name, u.nick, u.email, u.createdAt
 		from users u inner join followers f on u.id = f.follower_id where f.user_id = ?`,
 		userID)
@@ -232,8 +421,11 @@ func (repository Users) GetFollowers(userID uint64) ([]models.User, error) {
 }
 
 // GetFollowing
-func (repository Users) GetFollowing(userID uint64) ([]models.User, error) {
-	lines, error := repository.db.Query(`
+func (repository Users) GetFollowing(ctx context.Context, userID uint64) ([]models.User, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	lines, error := repository.db.QueryContext(ctx, `
 		select u.id, u.name, u.nick, u.email, u.createdAt
 		from users u inner join followers f on u.id = f.user_id where f.follower_id = ?`,
 		userID)
@@ -266,8 +458,11 @@ func (repository Users) GetFollowing(userID uint64) ([]models.User, error) {
 }
 
 // GetPassword get password of a user
-func (repository Users) GetPassword(userID uint64) (string, error) {
-	line, error := repository.db.Query("SELECT password FROM users where id = ?", userID)
+func (repository Users) GetPassword(ctx context.Context, userID uint64) (string, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx, "SELECT password FROM users where id = ?", userID)
 
 	if error != nil {
 		return "", error
@@ -287,9 +482,64 @@ func (repository Users) GetPassword(userID uint64) (string, error) {
 }
 
 // UpdatePassword update a user password
-func (repository Users) UpdatePassword(password string, userID uint64) error {
+func (repository Users) UpdatePassword(ctx context.Context, password string, userID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx, "UPDATE users SET password = ? where id = ?")
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, password, userID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// GetTOTP get the two factor authentication setup of a user
+func (repository Users) GetTOTP(ctx context.Context, ID uint64) (models.TOTP, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	line, error := repository.db.QueryContext(ctx,
+		"SELECT totp_secret, totp_enabled_at IS NOT NULL, totp_last_step FROM users where id = ?",
+		ID)
 
-	statement, error := repository.db.Prepare("UPDATE users SET password = ? where id = ?")
+	if error != nil {
+		return models.TOTP{}, error
+	}
+
+	defer line.Close()
+
+	var (
+		totp   models.TOTP
+		secret sql.NullString
+	)
+
+	if line.Next() {
+		if error = line.Scan(&secret, &totp.Enabled, &totp.LastStep); error != nil {
+			return models.TOTP{}, error
+		}
+	}
+
+	totp.Secret = secret.String
+
+	return totp, nil
+}
+
+// SetTOTPSecret store a secret pending confirmation, an empty secret disables two factor authentication
+func (repository Users) SetTOTPSecret(ctx context.Context, ID uint64, secret string) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE users SET totp_secret = ?, totp_enabled_at = NULL, totp_last_step = 0 where id = ?",
+	)
 
 	if error != nil {
 		return error
@@ -297,9 +547,62 @@ func (repository Users) UpdatePassword(password string, userID uint64) error {
 
 	defer statement.Close()
 
-	if _, error = statement.Exec(password, userID); error != nil {
+	if _, error = statement.ExecContext(ctx, sql.NullString{String: secret, Valid: secret != ""}, ID); error != nil {
 		return error
 	}
 
 	return nil
 }
+
+// EnableTOTP start requiring codes of the stored secret on login
+func (repository Users) EnableTOTP(ctx context.Context, ID uint64) error {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE users SET totp_enabled_at = ? where id = ? AND totp_secret IS NOT NULL",
+	)
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.ExecContext(ctx, time.Now(), ID); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// UseTOTPStep register the period of an accepted code, it returns false when a code
+// of that period or a later one was already used
+func (repository Users) UseTOTPStep(ctx context.Context, ID uint64, step int64) (bool, error) {
+	ctx, cancel := withQueryTimeout(ctx)
+	defer cancel()
+
+	statement, error := repository.db.PrepareContext(ctx,
+		"UPDATE users SET totp_last_step = ? where id = ? AND totp_last_step < ?",
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer statement.Close()
+
+	result, error := statement.ExecContext(ctx, step, ID, step)
+
+	if error != nil {
+		return false, error
+	}
+
+	affectedRows, error := result.RowsAffected()
+
+	if error != nil {
+		return false, error
+	}
+
+	return affectedRows == 1, nil
+}
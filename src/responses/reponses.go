@@ -23,11 +23,42 @@ func JSON(w http.ResponseWriter, statusCode int, data interface{}) {
 	}
 }
 
-// Error return a json error
+// Text return a plain text answer
+func Text(w http.ResponseWriter, statusCode int, text string) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+
+	if _, error := w.Write([]byte(text)); error != nil {
+		log.Print(error)
+	}
+}
+
+// fieldsError is implemented by errors telling the problems of each field of a request
+type fieldsError interface {
+	Fields() map[string][]string
+}
+
+// Error return a json error, along with the problems of each field when the error has them.
+// Internal errors caused by a cancelled request, a deadline or an unreachable database are
+// answered with 499, 504 and 503
 func Error(w http.ResponseWriter, statusCode int, error error) {
+	if statusCode == http.StatusInternalServerError {
+		if status := failureStatus(error); status != 0 {
+			statusCode = status
+		}
+	}
+
+	var fields map[string][]string
+
+	if withFields, ok := error.(fieldsError); ok {
+		fields = withFields.Fields()
+	}
+
 	JSON(w, statusCode, struct {
-		Error string `json:"error"`
+		Error  string              `json:"error"`
+		Fields map[string][]string `json:"fields,omitempty"`
 	}{
-		Error: error.Error(),
+		Error:  error.Error(),
+		Fields: fields,
 	})
 }
@@ -0,0 +1,31 @@
+package responses
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// StatusClientClosedRequest is answered, as nginx does, when the client gave up on the request
+// before it finished. The client never reads it, but it tells these requests apart in the logs
+const StatusClientClosedRequest = 499
+
+// failureStatus tells the status of errors that are not a fault of the api: the client going away,
+// a deadline passing or the database being unreachable. Zero is returned for any other error
+func failureStatus(error error) int {
+	var netError net.Error
+
+	switch {
+	case errors.Is(error, context.Canceled):
+		return StatusClientClosedRequest
+	case errors.Is(error, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(error, driver.ErrBadConn), errors.Is(error, sql.ErrConnDone), errors.As(error, &netError):
+		return http.StatusServiceUnavailable
+	}
+
+	return 0
+}
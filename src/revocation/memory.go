@@ -0,0 +1,97 @@
+package revocation
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"sync"
+	"time"
+)
+
+// evictionInterval is the minimum time between two sweeps of expired entries
+const evictionInterval = time.Minute
+
+// MemoryStore is a Store kept in the process memory, revocations are lost on restart
+// and are not shared between instances
+type MemoryStore struct {
+	mutex        sync.RWMutex
+	tokens       map[string]time.Time
+	users        map[uint64]time.Time
+	lastEviction time.Time
+}
+
+// NewMemoryStore returns an empty memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens:       map[string]time.Time{},
+		users:        map[uint64]time.Time{},
+		lastEviction: time.Now(),
+	}
+}
+
+// RevokeToken revoke a single token until it expires
+func (store *MemoryStore) RevokeToken(tokenID string, expiresAt time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.tokens[tokenID] = expiresAt
+	store.evict()
+
+	return nil
+}
+
+// RevokeUser revoke every token of a user issued until the given moment
+func (store *MemoryStore) RevokeUser(userID uint64, issuedUntil time.Time) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if issuedUntil.After(store.users[userID]) {
+		store.users[userID] = issuedUntil
+	}
+
+	store.evict()
+
+	return nil
+}
+
+// IsRevoked report if a token was revoked by its id or by its owner
+func (store *MemoryStore) IsRevoked(tokenID string, userID uint64, issuedAt time.Time) (bool, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	if _, revoked := store.tokens[tokenID]; revoked {
+		return true, nil
+	}
+
+	issuedUntil, revoked := store.users[userID]
+
+	return revoked && revokedByUser(issuedAt, issuedUntil), nil
+}
+
+// evict remove the entries that can no longer match a valid token, the caller must hold the lock
+func (store *MemoryStore) evict() {
+	now := time.Now()
+
+	if now.Sub(store.lastEviction) < evictionInterval {
+		return
+	}
+
+	store.lastEviction = now
+
+	for tokenID, expiresAt := range store.tokens {
+		if now.After(expiresAt) {
+			delete(store.tokens, tokenID)
+		}
+	}
+
+	for userID, issuedUntil := range store.users {
+		if now.After(issuedUntil.Add(config.AccessTokenDuration)) {
+			delete(store.users, userID)
+		}
+	}
+}
+
+// revokedByUser compares with the precision of the iat claim, so a token issued right after
+// the revocation, even in the same second, stays valid
+func revokedByUser(issuedAt, issuedUntil time.Time) bool {
+	return !issuedAt.After(issuedUntil.Truncate(authentication.IssuedAtPrecision))
+}
@@ -0,0 +1,107 @@
+package revocation
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/database"
+	"database/sql"
+	"time"
+)
+
+// SQLStore is a Store persisted in the database and shared by every instance
+type SQLStore struct {
+	db      *sql.DB
+	dialect database.Dialect
+}
+
+// NewSQLStore returns a store backed by the revoked_tokens and user_revocations tables
+// of a database of the dialect
+func NewSQLStore(db *sql.DB, dialect database.Dialect) *SQLStore {
+	return &SQLStore{db, dialect}
+}
+
+// RevokeToken revoke a single token until it expires
+func (store *SQLStore) RevokeToken(tokenID string, expiresAt time.Time) error {
+	statement, error := store.db.Prepare(store.dialect.Rebind(
+		store.dialect.InsertIgnore("revoked_tokens (token_id, expires_at) values (?, ?)"),
+	))
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.Exec(tokenID, expiresAt); error != nil {
+		return error
+	}
+
+	// Expired tokens are rejected anyway, so their entries are useless
+	if _, error = store.db.Exec(store.dialect.Rebind("DELETE FROM revoked_tokens WHERE expires_at < ?"), time.Now()); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// RevokeUser revoke every token of a user issued until the given moment
+func (store *SQLStore) RevokeUser(userID uint64, issuedUntil time.Time) error {
+	issuedUntil = issuedUntil.Truncate(authentication.IssuedAtPrecision)
+
+	statement, error := store.db.Prepare(store.dialect.Rebind(
+		store.dialect.InsertIgnore("user_revocations (user_id, issued_until) values (?, ?)"),
+	))
+
+	if error != nil {
+		return error
+	}
+
+	defer statement.Close()
+
+	if _, error = statement.Exec(userID, issuedUntil); error != nil {
+		return error
+	}
+
+	// A revocation already kept for the user only moves forward
+	if _, error = store.db.Exec(
+		store.dialect.Rebind("UPDATE user_revocations SET issued_until = ? WHERE user_id = ? AND issued_until < ?"),
+		issuedUntil, userID, issuedUntil,
+	); error != nil {
+		return error
+	}
+
+	if _, error = store.db.Exec(
+		store.dialect.Rebind("DELETE FROM user_revocations WHERE issued_until < ?"),
+		time.Now().Add(-config.AccessTokenDuration),
+	); error != nil {
+		return error
+	}
+
+	return nil
+}
+
+// IsRevoked report if a token was revoked by its id or by its owner
+func (store *SQLStore) IsRevoked(tokenID string, userID uint64, issuedAt time.Time) (bool, error) {
+	line, error := store.db.Query(store.dialect.Rebind(`
+	SELECT
+		(SELECT count(*) FROM revoked_tokens WHERE token_id = ?) +
+		(SELECT count(*) FROM user_revocations WHERE user_id = ? AND issued_until >= ?)`),
+		tokenID, userID, issuedAt.Truncate(authentication.IssuedAtPrecision),
+	)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer line.Close()
+
+	var matches int
+
+	if line.Next() {
+		if error = line.Scan(&matches); error != nil {
+			return false, error
+		}
+	}
+
+	return matches > 0, nil
+}
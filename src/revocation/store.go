@@ -0,0 +1,55 @@
+package revocation
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/database"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Store keeps the tokens revoked before their expiration
+type Store interface {
+	// RevokeToken revoke a single token until it expires
+	RevokeToken(tokenID string, expiresAt time.Time) error
+	// RevokeUser revoke every token of a user issued until the given moment
+	RevokeUser(userID uint64, issuedUntil time.Time) error
+	// IsRevoked report if a token was revoked by its id or by its owner
+	IsRevoked(tokenID string, userID uint64, issuedAt time.Time) (bool, error)
+}
+
+// SessionTokenID returns the id under which a session is revoked, every token
+// carrying the session is rejected while it is revoked
+func SessionTokenID(sessionID uint64) string {
+	return fmt.Sprintf("session:%d", sessionID)
+}
+
+// IsAccessRevoked report if an access token was revoked by its id, by its owner or along with
+// its session
+func IsAccessRevoked(claims authentication.Claims) (bool, error) {
+	revoked, error := Default.IsRevoked(claims.TokenID, claims.UserID, claims.IssuedAt)
+
+	if error != nil || revoked || claims.SessionID == 0 {
+		return revoked, error
+	}
+
+	return Default.IsRevoked(SessionTokenID(claims.SessionID), claims.UserID, claims.IssuedAt)
+}
+
+// Default is the store consulted by the authentication middleware
+var Default Store = NewMemoryStore()
+
+// Configure select the store implementation set in config.RevocationStore
+func Configure(db *sql.DB, dialect database.Dialect) error {
+	switch config.RevocationStore {
+	case "", "memory":
+		Default = NewMemoryStore()
+	case "database", "mysql":
+		Default = NewSQLStore(db, dialect)
+	default:
+		return fmt.Errorf("Unknown revocation store %s", config.RevocationStore)
+	}
+
+	return nil
+}
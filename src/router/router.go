@@ -1,13 +1,14 @@
 package router
 
 import (
+	"api/src/container"
 	"api/src/router/routes"
 
 	"github.com/gorilla/mux"
 )
 
 // Generate retorna um novo router comas rotas configuradas
-func Generate() *mux.Router {
+func Generate(dependencies *container.Container) *mux.Router {
 	r := mux.NewRouter()
-	return routes.Configurate(r)
+	return routes.Configurate(r, dependencies)
 }
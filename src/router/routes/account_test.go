@@ -0,0 +1,281 @@
+package routes_test
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/models"
+	"api/src/security"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTwoFactorAuthentication(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/enroll"), joao.Token, nil), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/confirm"), maria.Token, models.MFACode{Code: "000000"}), http.StatusBadRequest)
+
+	response := api.request(http.MethodPost, userPath(maria.ID, "/mfa/enroll"), maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var enrollment models.MFAEnrollment
+	decode(t, response, &enrollment)
+
+	code, error := security.TOTPCode(enrollment.Secret, time.Now())
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	response = api.request(http.MethodPost, userPath(maria.ID, "/mfa/confirm"), maria.Token, models.MFACode{Code: code})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var recovery models.RecoveryCodes
+	decode(t, response, &recovery)
+
+	if len(recovery.RecoveryCodes) < 3 {
+		t.Fatalf("Expected recovery codes, got %v", recovery.RecoveryCodes)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/enroll"), maria.Token, nil), http.StatusConflict)
+
+	// The password alone is no longer enough
+	response = api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var challenge models.MFAChallenge
+	decode(t, response, &challenge)
+
+	if !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatalf("Expected a two factor challenge, got %+v", challenge)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/login/mfa", "", models.MFALogin{MFAToken: challenge.MFAToken, Code: "000000"}), http.StatusUnauthorized)
+
+	response = api.request(http.MethodPost, "/login/mfa", "", models.MFALogin{MFAToken: challenge.MFAToken, Code: recovery.RecoveryCodes[0]})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var login models.LoginResponse
+	decode(t, response, &login)
+
+	if login.AccessToken == "" || login.User.ID != maria.ID {
+		t.Fatalf("Unexpected login %+v", login)
+	}
+
+	// Recovery codes are single use
+	expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/disable"), maria.Token, models.MFACode{Code: recovery.RecoveryCodes[0]}), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/disable"), maria.Token, models.MFACode{Code: recovery.RecoveryCodes[1]}), http.StatusNoContent)
+
+	var plainLogin models.LoginResponse
+	decode(t, api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password}), &plainLogin)
+
+	if plainLogin.AccessToken == "" {
+		t.Fatal("Login still asks for a second factor after disabling it")
+	}
+}
+
+// enrollMFA start the enrollment of two factor authentication, returning the secret
+func (api *api) enrollMFA(user account) string {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, userPath(user.ID, "/mfa/enroll"), user.Token, nil)
+
+	expectStatus(api.t, response, http.StatusOK)
+
+	var enrollment models.MFAEnrollment
+	decode(api.t, response, &enrollment)
+
+	return enrollment.Secret
+}
+
+// currentCode returns the code the authenticator app shows for the secret
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+
+	code, error := security.TOTPCode(secret, time.Now())
+
+	if error != nil {
+		t.Fatal(error)
+	}
+
+	return code
+}
+
+// wrongCode returns a code the authenticator app does not show for the secret
+func wrongCode(t *testing.T, secret string) string {
+	if currentCode(t, secret) == "000000" {
+		return "111111"
+	}
+
+	return "000000"
+}
+
+func TestMFACodesAreThrottled(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	secret := api.enrollMFA(maria)
+
+	for attempt := 0; attempt < config.LoginAccountFailures; attempt++ {
+		expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/confirm"), maria.Token, models.MFACode{Code: wrongCode(t, secret)}), http.StatusBadRequest)
+	}
+
+	// Even the right code waits for the lockout to pass
+	expectStatus(t, api.request(http.MethodPost, userPath(maria.ID, "/mfa/confirm"), maria.Token, models.MFACode{Code: currentCode(t, secret)}), http.StatusTooManyRequests)
+
+	joao := api.signUp("joao")
+	secret = api.enrollMFA(joao)
+
+	expectStatus(t, api.request(http.MethodPost, userPath(joao.ID, "/mfa/confirm"), joao.Token, models.MFACode{Code: currentCode(t, secret)}), http.StatusOK)
+
+	for attempt := 0; attempt < config.LoginAccountFailures; attempt++ {
+		expectStatus(t, api.request(http.MethodPost, userPath(joao.ID, "/mfa/disable"), joao.Token, models.MFACode{Code: "00000000"}), http.StatusForbidden)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, userPath(joao.ID, "/mfa/disable"), joao.Token, models.MFACode{Code: currentCode(t, secret)}), http.StatusTooManyRequests)
+}
+
+func TestAPIKeys(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	keysPath := userPath(maria.ID, "/api-keys")
+
+	expectStatus(t, api.request(http.MethodPost, keysPath, joao.Token, models.APIKey{Name: "Script"}), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPost, keysPath, maria.Token, models.APIKey{Name: "Script", Scopes: []string{"everything"}}), http.StatusBadRequest)
+
+	response := api.request(http.MethodPost, keysPath, maria.Token,
+		models.APIKey{Name: "Script", Scopes: []string{authentication.ScopePublicationsRead}})
+
+	expectStatus(t, response, http.StatusCreated)
+
+	var apiKey models.APIKey
+	decode(t, response, &apiKey)
+
+	if apiKey.Key == "" {
+		t.Fatal("The key was not shown on creation")
+	}
+
+	withKey := func(method, path string) int {
+		request := httptest.NewRequest(method, path, nil)
+		request.Header.Set("X-API-Key", apiKey.Key)
+
+		return api.serve(request).Code
+	}
+
+	if status := withKey(http.MethodGet, "/publications"); status != http.StatusOK {
+		t.Fatalf("Api key could not read publications: %d", status)
+	}
+
+	if status := withKey(http.MethodGet, "/users"); status != http.StatusForbidden {
+		t.Fatalf("Api key reached a scope it does not have: %d", status)
+	}
+
+	response = api.request(http.MethodGet, keysPath, maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var apiKeys []models.APIKey
+	decode(t, response, &apiKeys)
+
+	if len(apiKeys) != 1 || apiKeys[0].ID != apiKey.ID || apiKeys[0].Key != "" {
+		t.Fatalf("Unexpected api keys %+v", apiKeys)
+	}
+
+	keyPath := fmt.Sprintf("%s/%d", keysPath, apiKey.ID)
+
+	expectStatus(t, api.request(http.MethodDelete, fmt.Sprintf("%s/%d", userPath(joao.ID, "/api-keys"), apiKey.ID), joao.Token, nil), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodDelete, keyPath, maria.Token, nil), http.StatusNoContent)
+
+	if status := withKey(http.MethodGet, "/publications"); status != http.StatusUnauthorized {
+		t.Fatalf("Revoked api key still authenticates: %d", status)
+	}
+}
+
+func TestSessions(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	other := api.login(maria.Email)
+	joao := api.signUp("joao")
+
+	sessionsPath := userPath(maria.ID, "/sessions")
+
+	expectStatus(t, api.request(http.MethodGet, sessionsPath, joao.Token, nil), http.StatusForbidden)
+
+	response := api.request(http.MethodGet, sessionsPath, maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var sessions []models.Session
+	decode(t, response, &sessions)
+
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %+v", sessions)
+	}
+
+	var current, remote models.Session
+
+	for _, session := range sessions {
+		if session.Current {
+			current = session
+		} else {
+			remote = session
+		}
+	}
+
+	if current.ID == 0 || remote.ID == 0 {
+		t.Fatalf("Expected the current session to be flagged %+v", sessions)
+	}
+
+	expectStatus(t, api.request(http.MethodDelete, fmt.Sprintf("%s/%d", sessionsPath, remote.ID), maria.Token, nil), http.StatusNoContent)
+	expectStatus(t, api.request(http.MethodDelete, fmt.Sprintf("%s/%d", sessionsPath, remote.ID), maria.Token, nil), http.StatusNotFound)
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", other.AccessToken, nil), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: other.RefreshToken}), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodGet, "/publications", maria.Token, nil), http.StatusOK)
+}
+
+func TestSessionActivity(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	lastSeen := func() time.Time {
+		var sessions []models.Session
+		decode(t, api.request(http.MethodGet, userPath(maria.ID, "/sessions"), maria.Token, nil), &sessions)
+
+		if len(sessions) != 1 {
+			t.Fatalf("Expected 1 session, got %+v", sessions)
+		}
+
+		return sessions[0].LastSeenAt
+	}
+
+	seen := lastSeen()
+
+	// Using the session within the interval leaves it alone
+	if !lastSeen().Equal(seen) {
+		t.Fatal("Session activity written twice within the interval")
+	}
+
+	config.SessionActivityInterval = 0
+
+	defer func() { config.SessionActivityInterval = time.Minute }()
+
+	if !lastSeen().After(seen) {
+		t.Fatal("Session activity not written after the interval")
+	}
+}
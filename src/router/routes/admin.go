@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"api/src/authentication"
+	"api/src/controllers"
+	"net/http"
+)
+
+var adminRoutes = []Route{
+	{
+		URI:                    "/admin/users",
+		Method:                 http.MethodGet,
+		Function:               controllers.AdminListUsers,
+		RequiresAuthentication: true,
+		Permissions:            []string{authentication.PermissionManageUsers},
+	},
+	{
+		URI:                    "/admin/users/{userId}/roles",
+		Method:                 http.MethodPut,
+		Function:               controllers.AdminUpdateRoles,
+		RequiresAuthentication: true,
+		Roles:                  []string{authentication.RoleAdmin},
+		Permissions:            []string{authentication.PermissionManageUsers},
+	},
+	{
+		URI:                    "/admin/users/{userId}/suspend",
+		Method:                 http.MethodPost,
+		Function:               controllers.AdminSuspendUser,
+		RequiresAuthentication: true,
+		Permissions:            []string{authentication.PermissionManageUsers},
+	},
+	{
+		URI:                    "/admin/users/{userId}/unsuspend",
+		Method:                 http.MethodPost,
+		Function:               controllers.AdminUnsuspendUser,
+		RequiresAuthentication: true,
+		Permissions:            []string{authentication.PermissionManageUsers},
+	},
+	{
+		URI:                    "/admin/users/{userId}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.AdminDeleteUser,
+		RequiresAuthentication: true,
+		Permissions:            []string{authentication.PermissionManageUsers},
+	},
+	{
+		URI:                    "/admin/publications",
+		Method:                 http.MethodGet,
+		Function:               controllers.AdminListPublications,
+		RequiresAuthentication: true,
+		Permissions:            []string{authentication.PermissionManagePublications},
+	},
+	{
+		URI:                    "/admin/publications/{publicationId}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.AdminDeletePublication,
+		RequiresAuthentication: true,
+		Permissions:            []string{authentication.PermissionManagePublications},
+	},
+	{
+		URI:                    "/admin/users/{userId}/impersonate",
+		Method:                 http.MethodPost,
+		Function:               controllers.AdminImpersonateUser,
+		RequiresAuthentication: true,
+		Roles:                  []string{authentication.RoleAdmin},
+		Permissions:            []string{authentication.PermissionImpersonateUsers},
+	},
+	{
+		URI:                    "/admin/database/stats",
+		Method:                 http.MethodGet,
+		Function:               controllers.AdminDatabaseStats,
+		RequiresAuthentication: true,
+		Roles:                  []string{authentication.RoleAdmin},
+	},
+}
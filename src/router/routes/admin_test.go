@@ -0,0 +1,233 @@
+package routes_test
+
+import (
+	"api/src/audit"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func adminUserPath(ID uint64, suffix string) string {
+	return fmt.Sprintf("/admin/users/%d%s", ID, suffix)
+}
+
+// impersonate returns an access token of the admin acting as the user
+func (api *api) impersonate(admin account, user account) string {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, adminUserPath(user.ID, "/impersonate"), admin.Token, nil)
+
+	expectStatus(api.t, response, http.StatusCreated)
+
+	var impersonation models.Impersonation
+	decode(api.t, response, &impersonation)
+
+	return impersonation.AccessToken
+}
+
+func TestAdminRoutesRequireTheAdminRole(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	routes := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/admin/users"},
+		{http.MethodPut, adminUserPath(maria.ID, "/roles")},
+		{http.MethodPost, adminUserPath(maria.ID, "/suspend")},
+		{http.MethodPost, adminUserPath(maria.ID, "/unsuspend")},
+		{http.MethodDelete, adminUserPath(maria.ID, "")},
+		{http.MethodGet, "/admin/publications"},
+		{http.MethodDelete, "/admin/publications/1"},
+		{http.MethodPost, adminUserPath(maria.ID, "/impersonate")},
+		{http.MethodGet, "/admin/database/stats"},
+	}
+
+	for _, route := range routes {
+		response := api.request(route.method, route.path, maria.Token, nil)
+
+		if response.Code != http.StatusForbidden {
+			t.Errorf("%s %s answered %d to a user without the admin role", route.method, route.path, response.Code)
+		}
+	}
+}
+
+func TestAdminListAndUpdateRoles(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+	maria := api.signUp("maria")
+
+	response := api.request(http.MethodGet, "/admin/users", admin.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var users []models.User
+	decode(t, response, &users)
+
+	if len(users) != 2 || len(users[0].Roles) != 2 || len(users[1].Roles) != 1 {
+		t.Fatalf("Unexpected users %+v", users)
+	}
+
+	rolesPath := adminUserPath(maria.ID, "/roles")
+
+	expectStatus(t, api.request(http.MethodPut, rolesPath, admin.Token, models.Roles{}), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPut, rolesPath, admin.Token, models.Roles{Roles: []string{"owner"}}), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPut, adminUserPath(admin.ID, "/roles"), admin.Token, models.Roles{Roles: []string{"user"}}), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPut, rolesPath, admin.Token, models.Roles{Roles: []string{"user", "admin"}}), http.StatusNoContent)
+
+	users = nil
+	decode(t, api.request(http.MethodGet, "/admin/users", admin.Token, nil), &users)
+
+	if len(users[1].Roles) != 2 {
+		t.Fatalf("Roles were not updated %+v", users[1])
+	}
+
+	// Tokens carrying the previous roles stop working
+	expectStatus(t, api.request(http.MethodGet, "/publications", maria.Token, nil), http.StatusUnauthorized)
+}
+
+func TestAdminSuspendUser(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+	maria := api.signUp("maria")
+
+	expectStatus(t, api.request(http.MethodPost, adminUserPath(maria.ID, "/suspend"), admin.Token, nil), http.StatusNoContent)
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", maria.Token, nil), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password}), http.StatusForbidden)
+
+	expectStatus(t, api.request(http.MethodPost, adminUserPath(maria.ID, "/unsuspend"), admin.Token, nil), http.StatusNoContent)
+
+	api.login(maria.Email)
+}
+
+func TestAdminDeleteUser(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+	maria := api.signUp("maria")
+
+	api.publish(maria, "Of a deleted user")
+
+	expectStatus(t, api.request(http.MethodDelete, adminUserPath(admin.ID, ""), admin.Token, nil), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodDelete, adminUserPath(maria.ID, ""), admin.Token, nil), http.StatusNoContent)
+
+	var publications []models.Publication
+	decode(t, api.request(http.MethodGet, "/admin/publications", admin.Token, nil), &publications)
+
+	if len(publications) != 0 {
+		t.Fatalf("Publications of a deleted user remain %+v", publications)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password}), http.StatusUnauthorized)
+}
+
+func TestAdminModeratePublications(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	first := api.publish(maria, "Of maria")
+	second := api.publish(joao, "Of joao")
+
+	response := api.request(http.MethodGet, "/admin/publications", admin.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var publications []models.Publication
+	decode(t, response, &publications)
+
+	if len(publications) != 2 || publications[0].ID != second || publications[1].ID != first {
+		t.Fatalf("Unexpected publications %+v", publications)
+	}
+
+	expectStatus(t, api.request(http.MethodDelete, fmt.Sprintf("/admin/publications/%d", first), admin.Token, nil), http.StatusNoContent)
+
+	if publication := api.publication(joao, first); publication.ID != 0 {
+		t.Fatalf("Moderated publication still found %+v", publication)
+	}
+}
+
+func TestAdminImpersonateUser(t *testing.T) {
+	api := newAPI(t)
+
+	writer := audit.NewMemoryWriter()
+	previous := audit.Default
+	audit.Default = writer
+	defer func() { audit.Default = previous }()
+
+	admin := api.signUpAdmin("admin")
+	other := api.signUpAdmin("other")
+	maria := api.signUp("maria")
+
+	expectStatus(t, api.request(http.MethodPost, adminUserPath(other.ID, "/impersonate"), admin.Token, nil), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPost, adminUserPath(999, "/impersonate"), admin.Token, nil), http.StatusNotFound)
+
+	response := api.request(http.MethodPost, adminUserPath(maria.ID, "/impersonate"), admin.Token, nil)
+
+	expectStatus(t, response, http.StatusCreated)
+
+	var impersonation models.Impersonation
+	decode(t, response, &impersonation)
+
+	if impersonation.UserID != maria.ID || impersonation.ActorID != admin.ID {
+		t.Fatalf("Unexpected impersonation %+v", impersonation)
+	}
+
+	expectStatus(t, api.request(http.MethodPost, "/publications", impersonation.AccessToken, models.Publication{Title: "By admin", Content: "Acting as maria"}), http.StatusCreated)
+
+	// Only the user can take sensitive actions
+	expectStatus(t, api.request(http.MethodDelete, userPath(maria.ID, ""), impersonation.AccessToken, nil), http.StatusForbidden)
+
+	// Setting another email would let the admin take the account over by resetting its password
+	expectStatus(t, api.request(http.MethodPut, userPath(maria.ID, ""), impersonation.AccessToken,
+		map[string]string{"name": "Maria", "nick": "maria", "email": "admin@devbook.test"}), http.StatusForbidden)
+
+	entries := writer.Entries()
+
+	if len(entries) != 4 || entries[0].ActorID != admin.ID || entries[0].UserID != maria.ID ||
+		entries[1].Path != "/publications" || entries[2].Status != http.StatusForbidden {
+		t.Fatalf("Unexpected audit entries %+v", entries)
+	}
+}
+
+func TestAdminImpersonationRefusedWhenNotAudited(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+	maria := api.signUp("maria")
+	token := api.impersonate(admin, maria)
+
+	// A worker that takes no more entries, like one shutting down
+	worker := audit.NewWorker(audit.NewMemoryWriter(), 1)
+	worker.Close()
+
+	previous := audit.Default
+	audit.Default = worker
+	defer func() { audit.Default = previous }()
+
+	expectStatus(t, api.request(http.MethodPost, "/publications", token, models.Publication{Title: "By admin", Content: "Acting as maria"}), http.StatusServiceUnavailable)
+
+	var publications []models.Publication
+	decode(t, api.request(http.MethodGet, fmt.Sprintf("/publications/%d/publications", maria.ID), maria.Token, nil), &publications)
+
+	if len(publications) != 0 {
+		t.Fatalf("Request ran without being audited %+v", publications)
+	}
+}
+
+func TestAdminDatabaseStatsWithoutDatabase(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+
+	// The memory store has no connection pool to report
+	expectStatus(t, api.request(http.MethodGet, "/admin/database/stats", admin.Token, nil), http.StatusInternalServerError)
+}
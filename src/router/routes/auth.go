@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var authRoutes = []Route{
+	{
+		URI:                    "/auth/refresh",
+		Method:                 http.MethodPost,
+		Function:               controllers.RefreshToken,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/.well-known/jwks.json",
+		Method:                 http.MethodGet,
+		Function:               controllers.JWKS,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/forgot-password",
+		Method:                 http.MethodPost,
+		Function:               controllers.ForgotPassword,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/reset-password",
+		Method:                 http.MethodPost,
+		Function:               controllers.ResetPassword,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/verify-email",
+		Method:                 http.MethodPost,
+		Function:               controllers.VerifyEmail,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/verify-email/resend",
+		Method:                 http.MethodPost,
+		Function:               controllers.ResendVerification,
+		RequiresAuthentication: true,
+	},
+	{
+		URI:                    "/auth/magic-link",
+		Method:                 http.MethodPost,
+		Function:               controllers.SendMagicLink,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/auth/magic-link/{token}",
+		Method:                 http.MethodGet,
+		Function:               controllers.MagicLinkLogin,
+		RequiresAuthentication: false,
+	},
+}
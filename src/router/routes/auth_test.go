@@ -0,0 +1,293 @@
+package routes_test
+
+import (
+	"api/src/authentication"
+	"api/src/config"
+	"api/src/mailer"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLogin(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.register("maria")
+
+	login := api.login("MARIA@devbook.test")
+
+	if login.AccessToken == "" || login.RefreshToken == "" || login.User.ID != maria.ID || login.User.Nick != "maria" {
+		t.Fatalf("Unexpected login %+v", login)
+	}
+
+	response := api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: "wrong"})
+
+	expectStatus(t, response, http.StatusUnauthorized)
+
+	response = api.request(http.MethodPost, "/login", "", models.Credentials{Email: "nobody@devbook.test", Password: password})
+
+	expectStatus(t, response, http.StatusUnauthorized)
+}
+
+func TestLoginAddressLockoutIgnoresForgedForwardedFor(t *testing.T) {
+	config.TrustProxyHeaders = true
+	config.LoginAddressFailures = 2
+
+	defer func() {
+		config.TrustProxyHeaders = false
+		config.LoginAddressFailures = 20
+	}()
+
+	api := newAPI(t)
+
+	// attempt log in as another unknown user each time, from a new address forged by the client
+	// on the left of the one the proxy appended
+	attempt := func(try int) *httptest.ResponseRecorder {
+		request := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(
+			fmt.Sprintf(`{"email": "nobody%d@devbook.test", "password": "wrong"}`, try)))
+		request.Header.Set("Content-Type", "application/json")
+		request.Header.Set("X-Forwarded-For", fmt.Sprintf("198.51.100.%d, 203.0.113.7", try))
+
+		return api.serve(request)
+	}
+
+	attempt(1)
+	attempt(2)
+
+	expectStatus(t, attempt(3), http.StatusTooManyRequests)
+}
+
+func TestLoginAnswersPlainTextWhenPreferred(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.register("maria")
+
+	request := httptest.NewRequest(http.MethodPost, "/login",
+		strings.NewReader(`{"email":"`+maria.Email+`","password":"`+password+`"}`))
+	request.Header.Set("Accept", "text/plain")
+
+	response := api.serve(request)
+
+	expectStatus(t, response, http.StatusOK)
+
+	if _, error := authentication.ParseToken(response.Body.String(), authentication.TokenTypeAccess); error != nil {
+		t.Fatalf("Body is not an access token: %v", error)
+	}
+}
+
+func TestLoginWithScope(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.register("maria")
+	scope := authentication.ScopePublicationsRead
+
+	response := api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password, Scope: &scope})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var login models.LoginResponse
+	decode(t, response, &login)
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", login.AccessToken, nil), http.StatusOK)
+	expectStatus(t, api.request(http.MethodGet, "/users", login.AccessToken, nil), http.StatusForbidden)
+
+	invalid := "everything"
+
+	response = api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password, Scope: &invalid})
+
+	expectStatus(t, response, http.StatusBadRequest)
+}
+
+func TestRefreshToken(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	response := api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var tokens models.Tokens
+	decode(t, response, &tokens)
+
+	if tokens.RefreshToken == "" || tokens.RefreshToken == maria.RefreshToken {
+		t.Fatalf("Refresh token was not rotated %+v", tokens)
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", tokens.AccessToken, nil), http.StatusOK)
+
+	// Reusing a rotated token ends the whole family
+	response = api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken})
+
+	expectStatus(t, response, http.StatusUnauthorized)
+
+	response = api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: tokens.RefreshToken})
+
+	expectStatus(t, response, http.StatusUnauthorized)
+
+	// and its session, with the access tokens already issued in it
+	expectStatus(t, api.request(http.MethodGet, "/publications", tokens.AccessToken, nil), http.StatusUnauthorized)
+
+	other := api.login(maria.Email)
+
+	var sessions []models.Session
+	decode(t, api.request(http.MethodGet, userPath(maria.ID, "/sessions"), other.AccessToken, nil), &sessions)
+
+	if len(sessions) != 1 {
+		t.Fatalf("Expected only the new session to be active, got %+v", sessions)
+	}
+}
+
+func TestRefreshTokenKeptWhenRotationFails(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	api.failRefreshTokens(true)
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken}), http.StatusInternalServerError)
+
+	api.failRefreshTokens(false)
+
+	// The retry is not taken for a replay, the token was not spent
+	response := api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var tokens models.Tokens
+	decode(t, response, &tokens)
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: tokens.RefreshToken}), http.StatusOK)
+}
+
+func TestLogout(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	expectStatus(t, api.request(http.MethodPost, "/logout", maria.Token, models.RefreshRequest{RefreshToken: maria.RefreshToken}), http.StatusNoContent)
+	expectStatus(t, api.request(http.MethodGet, "/publications", maria.Token, nil), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken}), http.StatusUnauthorized)
+}
+
+func TestLogoutAll(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	other := api.login(maria.Email)
+
+	expectStatus(t, api.request(http.MethodPost, "/logout/all", maria.Token, nil), http.StatusNoContent)
+	expectStatus(t, api.request(http.MethodGet, "/publications", other.AccessToken, nil), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: other.RefreshToken}), http.StatusUnauthorized)
+
+	// Logging in again right away, likely in the same second, is not revoked
+	again := api.login(maria.Email)
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", again.AccessToken, nil), http.StatusOK)
+}
+
+func TestJWKS(t *testing.T) {
+	api := newAPI(t)
+
+	response := api.request(http.MethodGet, "/.well-known/jwks.json", "", nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var keys authentication.JSONWebKeySet
+	decode(t, response, &keys)
+}
+
+func TestPasswordReset(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/forgot-password", "", models.ForgotPassword{Email: "nobody@devbook.test"}), http.StatusAccepted)
+	expectStatus(t, api.request(http.MethodPost, "/auth/forgot-password", "", models.ForgotPassword{Email: maria.Email}), http.StatusAccepted)
+
+	token := api.lastToken(maria.Email, "Password reset")
+	newPassword := "Res3t-Password"
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/reset-password", "", models.ResetPassword{Token: "invalid", NewPassword: newPassword}), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPost, "/auth/reset-password", "", models.ResetPassword{Token: token, NewPassword: newPassword}), http.StatusNoContent)
+	expectStatus(t, api.request(http.MethodPost, "/auth/reset-password", "", models.ResetPassword{Token: token, NewPassword: newPassword}), http.StatusBadRequest)
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken}), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: newPassword}), http.StatusOK)
+}
+
+func TestPasswordResetUndeliveredLooksLikeUnknownEmail(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	// A queue that refuses every message, like a full one
+	queue := mailer.NewQueue(api.outbox, 1)
+	queue.Close()
+	mailer.Default = queue
+
+	defer func() { mailer.Default = api.outbox }()
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/forgot-password", "", models.ForgotPassword{Email: "nobody@devbook.test"}), http.StatusAccepted)
+	expectStatus(t, api.request(http.MethodPost, "/auth/forgot-password", "", models.ForgotPassword{Email: maria.Email}), http.StatusAccepted)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	// The registration email was sent less than the resend interval ago
+	expectStatus(t, api.request(http.MethodPost, "/auth/verify-email/resend", maria.Token, nil), http.StatusTooManyRequests)
+
+	token := api.lastToken(maria.Email, "Confirm your email")
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/verify-email", "", models.VerifyEmail{Token: "invalid"}), http.StatusBadRequest)
+	expectStatus(t, api.request(http.MethodPost, "/auth/verify-email", "", models.VerifyEmail{Token: token}), http.StatusNoContent)
+	expectStatus(t, api.request(http.MethodPost, "/auth/verify-email", "", models.VerifyEmail{Token: token}), http.StatusBadRequest)
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/verify-email/resend", maria.Token, nil), http.StatusConflict)
+}
+
+func TestMagicLink(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.register("maria")
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/magic-link", "", models.MagicLinkRequest{Email: "nobody@devbook.test"}), http.StatusAccepted)
+	expectStatus(t, api.request(http.MethodPost, "/auth/magic-link", "", models.MagicLinkRequest{Email: maria.Email}), http.StatusAccepted)
+
+	token := api.lastToken(maria.Email, "Your login link")
+
+	response := api.request(http.MethodGet, "/auth/magic-link/"+token, "", nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var login models.LoginResponse
+	decode(t, response, &login)
+
+	if login.AccessToken == "" || login.User.ID != maria.ID {
+		t.Fatalf("Unexpected login %+v", login)
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/auth/magic-link/"+token, "", nil), http.StatusUnauthorized)
+}
+
+func TestMagicLinkUndeliveredLooksLikeUnknownEmail(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.register("maria")
+
+	// A queue that refuses every message, like a full one
+	queue := mailer.NewQueue(api.outbox, 1)
+	queue.Close()
+	mailer.Default = queue
+
+	defer func() { mailer.Default = api.outbox }()
+
+	expectStatus(t, api.request(http.MethodPost, "/auth/magic-link", "", models.MagicLinkRequest{Email: "nobody@devbook.test"}), http.StatusAccepted)
+	expectStatus(t, api.request(http.MethodPost, "/auth/magic-link", "", models.MagicLinkRequest{Email: maria.Email}), http.StatusAccepted)
+}
@@ -11,3 +11,26 @@ var loginRoute = Route{
 	Function:               controllers.Login,
 	RequiresAuthentication: false,
 }
+
+var loginMFARoute = Route{
+	URI:                    "/login/mfa",
+	Method:                 http.MethodPost,
+	Function:               controllers.LoginMFA,
+	RequiresAuthentication: false,
+}
+
+var logoutRoutes = []Route{
+	{
+		URI:                    "/logout",
+		Method:                 http.MethodPost,
+		Function:               controllers.Logout,
+		RequiresAuthentication: true,
+	},
+	{
+		URI:                    "/logout/all",
+		Method:                 http.MethodPost,
+		Function:               controllers.LogoutAll,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+}
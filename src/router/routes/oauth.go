@@ -0,0 +1,61 @@
+package routes
+
+import (
+	"api/src/controllers"
+	"net/http"
+)
+
+var oauthRoutes = []Route{
+	{
+		URI:                    "/oauth/clients",
+		Method:                 http.MethodPost,
+		Function:               controllers.CreateOAuthClient,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/oauth/clients",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListOAuthClients,
+		RequiresAuthentication: true,
+	},
+	{
+		URI:                    "/oauth/clients/{clientId}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.DeleteOAuthClient,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/oauth/authorize",
+		Method:                 http.MethodGet,
+		Function:               controllers.OAuthConsent,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/oauth/authorize",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthAuthorize,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/oauth/token",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthToken,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/oauth/introspect",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthIntrospect,
+		RequiresAuthentication: false,
+	},
+	{
+		URI:                    "/oauth/revoke",
+		Method:                 http.MethodPost,
+		Function:               controllers.OAuthRevoke,
+		RequiresAuthentication: false,
+	},
+}
@@ -0,0 +1,362 @@
+package routes_test
+
+import (
+	"api/src/authentication"
+	"api/src/models"
+	"api/src/security"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+const redirectURI = "https://client.test/callback"
+
+// createClient register an oauth client owned by the account
+func (api *api) createClient(owner account, confidential bool) models.OAuthClient {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, "/oauth/clients", owner.Token, models.OAuthClient{
+		Name:         "Client",
+		Confidential: confidential,
+		RedirectURIs: []string{redirectURI},
+		Scopes:       []string{authentication.ScopePublicationsRead, authentication.ScopeUsersRead},
+	})
+
+	expectStatus(api.t, response, http.StatusCreated)
+
+	var client models.OAuthClient
+	decode(api.t, response, &client)
+
+	return client
+}
+
+// authorize approve the client on behalf of the account and returns the authorization code
+func (api *api) authorize(user account, client models.OAuthClient, verifier string) string {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, "/oauth/authorize", user.Token, models.OAuthAuthorization{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         redirectURI,
+		Scope:               authentication.ScopePublicationsRead,
+		State:               "xyz",
+		CodeChallenge:       security.PKCEChallenge(verifier),
+		CodeChallengeMethod: security.PKCEMethodS256,
+		Approve:             true,
+	})
+
+	expectStatus(api.t, response, http.StatusOK)
+
+	var redirect models.OAuthRedirect
+	decode(api.t, response, &redirect)
+
+	address, error := url.Parse(redirect.RedirectURI)
+
+	if error != nil {
+		api.t.Fatal(error)
+	}
+
+	if address.Query().Get("state") != "xyz" || address.Query().Get("code") == "" {
+		api.t.Fatalf("Unexpected redirect %s", redirect.RedirectURI)
+	}
+
+	return address.Query().Get("code")
+}
+
+func TestOAuthClients(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	expectStatus(t, api.request(http.MethodPost, "/oauth/clients", maria.Token, models.OAuthClient{Name: "Client"}), http.StatusBadRequest)
+
+	confidential := api.createClient(maria, true)
+	public := api.createClient(maria, false)
+
+	if confidential.ClientSecret == "" || public.ClientSecret != "" {
+		t.Fatalf("Only confidential clients have a secret %+v %+v", confidential, public)
+	}
+
+	response := api.request(http.MethodGet, "/oauth/clients", maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var clients []models.OAuthClient
+	decode(t, response, &clients)
+
+	if len(clients) != 2 || clients[0].ClientSecret != "" {
+		t.Fatalf("Unexpected clients %+v", clients)
+	}
+
+	expectStatus(t, api.request(http.MethodDelete, "/oauth/clients/"+public.ClientID, joao.Token, nil), http.StatusNotFound)
+	expectStatus(t, api.request(http.MethodDelete, "/oauth/clients/"+public.ClientID, maria.Token, nil), http.StatusNoContent)
+
+	clients = nil
+	decode(t, api.request(http.MethodGet, "/oauth/clients", maria.Token, nil), &clients)
+
+	if len(clients) != 1 || clients[0].ClientID != confidential.ClientID {
+		t.Fatalf("Unexpected clients after deletion %+v", clients)
+	}
+}
+
+func TestOAuthConsent(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	client := api.createClient(maria, false)
+
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {client.ClientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {authentication.ScopeUsersRead},
+		"code_challenge":        {security.PKCEChallenge("verifier")},
+		"code_challenge_method": {security.PKCEMethodS256},
+	}
+
+	response := api.request(http.MethodGet, "/oauth/authorize?"+query.Encode(), maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var consent models.OAuthConsent
+	decode(t, response, &consent)
+
+	if consent.ClientName != "Client" || len(consent.Scopes) != 1 || consent.Scopes[0] != authentication.ScopeUsersRead {
+		t.Fatalf("Unexpected consent %+v", consent)
+	}
+
+	query.Set("redirect_uri", "https://attacker.test/callback")
+
+	expectStatus(t, api.request(http.MethodGet, "/oauth/authorize?"+query.Encode(), maria.Token, nil), http.StatusBadRequest)
+}
+
+func TestOAuthAuthorizeDenied(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	client := api.createClient(maria, false)
+
+	response := api.request(http.MethodPost, "/oauth/authorize", maria.Token, models.OAuthAuthorization{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         redirectURI,
+		CodeChallenge:       security.PKCEChallenge("verifier"),
+		CodeChallengeMethod: security.PKCEMethodS256,
+	})
+
+	expectStatus(t, response, http.StatusOK)
+
+	var redirect models.OAuthRedirect
+	decode(t, response, &redirect)
+
+	if redirect.RedirectURI != redirectURI+"?error=access_denied" {
+		t.Fatalf("Unexpected redirect %s", redirect.RedirectURI)
+	}
+}
+
+func TestOAuthAuthorizationCodeFlow(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	client := api.createClient(maria, true)
+	verifier := "a-verifier-long-enough-to-be-accepted-by-the-server"
+
+	code := api.authorize(maria, client, verifier)
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {client.ClientID},
+		"client_secret": {"wrong"},
+	}
+
+	expectStatus(t, api.form("/oauth/token", exchange), http.StatusUnauthorized)
+
+	exchange.Set("client_secret", client.ClientSecret)
+
+	response := api.form("/oauth/token", exchange)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var tokens models.OAuthTokens
+	decode(t, response, &tokens)
+
+	if tokens.Scope != authentication.ScopePublicationsRead || tokens.RefreshToken == "" {
+		t.Fatalf("Unexpected tokens %+v", tokens)
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", tokens.AccessToken, nil), http.StatusOK)
+	expectStatus(t, api.request(http.MethodGet, "/users", tokens.AccessToken, nil), http.StatusForbidden)
+
+	// Codes are exchanged only once
+	expectStatus(t, api.form("/oauth/token", exchange), http.StatusBadRequest)
+
+	authenticated := func(values url.Values) url.Values {
+		values.Set("client_id", client.ClientID)
+		values.Set("client_secret", client.ClientSecret)
+
+		return values
+	}
+
+	introspect := func(token string) models.OAuthIntrospection {
+		response := api.form("/oauth/introspect", authenticated(url.Values{"token": {token}}))
+
+		expectStatus(t, response, http.StatusOK)
+
+		var introspection models.OAuthIntrospection
+		decode(t, response, &introspection)
+
+		return introspection
+	}
+
+	// The replay revoked the tokens issued for the code
+	if introspect(tokens.RefreshToken).Active {
+		t.Fatal("Refresh token still active after the code was replayed")
+	}
+
+	if introspect(tokens.AccessToken).Active {
+		t.Fatal("Access token still active after the code was replayed")
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", tokens.AccessToken, nil), http.StatusUnauthorized)
+
+	code = api.authorize(maria, client, verifier)
+	exchange.Set("code", code)
+
+	decode(t, api.form("/oauth/token", exchange), &tokens)
+
+	if introspection := introspect(tokens.AccessToken); !introspection.Active || introspection.ClientID != client.ClientID {
+		t.Fatalf("Unexpected introspection %+v", introspection)
+	}
+
+	response = api.form("/oauth/token", authenticated(url.Values{"grant_type": {"refresh_token"}, "refresh_token": {tokens.RefreshToken}}))
+
+	expectStatus(t, response, http.StatusOK)
+
+	var refreshed models.OAuthTokens
+	decode(t, response, &refreshed)
+
+	expectStatus(t, api.form("/oauth/revoke", authenticated(url.Values{"token": {refreshed.AccessToken}})), http.StatusOK)
+
+	if introspect(refreshed.AccessToken).Active {
+		t.Fatal("Revoked access token still active")
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", refreshed.AccessToken, nil), http.StatusUnauthorized)
+
+	expectStatus(t, api.form("/oauth/token", authenticated(url.Values{"grant_type": {"password"}})), http.StatusBadRequest)
+}
+
+func TestOAuthAuthorizeImpersonated(t *testing.T) {
+	api := newAPI(t)
+
+	admin := api.signUpAdmin("admin")
+	maria := api.signUp("maria")
+	client := api.createClient(admin, false)
+
+	token := api.impersonate(admin, maria)
+
+	// Tokens issued on the approval would act as maria without a trace of the admin
+	response := api.request(http.MethodPost, "/oauth/authorize", token, models.OAuthAuthorization{
+		ResponseType:        "code",
+		ClientID:            client.ClientID,
+		RedirectURI:         redirectURI,
+		Scope:               authentication.ScopePublicationsRead,
+		CodeChallenge:       security.PKCEChallenge("verifier"),
+		CodeChallengeMethod: security.PKCEMethodS256,
+		Approve:             true,
+	})
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	expectStatus(t, api.request(http.MethodPost, "/oauth/clients", token, models.OAuthClient{
+		Name:         "Client",
+		RedirectURIs: []string{redirectURI},
+	}), http.StatusForbidden)
+
+	owned := api.createClient(maria, false)
+
+	expectStatus(t, api.request(http.MethodDelete, "/oauth/clients/"+owned.ClientID, token, nil), http.StatusForbidden)
+}
+
+func TestOAuthCodeKeptWhenIssuingFails(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	client := api.createClient(maria, false)
+	verifier := "a-verifier-long-enough-to-be-accepted-by-the-server"
+
+	exchange := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {api.authorize(maria, client, verifier)},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {client.ClientID},
+	}
+
+	api.failRefreshTokens(true)
+
+	expectStatus(t, api.form("/oauth/token", exchange), http.StatusInternalServerError)
+
+	api.failRefreshTokens(false)
+
+	response := api.form("/oauth/token", exchange)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var tokens models.OAuthTokens
+	decode(t, response, &tokens)
+
+	refresh := url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {tokens.RefreshToken},
+		"client_id":     {client.ClientID},
+	}
+
+	api.failRefreshTokens(true)
+
+	expectStatus(t, api.form("/oauth/token", refresh), http.StatusInternalServerError)
+
+	api.failRefreshTokens(false)
+
+	expectStatus(t, api.form("/oauth/token", refresh), http.StatusOK)
+}
+
+func TestOAuthIntrospectRevokedSession(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	client := api.createClient(maria, false)
+	verifier := "a-verifier-long-enough-to-be-accepted-by-the-server"
+
+	var tokens models.OAuthTokens
+	decode(t, api.form("/oauth/token", url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {api.authorize(maria, client, verifier)},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+		"client_id":     {client.ClientID},
+	}), &tokens)
+
+	var sessions []models.Session
+	decode(t, api.request(http.MethodGet, userPath(maria.ID, "/sessions"), maria.Token, nil), &sessions)
+
+	for _, session := range sessions {
+		if session.ClientID == client.ClientID {
+			expectStatus(t, api.request(http.MethodDelete, fmt.Sprintf("%s/%d", userPath(maria.ID, "/sessions"), session.ID), maria.Token, nil), http.StatusNoContent)
+		}
+	}
+
+	expectStatus(t, api.request(http.MethodGet, "/publications", tokens.AccessToken, nil), http.StatusUnauthorized)
+
+	var introspection models.OAuthIntrospection
+	decode(t, api.form("/oauth/introspect", url.Values{"token": {tokens.AccessToken}, "client_id": {client.ClientID}}), &introspection)
+
+	if introspection.Active {
+		t.Fatal("Access token of a revoked session still active")
+	}
+}
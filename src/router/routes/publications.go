@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"api/src/authentication"
 	"api/src/controllers"
 	"net/http"
 )
@@ -11,47 +12,55 @@ var publicationsRoutes = []Route{
 		Method:                 http.MethodPost,
 		Function:               controllers.CreatePublication,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsWrite},
 	},
 	{
 		URI:                    "/publications",
 		Method:                 http.MethodGet,
 		Function:               controllers.ListPublications,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsRead},
 	},
 	{
 		URI:                    "/publications/{publicationId}",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetPublication,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsRead},
 	},
 	{
 		URI:                    "/publications/{publicationId}",
 		Method:                 http.MethodPut,
 		Function:               controllers.UpdatePublication,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsWrite},
 	},
 	{
 		URI:                    "/publications/{publicationId}",
 		Method:                 http.MethodDelete,
 		Function:               controllers.DeletePublication,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsWrite},
 	},
 	{
 		URI:                    "/publications/{userId}/publications",
 		Method:                 http.MethodGet,
 		Function:               controllers.ListUserPublications,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsRead},
 	},
 	{
 		URI:                    "/publications/{publicationId}/like",
 		Method:                 http.MethodPost,
 		Function:               controllers.LikePublication,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsWrite},
 	},
 	{
 		URI:                    "/publications/{publicationId}/unlike",
 		Method:                 http.MethodPost,
 		Function:               controllers.UnLikePublication,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopePublicationsWrite},
 	},
 }
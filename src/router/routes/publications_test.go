@@ -0,0 +1,162 @@
+package routes_test
+
+import (
+	"api/src/config"
+	"api/src/models"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// publish create a publication and returns its id
+func (api *api) publish(author account, title string) uint64 {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, "/publications", author.Token, models.Publication{Title: title, Content: "Content of " + title})
+
+	expectStatus(api.t, response, http.StatusCreated)
+
+	var ID uint64
+	decode(api.t, response, &ID)
+
+	return ID
+}
+
+// publication get a publication through the api
+func (api *api) publication(reader account, ID uint64) models.Publication {
+	api.t.Helper()
+
+	response := api.request(http.MethodGet, publicationPath(ID, ""), reader.Token, nil)
+
+	expectStatus(api.t, response, http.StatusOK)
+
+	var publication models.Publication
+	decode(api.t, response, &publication)
+
+	return publication
+}
+
+func publicationPath(ID uint64, suffix string) string {
+	return fmt.Sprintf("/publications/%d%s", ID, suffix)
+}
+
+func TestCreateAndGetPublication(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	response := api.request(http.MethodPost, "/publications", maria.Token, models.Publication{Title: "Empty"})
+
+	expectStatus(t, response, http.StatusBadRequest)
+
+	ID := api.publish(maria, "  First  ")
+
+	publication := api.publication(maria, ID)
+
+	if publication.Title != "First" || publication.AuthorID != maria.ID || publication.AuthorNick != "maria" || publication.Likes != 0 {
+		t.Fatalf("Unexpected publication %+v", publication)
+	}
+}
+
+func TestPublishRequiringVerifiedEmail(t *testing.T) {
+	api := newAPI(t)
+
+	config.RequireVerifiedEmailToPost = true
+	defer func() { config.RequireVerifiedEmailToPost = false }()
+
+	maria := api.signUp("maria")
+
+	response := api.request(http.MethodPost, "/publications", maria.Token, models.Publication{Title: "Title", Content: "Content"})
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	response = api.request(http.MethodPost, "/auth/verify-email", "",
+		models.VerifyEmail{Token: api.lastToken(maria.Email, "Confirm your email")})
+
+	expectStatus(t, response, http.StatusNoContent)
+
+	api.publish(maria, "Verified")
+}
+
+func TestListPublications(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+	ana := api.signUp("ana")
+
+	first := api.publish(maria, "First of maria")
+	api.publish(joao, "First of joao")
+	api.publish(ana, "First of ana")
+	second := api.publish(maria, "Second of maria")
+
+	expectStatus(t, api.request(http.MethodPost, userPath(joao.ID, "/follow"), maria.Token, nil), http.StatusNoContent)
+
+	var feed []models.Publication
+	decode(t, api.request(http.MethodGet, "/publications", maria.Token, nil), &feed)
+
+	if len(feed) != 3 || feed[0].ID != second || feed[2].ID != first {
+		t.Fatalf("Feed should have the publications of maria and joao, newest first: %+v", feed)
+	}
+
+	for _, publication := range feed {
+		if publication.AuthorID == ana.ID {
+			t.Fatalf("Feed has a publication of a user not followed %+v", publication)
+		}
+	}
+
+	var authored []models.Publication
+	decode(t, api.request(http.MethodGet, fmt.Sprintf("/publications/%d/publications", maria.ID), ana.Token, nil), &authored)
+
+	if len(authored) != 2 || authored[0].ID != first || authored[1].ID != second || authored[0].AuthorNick != "maria" {
+		t.Fatalf("Unexpected publications of maria %+v", authored)
+	}
+}
+
+func TestUpdateAndDeletePublication(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	ID := api.publish(maria, "Draft")
+	changes := models.Publication{Title: "Final", Content: "Reviewed"}
+
+	expectStatus(t, api.request(http.MethodPut, publicationPath(ID, ""), joao.Token, changes), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodPut, publicationPath(ID, ""), maria.Token, changes), http.StatusNoContent)
+
+	if publication := api.publication(joao, ID); publication.Title != "Final" || publication.Content != "Reviewed" {
+		t.Fatalf("Publication was not updated %+v", publication)
+	}
+
+	expectStatus(t, api.request(http.MethodDelete, publicationPath(ID, ""), joao.Token, nil), http.StatusForbidden)
+	expectStatus(t, api.request(http.MethodDelete, publicationPath(ID, ""), maria.Token, nil), http.StatusNoContent)
+
+	if publication := api.publication(maria, ID); publication.ID != 0 {
+		t.Fatalf("Deleted publication still found %+v", publication)
+	}
+}
+
+func TestLikePublication(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	ID := api.publish(maria, "Liked")
+
+	expectStatus(t, api.request(http.MethodPost, publicationPath(ID, "/like"), joao.Token, nil), http.StatusNoContent)
+	expectStatus(t, api.request(http.MethodPost, publicationPath(ID, "/like"), maria.Token, nil), http.StatusNoContent)
+
+	if likes := api.publication(maria, ID).Likes; likes != 2 {
+		t.Fatalf("Expected 2 likes, got %d", likes)
+	}
+
+	for range []int{1, 2, 3} {
+		expectStatus(t, api.request(http.MethodPost, publicationPath(ID, "/unlike"), joao.Token, nil), http.StatusNoContent)
+	}
+
+	if likes := api.publication(maria, ID).Likes; likes != 0 {
+		t.Fatalf("Likes went below zero: %d", likes)
+	}
+}
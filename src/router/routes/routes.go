@@ -1,6 +1,9 @@
 package routes
 
 import (
+	"api/src/config"
+	"api/src/container"
+	"api/src/controllers"
 	"api/src/middlewares"
 	"net/http"
 
@@ -13,26 +16,56 @@ type Route struct {
 	Method                 string
 	Function               func(http.ResponseWriter, *http.Request)
 	RequiresAuthentication bool
+	// Roles the user needs any of, empty for every authenticated user
+	Roles []string
+	// Permissions the roles of the user must grant
+	Permissions []string
+	// Scopes a restricted credential must have, without them only unrestricted credentials reach the route
+	Scopes []string
+	// Sensitive routes cannot be reached by admins impersonating the user
+	Sensitive bool
 }
 
-// Configurate insert all the routes
-func Configurate(r *mux.Router) *mux.Router {
+// Configurate insert all the routes, handled with the shared dependencies
+func Configurate(r *mux.Router, dependencies *container.Container) *mux.Router {
+	controllers.Inject(dependencies)
+	middlewares.Inject(dependencies)
+
 	routes := usersRoutes
-	routes = append(routes, loginRoute)
+	routes = append(routes, loginRoute, loginMFARoute)
+	routes = append(routes, logoutRoutes...)
+	routes = append(routes, authRoutes...)
 	routes = append(routes, publicationsRoutes...)
+	routes = append(routes, adminRoutes...)
+	routes = append(routes, oauthRoutes...)
 
 	for _, route := range routes {
+		function := route.Function
 
-		if route.RequiresAuthentication {
+		if len(route.Roles) > 0 || len(route.Permissions) > 0 {
+			function = middlewares.Authorization(route.Roles, route.Permissions, function)
+		}
+
+		if route.Sensitive {
+			function = middlewares.NotImpersonated(function)
+		}
 
-			r.HandleFunc(route.URI,
-				middlewares.Logger(middlewares.Authentication(route.Function)),
-			).Methods(route.Method)
+		if route.RequiresAuthentication {
+			function = middlewares.Authentication(middlewares.Scopes(route.Scopes, function))
+		}
 
-		} else {
-			r.HandleFunc(route.URI, middlewares.Logger(route.Function)).Methods(route.Method)
+		if config.CookieAuth && changesState(route.Method) {
+			function = middlewares.CSRF(function)
 		}
+
+		r.HandleFunc(route.URI, middlewares.Logger(middlewares.Timeout(function))).Methods(route.Method)
 	}
 
 	return r
 }
+
+// changesState report if requests of the method can change something, those are the
+// requests other sites could forge
+func changesState(method string) bool {
+	return method != http.MethodGet && method != http.MethodHead && method != http.MethodOptions
+}
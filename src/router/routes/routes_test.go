@@ -0,0 +1,347 @@
+package routes_test
+
+import (
+	"api/src/config"
+	"api/src/container"
+	"api/src/mailer"
+	"api/src/models"
+	"api/src/repositories"
+	"api/src/repositories/memory"
+	"api/src/revocation"
+	"api/src/router/routes"
+	"api/src/security"
+	"api/src/throttling"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// The store must keep implementing every repository the controllers use
+var _ repositories.Store = memory.NewStore()
+
+const password = "Str0ng-Passw0rd"
+
+var mailedToken = regexp.MustCompile(`token=(\S+)`)
+
+func TestMain(m *testing.M) {
+	config.SecretKey = []byte("routes test secret")
+	config.PublicURL = "https://devbook.test"
+
+	// Passwords are hashed on every registration, the cheapest hash keeps the suite fast
+	security.CurrentHasher = security.BcryptHasher{Cost: bcrypt.MinCost}
+
+	log.SetOutput(ioutil.Discard)
+
+	os.Exit(m.Run())
+}
+
+// api is the router of every route handled with an in memory store
+type api struct {
+	t       *testing.T
+	handler http.Handler
+	store   *memory.Store
+	outbox  *mailer.MemorySender
+}
+
+// account is a registered user along with the access token of its login
+type account struct {
+	ID    uint64
+	Nick  string
+	Email string
+	Token string
+	// RefreshToken of the login, its family is the session of the account
+	RefreshToken string
+}
+
+// newAPI returns an api with an empty store and fresh throttling, revocation and outbox
+func newAPI(t *testing.T) *api {
+	t.Helper()
+
+	store := memory.NewStore()
+	outbox := mailer.NewMemorySender()
+
+	mailer.Default = outbox
+	revocation.Default = revocation.NewMemoryStore()
+	throttling.Configure()
+
+	return &api{
+		t:       t,
+		handler: routes.Configurate(mux.NewRouter(), &container.Container{Store: store}),
+		store:   store,
+		outbox:  outbox,
+	}
+}
+
+// failRefreshTokens make every write of refresh tokens from now on fail, or stop it
+func (api *api) failRefreshTokens(fail bool) {
+	var store repositories.Store = api.store
+
+	if fail {
+		store = failingStore{api.store}
+	}
+
+	api.handler = routes.Configurate(mux.NewRouter(), &container.Container{Store: store})
+}
+
+// failingStore is a store whose units of work cannot write refresh tokens
+type failingStore struct {
+	*memory.Store
+}
+
+func (store failingStore) WithTx(ctx context.Context, work func(repositories.UnitOfWork) error) error {
+	return store.Store.WithTx(ctx, func(unit repositories.UnitOfWork) error {
+		return work(failingUnit{unit})
+	})
+}
+
+type failingUnit struct {
+	repositories.UnitOfWork
+}
+
+func (unit failingUnit) RefreshTokens() repositories.RefreshTokenRepository {
+	return failingRefreshTokens{unit.UnitOfWork.RefreshTokens()}
+}
+
+type failingRefreshTokens struct {
+	repositories.RefreshTokenRepository
+}
+
+func (repository failingRefreshTokens) Create(ctx context.Context, token models.RefreshToken) (uint64, error) {
+	return 0, errors.New("Refresh tokens cannot be stored")
+}
+
+func (repository failingRefreshTokens) RevokeUser(ctx context.Context, userID uint64) error {
+	return errors.New("Refresh tokens cannot be revoked")
+}
+
+// request send a request with a json body, authenticated by the token when informed
+func (api *api) request(method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	var payload bytes.Buffer
+
+	if body != nil {
+		if error := json.NewEncoder(&payload).Encode(body); error != nil {
+			api.t.Fatal(error)
+		}
+	}
+
+	request := httptest.NewRequest(method, path, &payload)
+	request.Header.Set("Content-Type", "application/json")
+
+	if token != "" {
+		request.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return api.serve(request)
+}
+
+// form send a form encoded request, as the oauth endpoints expect
+func (api *api) form(path string, values url.Values) *httptest.ResponseRecorder {
+	api.t.Helper()
+
+	request := httptest.NewRequest(http.MethodPost, path, strings.NewReader(values.Encode()))
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return api.serve(request)
+}
+
+func (api *api) serve(request *http.Request) *httptest.ResponseRecorder {
+	response := httptest.NewRecorder()
+
+	api.handler.ServeHTTP(response, request)
+
+	return response
+}
+
+// register create a user and returns it as answered by the api
+func (api *api) register(nick string) models.User {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, "/users", "", map[string]string{
+		"name":     strings.Title(nick),
+		"nick":     nick,
+		"email":    nick + "@devbook.test",
+		"password": password,
+	})
+
+	expectStatus(api.t, response, http.StatusCreated)
+
+	var user models.User
+	decode(api.t, response, &user)
+
+	return user
+}
+
+// login authenticate with email and password
+func (api *api) login(email string) models.LoginResponse {
+	api.t.Helper()
+
+	response := api.request(http.MethodPost, "/login", "", models.Credentials{Email: email, Password: password})
+
+	expectStatus(api.t, response, http.StatusOK)
+
+	var login models.LoginResponse
+	decode(api.t, response, &login)
+
+	return login
+}
+
+// signUp register a user and log it in
+func (api *api) signUp(nick string) account {
+	api.t.Helper()
+
+	user := api.register(nick)
+	login := api.login(user.Email)
+
+	return account{
+		ID:           user.ID,
+		Nick:         user.Nick,
+		Email:        user.Email,
+		Token:        login.AccessToken,
+		RefreshToken: login.RefreshToken,
+	}
+}
+
+// signUpAdmin register a user with the admin role, logged in after the promotion so the
+// token carries the role
+func (api *api) signUpAdmin(nick string) account {
+	api.t.Helper()
+
+	user := api.register(nick)
+
+	if error := api.store.Users().UpdateRoles(context.Background(), user.ID, []string{"user", "admin"}); error != nil {
+		api.t.Fatal(error)
+	}
+
+	login := api.login(user.Email)
+
+	return account{ID: user.ID, Nick: user.Nick, Email: user.Email, Token: login.AccessToken, RefreshToken: login.RefreshToken}
+}
+
+// lastToken returns the token of the last email sent to the address with the subject
+func (api *api) lastToken(email, subject string) string {
+	api.t.Helper()
+
+	messages := api.outbox.Messages()
+
+	for index := len(messages) - 1; index >= 0; index-- {
+		if messages[index].To != email || messages[index].Subject != subject {
+			continue
+		}
+
+		match := mailedToken.FindStringSubmatch(messages[index].Body)
+
+		if match == nil {
+			api.t.Fatalf("No token in the email %q", messages[index].Body)
+		}
+
+		token, error := url.QueryUnescape(match[1])
+
+		if error != nil {
+			api.t.Fatal(error)
+		}
+
+		return token
+	}
+
+	api.t.Fatalf("No email %q sent to %s", subject, email)
+
+	return ""
+}
+
+// expectStatus fail the test when the response has another status
+func expectStatus(t *testing.T, response *httptest.ResponseRecorder, status int) {
+	t.Helper()
+
+	if response.Code != status {
+		t.Fatalf("Expected status %d, got %d: %s", status, response.Code, response.Body.String())
+	}
+}
+
+// decode read the json body of the response
+func decode(t *testing.T, response *httptest.ResponseRecorder, value interface{}) {
+	t.Helper()
+
+	if error := json.Unmarshal(response.Body.Bytes(), value); error != nil {
+		t.Fatalf("Invalid json %q: %v", response.Body.String(), error)
+	}
+}
+
+func userPath(ID uint64, suffix string) string {
+	return fmt.Sprintf("/users/%d%s", ID, suffix)
+}
+
+func TestProtectedRoutesRequireAuthentication(t *testing.T) {
+	api := newAPI(t)
+
+	protected := []struct {
+		method string
+		path   string
+	}{
+		{http.MethodGet, "/users"},
+		{http.MethodGet, "/users/1"},
+		{http.MethodPut, "/users/1"},
+		{http.MethodDelete, "/users/1"},
+		{http.MethodPost, "/users/1/follow"},
+		{http.MethodPost, "/users/1/unfollow"},
+		{http.MethodGet, "/users/1/followers"},
+		{http.MethodGet, "/users/1/following"},
+		{http.MethodPost, "/users/1/updatePassword"},
+		{http.MethodPost, "/users/1/mfa/enroll"},
+		{http.MethodPost, "/users/1/mfa/confirm"},
+		{http.MethodPost, "/users/1/mfa/disable"},
+		{http.MethodPost, "/users/1/api-keys"},
+		{http.MethodGet, "/users/1/api-keys"},
+		{http.MethodDelete, "/users/1/api-keys/1"},
+		{http.MethodGet, "/users/1/sessions"},
+		{http.MethodDelete, "/users/1/sessions/1"},
+		{http.MethodPost, "/logout"},
+		{http.MethodPost, "/logout/all"},
+		{http.MethodPost, "/auth/verify-email/resend"},
+		{http.MethodPost, "/publications"},
+		{http.MethodGet, "/publications"},
+		{http.MethodGet, "/publications/1"},
+		{http.MethodPut, "/publications/1"},
+		{http.MethodDelete, "/publications/1"},
+		{http.MethodGet, "/publications/1/publications"},
+		{http.MethodPost, "/publications/1/like"},
+		{http.MethodPost, "/publications/1/unlike"},
+		{http.MethodGet, "/admin/users"},
+		{http.MethodPut, "/admin/users/1/roles"},
+		{http.MethodPost, "/admin/users/1/suspend"},
+		{http.MethodPost, "/admin/users/1/unsuspend"},
+		{http.MethodDelete, "/admin/users/1"},
+		{http.MethodGet, "/admin/publications"},
+		{http.MethodDelete, "/admin/publications/1"},
+		{http.MethodPost, "/admin/users/1/impersonate"},
+		{http.MethodGet, "/admin/database/stats"},
+		{http.MethodPost, "/oauth/clients"},
+		{http.MethodGet, "/oauth/clients"},
+		{http.MethodDelete, "/oauth/clients/client"},
+		{http.MethodGet, "/oauth/authorize"},
+		{http.MethodPost, "/oauth/authorize"},
+	}
+
+	for _, route := range protected {
+		response := api.request(route.method, route.path, "", nil)
+
+		if response.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s answered %d without credentials", route.method, route.path, response.Code)
+		}
+	}
+}
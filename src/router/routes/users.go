@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"api/src/authentication"
 	"api/src/controllers"
 	"net/http"
 )
@@ -17,53 +18,118 @@ var usersRoutes = []Route{
 		Method:                 http.MethodGet,
 		Function:               controllers.GetUsers,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersRead},
 	},
 	{
 		URI:                    "/users/{userId}",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetUser,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersRead},
 	},
 	{
 		URI:                    "/users/{userId}",
 		Method:                 http.MethodPut,
 		Function:               controllers.UpdateUser,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersWrite},
+		Sensitive:              true,
 	},
 	{
 		URI:                    "/users/{userId}",
 		Method:                 http.MethodDelete,
 		Function:               controllers.DeleteUser,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersWrite},
+		Sensitive:              true,
 	},
 	{
 		URI:                    "/users/{userID}/follow",
 		Method:                 http.MethodPost,
 		Function:               controllers.FollowUser,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersWrite},
 	},
 	{
 		URI:                    "/users/{userID}/unfollow",
 		Method:                 http.MethodPost,
 		Function:               controllers.UnFollowUser,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersWrite},
 	},
 	{
 		URI:                    "/users/{userID}/followers",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetFollowers,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersRead},
 	},
 	{
 		URI:                    "/users/{userID}/following",
 		Method:                 http.MethodGet,
 		Function:               controllers.GetFollowing,
 		RequiresAuthentication: true,
+		Scopes:                 []string{authentication.ScopeUsersRead},
 	},
 	{
-		URI: "/users/{userID}/updatePassword",
-		Method: http.MethodPost,
-		Function: controllers.UpdatePassword,
+		URI:                    "/users/{userID}/updatePassword",
+		Method:                 http.MethodPost,
+		Function:               controllers.UpdatePassword,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/users/{userID}/mfa/enroll",
+		Method:                 http.MethodPost,
+		Function:               controllers.EnrollMFA,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/users/{userID}/mfa/confirm",
+		Method:                 http.MethodPost,
+		Function:               controllers.ConfirmMFA,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/users/{userID}/mfa/disable",
+		Method:                 http.MethodPost,
+		Function:               controllers.DisableMFA,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/users/{userID}/api-keys",
+		Method:                 http.MethodPost,
+		Function:               controllers.CreateAPIKey,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/users/{userID}/api-keys",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListAPIKeys,
+		RequiresAuthentication: true,
+	},
+	{
+		URI:                    "/users/{userID}/api-keys/{apiKeyId}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.RevokeAPIKey,
+		RequiresAuthentication: true,
+		Sensitive:              true,
+	},
+	{
+		URI:                    "/users/{userID}/sessions",
+		Method:                 http.MethodGet,
+		Function:               controllers.ListSessions,
+		RequiresAuthentication: true,
+	},
+	{
+		URI:                    "/users/{userID}/sessions/{sessionId}",
+		Method:                 http.MethodDelete,
+		Function:               controllers.RevokeSession,
 		RequiresAuthentication: true,
+		Sensitive:              true,
 	},
 }
@@ -0,0 +1,216 @@
+package routes_test
+
+import (
+	"api/src/models"
+	"net/http"
+	"testing"
+)
+
+func TestCreateUser(t *testing.T) {
+	api := newAPI(t)
+
+	user := api.register("maria")
+
+	if user.ID == 0 || user.Nick != "maria" || user.Email != "maria@devbook.test" {
+		t.Fatalf("Unexpected user %+v", user)
+	}
+
+	response := api.request(http.MethodPost, "/users", "", map[string]string{
+		"name":     "Maria",
+		"nick":     "maria2",
+		"email":    "MARIA@devbook.test",
+		"password": password,
+	})
+
+	if response.Code == http.StatusCreated {
+		t.Fatal("Registered an email twice differing only by case")
+	}
+
+	response = api.request(http.MethodPost, "/users", "", map[string]string{"name": "Nobody"})
+
+	expectStatus(t, response, http.StatusBadRequest)
+}
+
+func TestSearchAndGetUsers(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	api.register("mariana")
+	api.register("joao")
+
+	response := api.request(http.MethodGet, "/users?user=MARI", maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var users []models.User
+	decode(t, response, &users)
+
+	if len(users) != 2 || users[0].Nick != "maria" || users[1].Nick != "mariana" {
+		t.Fatalf("Unexpected search result %+v", users)
+	}
+
+	response = api.request(http.MethodGet, userPath(maria.ID, ""), maria.Token, nil)
+
+	expectStatus(t, response, http.StatusOK)
+
+	var user models.User
+	decode(t, response, &user)
+
+	if user.ID != maria.ID || user.Password != "" {
+		t.Fatalf("Unexpected user %+v", user)
+	}
+
+	response = api.request(http.MethodGet, "/users/abc", maria.Token, nil)
+
+	expectStatus(t, response, http.StatusBadRequest)
+}
+
+func TestUpdateUser(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	changes := map[string]string{"name": "Maria Silva", "nick": "mariasilva", "email": "silva@devbook.test"}
+
+	response := api.request(http.MethodPut, userPath(maria.ID, ""), joao.Token, changes)
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	response = api.request(http.MethodPut, userPath(maria.ID, ""), maria.Token, changes)
+
+	expectStatus(t, response, http.StatusNoContent)
+
+	var user models.User
+	decode(t, api.request(http.MethodGet, userPath(maria.ID, ""), maria.Token, nil), &user)
+
+	if user.Name != "Maria Silva" || user.Nick != "mariasilva" || user.Email != "silva@devbook.test" {
+		t.Fatalf("User was not updated %+v", user)
+	}
+
+	// The new address must be confirmed again
+	api.lastToken("silva@devbook.test", "Confirm your email")
+}
+
+func TestDeleteUser(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	response := api.request(http.MethodDelete, userPath(maria.ID, ""), joao.Token, nil)
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	response = api.request(http.MethodDelete, userPath(maria.ID, ""), maria.Token, nil)
+
+	expectStatus(t, response, http.StatusNoContent)
+
+	var user models.User
+	decode(t, api.request(http.MethodGet, userPath(maria.ID, ""), joao.Token, nil), &user)
+
+	if user.ID != 0 {
+		t.Fatalf("Deleted user still found %+v", user)
+	}
+
+	// The logins of the account end with it
+	expectStatus(t, api.request(http.MethodGet, "/publications", maria.Token, nil), http.StatusUnauthorized)
+	expectStatus(t, api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken}), http.StatusUnauthorized)
+}
+
+func TestFollowUsers(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	response := api.request(http.MethodPost, userPath(maria.ID, "/follow"), maria.Token, nil)
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	for range []int{1, 2} {
+		response = api.request(http.MethodPost, userPath(maria.ID, "/follow"), joao.Token, nil)
+
+		expectStatus(t, response, http.StatusNoContent)
+	}
+
+	var followers, following []models.User
+
+	decode(t, api.request(http.MethodGet, userPath(maria.ID, "/followers"), joao.Token, nil), &followers)
+	decode(t, api.request(http.MethodGet, userPath(joao.ID, "/following"), joao.Token, nil), &following)
+
+	if len(followers) != 1 || followers[0].ID != joao.ID {
+		t.Fatalf("Unexpected followers %+v", followers)
+	}
+
+	if len(following) != 1 || following[0].ID != maria.ID {
+		t.Fatalf("Unexpected following %+v", following)
+	}
+
+	response = api.request(http.MethodPost, userPath(maria.ID, "/unfollow"), maria.Token, nil)
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	response = api.request(http.MethodPost, userPath(maria.ID, "/unfollow"), joao.Token, nil)
+
+	expectStatus(t, response, http.StatusNoContent)
+
+	followers = nil
+	decode(t, api.request(http.MethodGet, userPath(maria.ID, "/followers"), joao.Token, nil), &followers)
+
+	if len(followers) != 0 {
+		t.Fatalf("Unfollowed user still listed %+v", followers)
+	}
+}
+
+func TestUpdatePassword(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+	joao := api.signUp("joao")
+
+	newPassword := "An0ther-Secret"
+
+	response := api.request(http.MethodPost, userPath(maria.ID, "/updatePassword"), joao.Token,
+		models.Password{CurrentPassword: password, NewPassword: newPassword})
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	response = api.request(http.MethodPost, userPath(maria.ID, "/updatePassword"), maria.Token,
+		models.Password{CurrentPassword: "wrong", NewPassword: newPassword})
+
+	expectStatus(t, response, http.StatusForbidden)
+
+	response = api.request(http.MethodPost, userPath(maria.ID, "/updatePassword"), maria.Token,
+		models.Password{CurrentPassword: password, NewPassword: newPassword})
+
+	expectStatus(t, response, http.StatusNoContent)
+
+	// The sessions opened with the old password end
+	response = api.request(http.MethodPost, "/auth/refresh", "", models.RefreshRequest{RefreshToken: maria.RefreshToken})
+
+	expectStatus(t, response, http.StatusUnauthorized)
+
+	response = api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: newPassword})
+
+	expectStatus(t, response, http.StatusOK)
+}
+
+func TestUpdatePasswordRolledBack(t *testing.T) {
+	api := newAPI(t)
+
+	maria := api.signUp("maria")
+
+	api.failRefreshTokens(true)
+
+	response := api.request(http.MethodPost, userPath(maria.ID, "/updatePassword"), maria.Token,
+		models.Password{CurrentPassword: password, NewPassword: "An0ther-Secret"})
+
+	expectStatus(t, response, http.StatusInternalServerError)
+
+	api.failRefreshTokens(false)
+
+	// Nothing of the change is kept, the logins made with the password go on
+	expectStatus(t, api.request(http.MethodGet, "/publications", maria.Token, nil), http.StatusOK)
+	expectStatus(t, api.request(http.MethodPost, "/login", "", models.Credentials{Email: maria.Email, Password: password}), http.StatusOK)
+}
@@ -0,0 +1,116 @@
+package security
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
+	"os"
+	"strings"
+)
+
+// hashPrefixSize is the number of hex digits of the hash ranges, as in the range api of
+// Have I Been Pwned
+const hashPrefixSize = 5
+
+// BreachedList checks passwords against a local file of leaked sha1 hashes, one
+// "HASH" or "HASH:COUNT" per line sorted by hash, like the ordered by hash downloads of
+// Have I Been Pwned. The file is indexed by the first hex digits of the hashes and a check
+// only reads the range sharing the prefix of the password hash, never the whole list
+type BreachedList struct {
+	path   string
+	ranges map[string]hashRange
+}
+
+type hashRange struct {
+	start int64
+	end   int64
+}
+
+// NewBreachedList index the ranges of a breached password file
+func NewBreachedList(path string) (*BreachedList, error) {
+	file, error := os.Open(path)
+
+	if error != nil {
+		return nil, error
+	}
+
+	defer file.Close()
+
+	ranges := map[string]hashRange{}
+	reader := bufio.NewReader(file)
+
+	var (
+		offset  int64
+		current string
+		start   int64
+	)
+
+	for {
+		line, error := reader.ReadString('\n')
+
+		if len(line) >= hashPrefixSize {
+			prefix := strings.ToUpper(line[:hashPrefixSize])
+
+			if prefix != current {
+				if current != "" {
+					ranges[current] = hashRange{start, offset}
+				}
+
+				current, start = prefix, offset
+			}
+		}
+
+		offset += int64(len(line))
+
+		if error == io.EOF {
+			break
+		}
+
+		if error != nil {
+			return nil, error
+		}
+	}
+
+	if current != "" {
+		ranges[current] = hashRange{start, offset}
+	}
+
+	return &BreachedList{path: path, ranges: ranges}, nil
+}
+
+// Contains report if the sha1 hash of the password is in the list
+func (list *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	hashes, exists := list.ranges[hash[:hashPrefixSize]]
+
+	if !exists {
+		return false, nil
+	}
+
+	file, error := os.Open(list.path)
+
+	if error != nil {
+		return false, error
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(io.NewSectionReader(file, hashes.start, hashes.end-hashes.start))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if separator := strings.Index(line, ":"); separator >= 0 {
+			line = line[:separator]
+		}
+
+		if strings.EqualFold(line, hash) {
+			return true, nil
+		}
+	}
+
+	return false, scanner.Err()
+}
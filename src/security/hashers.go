@@ -0,0 +1,165 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrMismatchedPassword is returned when a password does not match its hash
+var ErrMismatchedPassword = errors.New("Password does not match")
+
+// Hasher hashes passwords with an algorithm and its parameters, the hashes carry both so
+// they can still be checked after the parameters change
+type Hasher interface {
+	// Hash returns the hash of a password
+	Hash(password string) (string, error)
+	// Recognizes report if the hash was made by the algorithm of the hasher
+	Recognizes(hash string) bool
+	// Compare check a password against a hash recognized by the hasher
+	Compare(hash, password string) error
+	// Current report if the hash was made with the parameters of the hasher
+	Current(hash string) bool
+}
+
+// BcryptHasher hashes passwords with bcrypt
+type BcryptHasher struct {
+	Cost int
+}
+
+// Hash returns the bcrypt hash of a password
+func (hasher BcryptHasher) Hash(password string) (string, error) {
+	hash, error := bcrypt.GenerateFromPassword([]byte(password), hasher.Cost)
+
+	return string(hash), error
+}
+
+// Recognizes report if the hash is a bcrypt hash
+func (hasher BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// Compare check a password against a bcrypt hash
+func (hasher BcryptHasher) Compare(hash, password string) error {
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
+
+// Current report if the hash is a bcrypt hash of the cost of the hasher
+func (hasher BcryptHasher) Current(hash string) bool {
+	cost, error := bcrypt.Cost([]byte(hash))
+
+	return error == nil && cost == hasher.Cost
+}
+
+// Argon2idHasher hashes passwords with argon2id, the hashes are written in the PHC
+// string format like $argon2id$v=19$m=19456,t=2,p=1$salt$key
+type Argon2idHasher struct {
+	// Memory in KiB
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// Hash returns the argon2id hash of a password with a random salt
+func (hasher Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, hasher.SaltLength)
+
+	if _, error := rand.Read(salt); error != nil {
+		return "", error
+	}
+
+	key := argon2.IDKey([]byte(password), salt, hasher.Iterations, hasher.Memory, hasher.Parallelism, hasher.KeyLength)
+
+	return fmt.Sprintf(
+		"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version,
+		hasher.Memory,
+		hasher.Iterations,
+		hasher.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Recognizes report if the hash is an argon2id hash
+func (hasher Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// Compare check a password against an argon2id hash, with the parameters written in the hash
+func (hasher Argon2idHasher) Compare(hash, password string) error {
+	parameters, salt, key, error := parseArgon2id(hash)
+
+	if error != nil {
+		return error
+	}
+
+	candidate := argon2.IDKey([]byte(password), salt, parameters.Iterations, parameters.Memory, parameters.Parallelism, uint32(len(key)))
+
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return ErrMismatchedPassword
+	}
+
+	return nil
+}
+
+// Current report if the hash was made with the parameters of the hasher
+func (hasher Argon2idHasher) Current(hash string) bool {
+	parameters, salt, key, error := parseArgon2id(hash)
+
+	return error == nil &&
+		parameters.Memory == hasher.Memory &&
+		parameters.Iterations == hasher.Iterations &&
+		parameters.Parallelism == hasher.Parallelism &&
+		uint32(len(salt)) == hasher.SaltLength &&
+		uint32(len(key)) == hasher.KeyLength
+}
+
+// parseArgon2id read the parameters, salt and key of an argon2id hash
+func parseArgon2id(hash string) (Argon2idHasher, []byte, []byte, error) {
+	parts := strings.Split(hash, "$")
+
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2idHasher{}, nil, nil, errors.New("Invalid argon2id hash")
+	}
+
+	var version int
+
+	if _, error := fmt.Sscanf(parts[2], "v=%d", &version); error != nil || version != argon2.Version {
+		return Argon2idHasher{}, nil, nil, errors.New("Unsupported argon2id version")
+	}
+
+	var parameters Argon2idHasher
+
+	if _, error := fmt.Sscanf(
+		parts[3], "m=%d,t=%d,p=%d", &parameters.Memory, &parameters.Iterations, &parameters.Parallelism,
+	); error != nil || parameters.Iterations == 0 || parameters.Parallelism == 0 {
+		return Argon2idHasher{}, nil, nil, errors.New("Invalid argon2id parameters")
+	}
+
+	salt, error := base64.RawStdEncoding.DecodeString(parts[4])
+
+	if error != nil {
+		return Argon2idHasher{}, nil, nil, error
+	}
+
+	key, error := base64.RawStdEncoding.DecodeString(parts[5])
+
+	if error != nil || len(key) == 0 {
+		return Argon2idHasher{}, nil, nil, errors.New("Invalid argon2id hash")
+	}
+
+	return parameters, salt, key, nil
+}
@@ -0,0 +1,31 @@
+package security
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"regexp"
+)
+
+// PKCEMethodS256 is the only code challenge method accepted, plain challenges would
+// give away the verifier to whoever sees the authorization request
+const PKCEMethodS256 = "S256"
+
+// codeVerifierFormat is the format of a verifier defined by RFC 7636
+var codeVerifierFormat = regexp.MustCompile(`^[A-Za-z0-9\-._~]{43,128}$`)
+
+// PKCEChallenge returns the S256 challenge of a code verifier
+func PKCEChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// VerifyPKCE check a code verifier against the challenge sent on the authorization request
+func VerifyPKCE(verifier, challenge, method string) bool {
+	if method != PKCEMethodS256 || !codeVerifierFormat.MatchString(verifier) {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(PKCEChallenge(verifier)), []byte(challenge)) == 1
+}
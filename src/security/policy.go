@@ -0,0 +1,96 @@
+package security
+
+import (
+	"api/src/config"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxPasswordBytes is the longest password bcrypt can tell apart, the bytes past it are ignored
+const MaxPasswordBytes = 72
+
+// ValidatePassword check a new password against the policy of the configuration and
+// return every rule it breaks. The personal values, like the nick and email of the
+// user, cannot be part of the password
+func ValidatePassword(password string, personal ...string) ([]string, error) {
+	problems := []string{}
+
+	if utf8.RuneCountInString(password) < config.PasswordMinLength {
+		problems = append(problems, fmt.Sprintf("Must have at least %d characters", config.PasswordMinLength))
+	}
+
+	if len(password) > MaxPasswordBytes {
+		problems = append(problems, fmt.Sprintf("Cannot have more than %d bytes", MaxPasswordBytes))
+	}
+
+	if characterClasses(password) < config.PasswordMinClasses {
+		problems = append(problems, fmt.Sprintf(
+			"Must mix at least %d of lowercase letters, uppercase letters, digits and symbols",
+			config.PasswordMinClasses,
+		))
+	}
+
+	if containsPersonal(password, personal) {
+		problems = append(problems, "Cannot contain your nick or email")
+	}
+
+	if Breached != nil && len(problems) == 0 {
+		breached, error := Breached.Contains(password)
+
+		if error != nil {
+			return nil, error
+		}
+
+		if breached {
+			problems = append(problems, "Appears in a list of leaked passwords, choose another one")
+		}
+	}
+
+	return problems, nil
+}
+
+// characterClasses count the kinds of characters used among lowercase letters,
+// uppercase letters, digits and anything else
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol int
+
+	for _, character := range password {
+		switch {
+		case unicode.IsLower(character):
+			lower = 1
+		case unicode.IsUpper(character):
+			upper = 1
+		case unicode.IsDigit(character):
+			digit = 1
+		default:
+			symbol = 1
+		}
+	}
+
+	return lower + upper + digit + symbol
+}
+
+// containsPersonal report if the password contains one of the values, or the local part
+// of an email, ignoring case. Values shorter than three characters are not considered
+func containsPersonal(password string, personal []string) bool {
+	password = strings.ToLower(password)
+
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		candidates := []string{value}
+
+		if at := strings.Index(value, "@"); at > 0 {
+			candidates = append(candidates, value[:at])
+		}
+
+		for _, candidate := range candidates {
+			if len(candidate) >= 3 && strings.Contains(password, candidate) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
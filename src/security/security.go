@@ -1,13 +1,152 @@
 package security
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"api/src/config"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// CurrentHasher hashes the new passwords
+	CurrentHasher Hasher = Argon2idHasher{Memory: 19456, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	// Breached is the list of leaked passwords new passwords are checked against, nil when
+	// no list is configured
+	Breached *BreachedList
+
+	// hashers recognize every kind of hash stored, whatever the current hasher
+	hashers = []Hasher{Argon2idHasher{}, BcryptHasher{}}
+
+	// legacyHasher made the hashes stored before passwords were hashed by CurrentHasher, they
+	// are only replaced as their users log in
+	legacyHasher Hasher = BcryptHasher{Cost: bcrypt.DefaultCost}
+
+	dummyHash     string
+	dummyHashOnce sync.Once
+)
+
+// Configure select the password hasher and load the breached password list of the configuration
+func Configure() error {
+	switch config.PasswordHasher {
+	case "argon2id":
+		if error := checkArgon2Parameters(config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism); error != nil {
+			return error
+		}
+
+		CurrentHasher = Argon2idHasher{
+			Memory:      uint32(config.Argon2Memory),
+			Iterations:  uint32(config.Argon2Iterations),
+			Parallelism: uint8(config.Argon2Parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+	case "bcrypt":
+		if config.BcryptCost < bcrypt.MinCost || config.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("Bcrypt cost must be between %d and %d, got %d", bcrypt.MinCost, bcrypt.MaxCost, config.BcryptCost)
+		}
+
+		CurrentHasher = BcryptHasher{Cost: config.BcryptCost}
+	default:
+		return fmt.Errorf("Unknown password hasher %s", config.PasswordHasher)
+	}
+
+	dummyHashOnce = sync.Once{}
+
+	Breached = nil
+
+	if config.BreachedPasswordsFile == "" {
+		return nil
+	}
+
+	list, error := NewBreachedList(config.BreachedPasswordsFile)
+
+	if error != nil {
+		return error
+	}
+
+	Breached = list
+
+	return nil
+}
+
+// checkArgon2Parameters report parameters argon2id cannot hash with, it panics on zero
+// iterations or parallelism and the values must fit the types of Argon2idHasher
+func checkArgon2Parameters(memory, iterations, parallelism int) error {
+	if parallelism < 1 || parallelism > math.MaxUint8 {
+		return fmt.Errorf("Argon2 parallelism must be between 1 and %d, got %d", math.MaxUint8, parallelism)
+	}
+
+	if iterations < 1 || int64(iterations) > math.MaxUint32 {
+		return fmt.Errorf("Argon2 iterations must be between 1 and %d, got %d", uint32(math.MaxUint32), iterations)
+	}
+
+	if memory < 8*parallelism || int64(memory) > math.MaxUint32 {
+		return fmt.Errorf("Argon2 memory must be between %d and %d KiB, got %d", 8*parallelism, uint32(math.MaxUint32), memory)
+	}
+
+	return nil
+}
 
 // Hash receive a string and put a hash
 func Hash(password string) ([]byte, error) {
-	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	hash, error := CurrentHasher.Hash(password)
+
+	return []byte(hash), error
 }
 
-// Check password compare password and hash
+// Check password compare password and hash, whichever hasher made the hash
 func CheckPassword(stringHash, stringPassword string) error {
-	return bcrypt.CompareHashAndPassword([]byte(stringHash), []byte(stringPassword))
+	for _, hasher := range hashers {
+		if hasher.Recognizes(stringHash) {
+			return hasher.Compare(stringHash, stringPassword)
+		}
+	}
+
+	return errors.New("Unknown password hash")
+}
+
+// NeedsRehash report if a hash was not made by the current hasher with its current
+// parameters, the password should then be hashed again while it is known
+func NeedsRehash(stringHash string) bool {
+	return !CurrentHasher.Recognizes(stringHash) || !CurrentHasher.Current(stringHash)
+}
+
+// SimulatePasswordCheck spends the time of a password comparison, used when there is no
+// user to compare against so the answer does not reveal whether the account exists. The
+// comparison is against the slowest kind of hash stored, as accounts can hold either kind
+func SimulatePasswordCheck(stringPassword string) {
+	dummyHashOnce.Do(func() {
+		dummyHash = slowestHash(CurrentHasher, legacyHasher)
+	})
+
+	CheckPassword(dummyHash, stringPassword)
+}
+
+// slowestHash returns a hash made by the hasher taking the longest to compare a password
+func slowestHash(candidates ...Hasher) string {
+	var (
+		slowest string
+		longest time.Duration
+	)
+
+	for _, hasher := range candidates {
+		hash, error := hasher.Hash("simulated password")
+
+		if error != nil {
+			continue
+		}
+
+		start := time.Now()
+		hasher.Compare(hash, "wrong password")
+
+		if elapsed := time.Since(start); slowest == "" || elapsed > longest {
+			slowest, longest = hash, elapsed
+		}
+	}
+
+	return slowest
 }
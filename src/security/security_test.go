@@ -0,0 +1,55 @@
+package security_test
+
+import (
+	"api/src/config"
+	"api/src/security"
+	"testing"
+)
+
+func TestConfigureRejectsInvalidArgon2Parameters(t *testing.T) {
+	hasher, memory, iterations, parallelism := config.PasswordHasher, config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism
+	current := security.CurrentHasher
+
+	defer func() {
+		config.PasswordHasher, config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism = hasher, memory, iterations, parallelism
+		security.CurrentHasher = current
+	}()
+
+	config.PasswordHasher = "argon2id"
+
+	for _, parameters := range []struct{ memory, iterations, parallelism int }{
+		{19456, 2, 0},
+		{19456, 2, 256},
+		{19456, 0, 1},
+		{4, 2, 1},
+		{-1, 2, 1},
+		{1 << 32, 2, 1},
+	} {
+		config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism = parameters.memory, parameters.iterations, parameters.parallelism
+
+		if error := security.Configure(); error == nil {
+			t.Errorf("Expected an error for %+v", parameters)
+		}
+	}
+
+	config.Argon2Memory, config.Argon2Iterations, config.Argon2Parallelism = 64, 1, 8
+
+	if error := security.Configure(); error != nil {
+		t.Fatal(error)
+	}
+
+	if _, error := security.Hash("correct horse battery staple"); error != nil {
+		t.Fatal(error)
+	}
+}
+
+func TestCheckPasswordRejectsZeroArgon2Parameters(t *testing.T) {
+	for _, hash := range []string{
+		"$argon2id$v=19$m=19456,t=0,p=1$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+		"$argon2id$v=19$m=19456,t=2,p=0$c2FsdHNhbHRzYWx0$a2V5a2V5a2V5a2V5",
+	} {
+		if error := security.CheckPassword(hash, "password"); error == nil {
+			t.Errorf("Expected an error for %s", hash)
+		}
+	}
+}
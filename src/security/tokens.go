@@ -0,0 +1,62 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// GenerateToken returns a random url safe token with size bytes of entropy
+func GenerateToken(size int) (string, error) {
+	bytes := make([]byte, size)
+
+	if _, error := rand.Read(bytes); error != nil {
+		return "", error
+	}
+
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// HashToken returns the sha256 digest of a token, the only form in which tokens are stored
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+
+	return hex.EncodeToString(sum[:])
+}
+
+// apiKeyMarker starts every api key, so leaked keys are easy to recognize
+const apiKeyMarker = "sk"
+
+// GenerateAPIKey returns a new api key like "sk_<prefix>_<secret>", where the prefix
+// is public and used to find the key
+func GenerateAPIKey() (key string, prefix string, error error) {
+	prefixBytes := make([]byte, 4)
+
+	if _, error = rand.Read(prefixBytes); error != nil {
+		return "", "", error
+	}
+
+	secret, error := GenerateToken(24)
+
+	if error != nil {
+		return "", "", error
+	}
+
+	prefix = hex.EncodeToString(prefixBytes)
+
+	return fmt.Sprintf("%s_%s_%s", apiKeyMarker, prefix, secret), prefix, nil
+}
+
+// APIKeyPrefix extract the public prefix of an api key
+func APIKeyPrefix(key string) (string, bool) {
+	parts := strings.SplitN(key, "_", 3)
+
+	if len(parts) != 3 || parts[0] != apiKeyMarker || len(parts[1]) != 8 || parts[2] == "" {
+		return "", false
+	}
+
+	return parts[1], true
+}
@@ -0,0 +1,114 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parameters of the RFC 6238 codes, the ones every authenticator app supports
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew is how many periods before and after now are accepted, for clock drift
+	totpSkew = 1
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a random base32 secret of 160 bits
+func GenerateTOTPSecret() (string, error) {
+	secret := make([]byte, 20)
+
+	if _, error := rand.Read(secret); error != nil {
+		return "", error
+	}
+
+	return totpEncoding.EncodeToString(secret), nil
+}
+
+// TOTPURI returns the otpauth uri authenticator apps read from a QR code
+func TOTPURI(issuer, account, secret string) string {
+	query := url.Values{}
+	query.Set("secret", secret)
+	query.Set("issuer", issuer)
+	query.Set("algorithm", "SHA1")
+	query.Set("digits", fmt.Sprint(totpDigits))
+	query.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(issuer + ":" + account)
+
+	return fmt.Sprintf("otpauth://totp/%s?%s", label, query.Encode())
+}
+
+// TOTPCode computes the code of a secret for the period containing the moment
+func TOTPCode(secret string, moment time.Time) (string, error) {
+	return totpCode(secret, moment.Unix()/totpPeriod)
+}
+
+// ValidateTOTP check a code against the periods around the moment, returning the matched
+// period so the caller can refuse a code being used twice
+func ValidateTOTP(secret, code string, moment time.Time) (int64, bool) {
+	current := moment.Unix() / totpPeriod
+
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		expected, error := totpCode(secret, step)
+
+		if error != nil {
+			return 0, false
+		}
+
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+func totpCode(secret string, step int64) (string, error) {
+	key, error := totpEncoding.DecodeString(strings.ToUpper(secret))
+
+	if error != nil {
+		return "", error
+	}
+
+	counter := make([]byte, 8)
+	binary.BigEndian.PutUint64(counter, uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation of RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+
+	for digit := 0; digit < totpDigits; digit++ {
+		modulo *= 10
+	}
+
+	return fmt.Sprintf("%0*d", totpDigits, value%modulo), nil
+}
+
+// GenerateRecoveryCode returns a random code like "abcd-efgh-ijkl-mnop" with 80 bits of entropy
+func GenerateRecoveryCode() (string, error) {
+	bytes := make([]byte, 10)
+
+	if _, error := rand.Read(bytes); error != nil {
+		return "", error
+	}
+
+	code := strings.ToLower(totpEncoding.EncodeToString(bytes))
+
+	return strings.Join([]string{code[0:4], code[4:8], code[8:12], code[12:16]}, "-"), nil
+}
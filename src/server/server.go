@@ -0,0 +1,75 @@
+// Package server runs the api over http until the process is asked to stop
+package server
+
+import (
+	"api/src/config"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// New returns a server of the handler on the api port, with the limits set in config so slow
+// clients cannot hold connections forever
+func New(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", config.Port),
+		Handler:           handler,
+		ReadTimeout:       config.ReadTimeout,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		WriteTimeout:      config.WriteTimeout,
+		IdleTimeout:       config.IdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+}
+
+// Run serve until SIGINT or SIGTERM, then stop accepting connections and give the requests in
+// progress up to config.ShutdownTimeout to finish. The hooks run in order once the server
+// stopped, to release what the requests used, like flushing workers and closing the database
+func Run(server *http.Server, hooks ...func()) error {
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	served := make(chan error, 1)
+
+	go func() {
+		served <- server.ListenAndServe()
+	}()
+
+	var error error
+
+	select {
+	case error = <-served:
+	case received := <-stop:
+		log.Printf("Received %s, waiting up to %s for the requests in progress", received, config.ShutdownTimeout)
+
+		error = shutdown(server)
+	}
+
+	for _, hook := range hooks {
+		hook()
+	}
+
+	if error == http.ErrServerClosed {
+		return nil
+	}
+
+	return error
+}
+
+// shutdown drain the connections of the server, closing the ones still busy at the deadline
+func shutdown(server *http.Server) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+
+	if error := server.Shutdown(ctx); error != nil {
+		server.Close()
+		return fmt.Errorf("Requests still in progress were cut: %v", error)
+	}
+
+	return nil
+}
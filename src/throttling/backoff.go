@@ -0,0 +1,116 @@
+package throttling
+
+import (
+	"sync"
+	"time"
+)
+
+// Backoff counts failures by key and, past a threshold, blocks the key for an
+// exponentially growing time
+type Backoff struct {
+	mutex        sync.Mutex
+	entries      map[string]*backoffEntry
+	threshold    int
+	base         time.Duration
+	max          time.Duration
+	lastEviction time.Time
+}
+
+type backoffEntry struct {
+	failures    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// NewBackoff returns a backoff that blocks after threshold failures, first for base and
+// doubling on every new failure up to max
+func NewBackoff(threshold int, base, max time.Duration) *Backoff {
+	return &Backoff{
+		entries:      map[string]*backoffEntry{},
+		threshold:    threshold,
+		base:         base,
+		max:          max,
+		lastEviction: time.Now(),
+	}
+}
+
+// Blocked returns how long the key must still wait, zero when it is not blocked
+func (backoff *Backoff) Blocked(key string) time.Duration {
+	backoff.mutex.Lock()
+	defer backoff.mutex.Unlock()
+
+	entry, exists := backoff.entries[key]
+
+	if !exists {
+		return 0
+	}
+
+	if wait := time.Until(entry.lockedUntil); wait > 0 {
+		return wait
+	}
+
+	return 0
+}
+
+// Fail register a failure of the key and returns for how long it is blocked from now on
+func (backoff *Backoff) Fail(key string) time.Duration {
+	backoff.mutex.Lock()
+	defer backoff.mutex.Unlock()
+
+	backoff.evict()
+
+	entry, exists := backoff.entries[key]
+
+	if !exists {
+		entry = &backoffEntry{}
+		backoff.entries[key] = entry
+	}
+
+	now := time.Now()
+
+	entry.failures++
+	entry.lastFailure = now
+
+	if entry.failures < backoff.threshold {
+		return 0
+	}
+
+	lock := backoff.base
+
+	for step := backoff.threshold; step < entry.failures && lock < backoff.max; step++ {
+		lock *= 2
+	}
+
+	if lock > backoff.max {
+		lock = backoff.max
+	}
+
+	entry.lockedUntil = now.Add(lock)
+
+	return lock
+}
+
+// Reset forget the failures of a key
+func (backoff *Backoff) Reset(key string) {
+	backoff.mutex.Lock()
+	defer backoff.mutex.Unlock()
+
+	delete(backoff.entries, key)
+}
+
+// evict forget the keys without failures for longer than the maximum block, the caller must hold the lock
+func (backoff *Backoff) evict() {
+	now := time.Now()
+
+	if now.Sub(backoff.lastEviction) < time.Minute {
+		return
+	}
+
+	backoff.lastEviction = now
+
+	for key, entry := range backoff.entries {
+		if now.After(entry.lockedUntil) && now.Sub(entry.lastFailure) > backoff.max {
+			delete(backoff.entries, key)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+package throttling
+
+import "api/src/config"
+
+var (
+	// LoginAccounts counts the failed logins of each account
+	LoginAccounts = newLoginAccounts()
+	// LoginAddresses counts the failed logins of each client address
+	LoginAddresses = newLoginAddresses()
+	// MagicLinks counts the magic links sent to each email address
+	MagicLinks = newMagicLinks()
+)
+
+// Configure create the limiters with the thresholds of the configuration
+func Configure() {
+	LoginAccounts = newLoginAccounts()
+	LoginAddresses = newLoginAddresses()
+	MagicLinks = newMagicLinks()
+}
+
+func newLoginAccounts() *Backoff {
+	return NewBackoff(config.LoginAccountFailures, config.LoginLockoutDuration, config.LoginMaxLockoutDuration)
+}
+
+func newLoginAddresses() *Backoff {
+	return NewBackoff(config.LoginAddressFailures, config.LoginLockoutDuration, config.LoginMaxLockoutDuration)
+}
+
+func newMagicLinks() *Window {
+	return NewWindow(config.MagicLinkLimit, config.MagicLinkInterval)
+}
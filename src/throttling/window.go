@@ -0,0 +1,73 @@
+package throttling
+
+import (
+	"sync"
+	"time"
+)
+
+// Window allows a number of hits by key in each period, counted from the first hit
+type Window struct {
+	mutex        sync.Mutex
+	entries      map[string]*windowEntry
+	limit        int
+	period       time.Duration
+	lastEviction time.Time
+}
+
+type windowEntry struct {
+	hits    int
+	resetAt time.Time
+}
+
+// NewWindow returns a window allowing limit hits of a key per period
+func NewWindow(limit int, period time.Duration) *Window {
+	return &Window{
+		entries:      map[string]*windowEntry{},
+		limit:        limit,
+		period:       period,
+		lastEviction: time.Now(),
+	}
+}
+
+// Hit register a hit of the key, it returns zero when the hit is allowed or how long
+// the key must wait otherwise, refused hits are not counted
+func (window *Window) Hit(key string) time.Duration {
+	window.mutex.Lock()
+	defer window.mutex.Unlock()
+
+	window.evict()
+
+	now := time.Now()
+
+	entry, exists := window.entries[key]
+
+	if !exists || now.After(entry.resetAt) {
+		entry = &windowEntry{resetAt: now.Add(window.period)}
+		window.entries[key] = entry
+	}
+
+	if entry.hits >= window.limit {
+		return entry.resetAt.Sub(now)
+	}
+
+	entry.hits++
+
+	return 0
+}
+
+// evict forget the keys whose period is over, the caller must hold the lock
+func (window *Window) evict() {
+	now := time.Now()
+
+	if now.Sub(window.lastEviction) < time.Minute {
+		return
+	}
+
+	window.lastEviction = now
+
+	for key, entry := range window.entries {
+		if now.After(entry.resetAt) {
+			delete(window.entries, key)
+		}
+	}
+}